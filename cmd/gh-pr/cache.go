@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/cmdutil"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/templates"
+)
+
+func cacheCmd(out *output.Writer) *cobra.Command {
+	cmd := cmdutil.CacheCmd(out, templates.OpenCache)
+	cmd.Long = "Manage the pull request template cache"
+	return cmd
+}
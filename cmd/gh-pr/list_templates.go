@@ -2,16 +2,28 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/cmdutil"
 	"go.sbr.pm/x/internal/output"
 	"go.sbr.pm/x/internal/templates"
 )
 
+func init() {
+	output.Register(output.Spec[[]templates.Template, templates.Template]{
+		Items: func(t []templates.Template) []templates.Template { return t },
+		Columns: []output.Column[templates.Template]{
+			{Name: "name", Value: func(t templates.Template) string { return t.Name }},
+			{Name: "path", Value: func(t templates.Template) string { return t.Path }},
+		},
+	})
+}
+
 func listTemplatesCmd(out *output.Writer) *cobra.Command {
 	var (
 		refresh bool
-		verbose bool
+		format  output.FormatOptions
 	)
 
 	cmd := &cobra.Command{
@@ -26,23 +38,25 @@ Examples:
   gh-pr list-templates                    # List templates in current repo
   gh-pr list-templates tektoncd/pipeline  # List templates from remote repo
   gh-pr list-templates --verbose          # Show template previews
-  gh-pr list-templates --refresh          # Bypass cache`,
+  gh-pr list-templates --refresh          # Bypass cache
+  gh-pr list-templates -o json            # Templates with their content as JSON
+  gh-pr list-templates --format '{{.Name}}'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var repo string
 			if len(args) > 0 {
 				repo = args[0]
 			}
-			return runListTemplates(out, repo, refresh, verbose)
+			return runListTemplates(out, repo, refresh, out.Level() >= output.LevelVerbose, format)
 		},
 	}
 
 	cmd.Flags().BoolVar(&refresh, "refresh", false, "Refresh template cache")
-	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show template content preview")
+	cmdutil.AddFormatFlags(cmd, &format, []templates.Template{}, "text")
 
 	return cmd
 }
 
-func runListTemplates(out *output.Writer, repo string, refresh, verbose bool) error {
+func runListTemplates(out *output.Writer, repo string, refresh, verbose bool, format output.FormatOptions) error {
 	finder, err := templates.NewFinder()
 	if err != nil {
 		return fmt.Errorf("failed to create template finder: %w", err)
@@ -74,6 +88,13 @@ func runListTemplates(out *output.Writer, repo string, refresh, verbose bool) er
 		}
 	}
 
+	if format.Format != "text" || format.Template != "" {
+		if tmplList == nil {
+			tmplList = []templates.Template{}
+		}
+		return output.Render(os.Stdout, format, tmplList)
+	}
+
 	if len(tmplList) == 0 {
 		if repo != "" {
 			out.Warning("No pull request templates found in %s.", repo)
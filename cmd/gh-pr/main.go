@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/cmdutil"
 	"go.sbr.pm/x/internal/output"
 )
 
@@ -31,6 +32,8 @@ and conflict resolution in a single command-line interface.`,
 		SilenceErrors: true,
 	}
 
+	cmdutil.AddOutputFlags(cmd, out)
+
 	cmd.AddCommand(versionCmd())
 	cmd.AddCommand(createCmd(out))
 	cmd.AddCommand(reviewCmd(out))
@@ -39,6 +42,8 @@ and conflict resolution in a single command-line interface.`,
 	cmd.AddCommand(resolveConflictsCmd(out))
 	cmd.AddCommand(commentCmd(out))
 	cmd.AddCommand(cleanupCmd(out))
+	cmd.AddCommand(cacheCmd(out))
+	cmd.AddCommand(cmdutil.PathsCmd(out, "gh-pr"))
 
 	return cmd
 }
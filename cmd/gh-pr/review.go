@@ -3,12 +3,14 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/cmdutil"
 	"go.sbr.pm/x/internal/output"
 )
 
@@ -18,6 +20,7 @@ func reviewCmd(out *output.Writer) *cobra.Command {
 		includeComments bool
 		jsonOutput      bool
 		llmFormat       bool
+		format          output.FormatOptions
 	)
 
 	cmd := &cobra.Command{
@@ -34,27 +37,38 @@ This command gathers all the context you need to review a PR in one call:
   - Commit list
 
 Use --diff to include the full diff output.
-Use --llm for a format optimized for AI/LLM consumption.`,
+Use --llm (-o llm) for a format optimized for AI/LLM consumption.
+Use -o to select another format (json, yaml, markdown, ...), or --format
+with a Go template, e.g. --format '{{.Number}} {{.ChecksSummary.Failed}}'.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			prRef := ""
 			if len(args) > 0 {
 				prRef = args[0]
 			}
+			// --json and --llm are shortcuts for -o json and -o llm
+			switch {
+			case jsonOutput:
+				format.Format = output.FormatJSON
+			case llmFormat:
+				format.Format = "llm"
+			}
 			return runReview(out, reviewOpts{
 				prRef:           prRef,
 				includeDiff:     includeDiff,
 				includeComments: includeComments,
-				jsonOutput:      jsonOutput,
-				llmFormat:       llmFormat,
+				format:          format,
 			})
 		},
 	}
 
 	cmd.Flags().BoolVar(&includeDiff, "diff", false, "Include full diff output")
 	cmd.Flags().BoolVarP(&includeComments, "comments", "c", true, "Include review comments")
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
-	cmd.Flags().BoolVar(&llmFormat, "llm", false, "Format output for LLM consumption (markdown)")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON (same as -o json)")
+	cmd.Flags().BoolVar(&llmFormat, "llm", false, "Format output for LLM consumption (same as -o llm)")
+	cmdutil.AddFormatFlags(cmd, &format, &PRContext{}, "human")
+	cmd.MarkFlagsMutuallyExclusive("json", "llm", "output")
+	cmd.MarkFlagsMutuallyExclusive("json", "llm", "format")
 
 	return cmd
 }
@@ -63,8 +77,27 @@ type reviewOpts struct {
 	prRef           string
 	includeDiff     bool
 	includeComments bool
-	jsonOutput      bool
-	llmFormat       bool
+	format          output.FormatOptions
+}
+
+func init() {
+	output.Register(output.Spec[*PRContext, *PRContext]{
+		Columns: []output.Column[*PRContext]{
+			{Name: "number", Value: func(c *PRContext) string { return strconv.Itoa(c.Number) }},
+			{Name: "title", Value: func(c *PRContext) string { return c.Title }},
+			{Name: "author", Value: func(c *PRContext) string { return c.Author }},
+			{Name: "state", Value: func(c *PRContext) string { return c.State }},
+			{Name: "review", Value: func(c *PRContext) string { return c.ReviewDecision }},
+			{Name: "checks", Value: func(c *PRContext) string {
+				return fmt.Sprintf("%d/%d", c.ChecksSummary.Passed, c.ChecksSummary.Total)
+			}},
+			{Name: "unresolved", Value: func(c *PRContext) string { return strconv.Itoa(c.CommentsSummary.Unresolved) }},
+			{Name: "url", Value: func(c *PRContext) string { return c.URL }},
+		},
+		Custom: map[string]func(io.Writer, *PRContext) error{
+			"llm": outputLLM,
+		},
+	})
 }
 
 // PRContext holds all the gathered PR information
@@ -154,15 +187,10 @@ func runReview(out *output.Writer, opts reviewOpts) error {
 	}
 
 	// Output based on format
-	if opts.jsonOutput {
-		return outputJSON(ctx)
+	if opts.format.Format == "human" && opts.format.Template == "" {
+		return outputHuman(out, ctx)
 	}
-
-	if opts.llmFormat {
-		return outputLLM(out, ctx)
-	}
-
-	return outputHuman(out, ctx)
+	return output.Render(os.Stdout, opts.format, ctx)
 }
 
 func fetchPRContext(opts reviewOpts) (*PRContext, error) {
@@ -514,13 +542,7 @@ func parseGitHubURL(url string) (repo string, prNumber int) {
 	return
 }
 
-func outputJSON(ctx *PRContext) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(ctx)
-}
-
-func outputLLM(out *output.Writer, ctx *PRContext) error {
+func outputLLM(w io.Writer, ctx *PRContext) error {
 	var sb strings.Builder
 
 	// PR metadata section
@@ -633,8 +655,8 @@ func outputLLM(out *output.Writer, ctx *PRContext) error {
 		sb.WriteString("\n")
 	}
 
-	fmt.Print(sb.String())
-	return nil
+	_, err := io.WriteString(w, sb.String())
+	return err
 }
 
 func outputLLMComment(sb *strings.Builder, c ReviewComment, replyMap map[int][]ReviewComment) {
@@ -1,14 +1,40 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"go.sbr.pm/x/internal/lazypr"
+	"go.sbr.pm/x/internal/paths"
+	"go.sbr.pm/x/internal/tui"
 )
 
+// actionLogFile is the name of the custom actions log in the state directory
+const actionLogFile = "actions.log"
+
+// logAction appends a timestamped line to the custom actions log.
+// Logging is best-effort and never interrupts an action.
+func logAction(format string, args ...interface{}) {
+	dir, err := paths.Ensure("lazypr", paths.State)
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, actionLogFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "[%s] %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
 // Action represents a PR action result.
 type actionResult struct {
 	success bool
@@ -115,29 +141,12 @@ func requestChangesPRs(prs []lazypr.PRDetail, body string) tea.Cmd {
 // copyToClipboard copies text to the system clipboard.
 func copyToClipboard(text string) tea.Cmd {
 	return func() tea.Msg {
-		// Try different clipboard commands
-		var cmd *exec.Cmd
-		switch {
-		case commandExists("wl-copy"):
-			cmd = exec.Command("wl-copy", text)
-		case commandExists("xclip"):
-			cmd = exec.Command("xclip", "-selection", "clipboard")
-			cmd.Stdin = strings.NewReader(text)
-		case commandExists("pbcopy"):
-			cmd = exec.Command("pbcopy")
-			cmd.Stdin = strings.NewReader(text)
-		default:
-			return actionResult{success: false, message: "No clipboard command found"}
-		}
-
-		if err := cmd.Run(); err != nil {
-			return actionResult{success: false, message: fmt.Sprintf("Failed to copy: %v", err)}
+		if err := tui.CopyToClipboard(text); err != nil {
+			if errors.Is(err, tui.ErrNoClipboard) {
+				return actionResult{success: false, message: "No clipboard command found"}
+			}
+			return actionResult{success: false, message: fmt.Sprintf("Failed to copy: %v", errors.Unwrap(err))}
 		}
 		return actionResult{success: true, message: "URL copied to clipboard"}
 	}
 }
-
-func commandExists(name string) bool {
-	_, err := exec.LookPath(name)
-	return err == nil
-}
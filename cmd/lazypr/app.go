@@ -6,14 +6,16 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"go.sbr.pm/x/internal/cache"
 	"go.sbr.pm/x/internal/lazypr"
+	"go.sbr.pm/x/internal/progress"
+	"go.sbr.pm/x/internal/tui"
 )
 
 const (
@@ -78,8 +80,16 @@ type Model struct {
 	actionsModalCursor int
 	config             *lazypr.Config // User configuration with custom actions
 
+	// Cache for showing the last loaded PRs while refreshing
+	cache     *cache.Cache
+	fromCache bool // Whether the PRs shown were loaded from cache
+
+	// Loading progress, reported by the fetcher
+	progress     chan progress.State
+	loadingState progress.State
+
 	// Styles
-	styles Styles
+	styles tui.Styles
 }
 
 // prLoadedMsg is sent when PRs have been loaded.
@@ -104,8 +114,10 @@ func NewModel(refs []lazypr.PRRef) Model {
 		selected:    make(map[int]bool),
 		focusedPane: paneList,
 		loading:     true,
-		styles:      NewStyles(DefaultTheme()),
+		styles:      tui.NewStyles(tui.DefaultTheme()),
 		config:      cfg,
+		cache:       newPRCache(),
+		progress:    newProgressChan(),
 	}
 }
 
@@ -125,15 +137,19 @@ func NewRepoModelWithFilter(repo lazypr.RepoRef, limit int, filter lazypr.Filter
 		selected:    make(map[int]bool),
 		focusedPane: paneList,
 		loading:     true,
-		styles:      NewStyles(DefaultTheme()),
+		styles:      tui.NewStyles(tui.DefaultTheme()),
 		config:      cfg,
+		cache:       newPRCache(),
+		progress:    newProgressChan(),
 	}
 }
 
 // Init implements tea.Model.
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
+		m.loadCachedPRs(),
 		m.loadPRs(),
+		m.waitForProgress(),
 		tea.EnterAltScreen,
 	)
 }
@@ -142,6 +158,7 @@ func (m Model) Init() tea.Cmd {
 func (m Model) loadPRs() tea.Cmd {
 	return func() tea.Msg {
 		fetcher := lazypr.NewFetcher()
+		fetcher.SetProgress(m.loadProgress())
 
 		var prs []lazypr.PRDetail
 		var err error
@@ -157,6 +174,7 @@ func (m Model) loadPRs() tea.Cmd {
 		if err != nil {
 			return prErrorMsg{err: err}
 		}
+		m.savePRs(prs)
 		return prLoadedMsg{prs: prs}
 	}
 }
@@ -420,6 +438,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "R":
 			m.loading = true
+			m.loadingState = progress.State{}
 			return m, m.loadPRs()
 
 		case "?":
@@ -453,14 +472,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case prCachedMsg:
+		// Only show cached PRs until the first fetch completes
+		if m.loading && len(m.prs) == 0 {
+			m.prs = msg.prs
+			m.fromCache = true
+			m.statusMsg = fmt.Sprintf("Showing PRs cached %s, refreshing...", formatAge(msg.age))
+			if msg.stale {
+				m.statusMsg = fmt.Sprintf("Showing stale PRs cached %s, refreshing...", formatAge(msg.age))
+			}
+			m.statusTime = 30
+			m.updateDetailViewport()
+		}
+
+	case progressMsg:
+		m.loadingState = progress.State(msg)
+		return m, m.waitForProgress()
+
 	case prLoadedMsg:
 		m.prs = msg.prs
 		m.loading = false
+		if m.fromCache {
+			m.fromCache = false
+			m.statusMsg = ""
+		}
+		m.clampCursor()
 		m.updateDetailViewport()
 
 	case prErrorMsg:
-		m.err = msg.err
 		m.loading = false
+		if len(m.prs) > 0 {
+			// Keep showing the PRs we have rather than failing
+			m.statusMsg = fmt.Sprintf("Refresh failed: %v", msg.err)
+			m.statusTime = 50
+		} else {
+			m.err = msg.err
+		}
 
 	case actionResult:
 		m.statusMsg = msg.message
@@ -495,6 +542,24 @@ func (m *Model) resizeViewport() {
 	m.detailViewport.SetContent(m.renderDetailContent())
 }
 
+// clampCursor keeps the cursor and selection within the PR list after it
+// has been replaced, e.g. when fresh PRs replace cached ones.
+func (m *Model) clampCursor() {
+	count := len(m.filteredPRs())
+	if m.cursor >= count {
+		m.cursor = count - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	for i := range m.selected {
+		if i >= count {
+			delete(m.selected, i)
+		}
+	}
+	m.ensureCursorVisible()
+}
+
 // ensureCursorVisible adjusts listOffset so the cursor is visible.
 // Each PR item takes 2 lines (title + author).
 func (m *Model) ensureCursorVisible() {
@@ -718,22 +783,15 @@ func (m Model) executeAction(action lazypr.Action, prs []lazypr.PRDetail) tea.Cm
 	cmd := lazypr.SubstituteBatchPlaceholders(action.Command, prs)
 
 	// Log the command being executed
-	logFile, _ := os.OpenFile("/tmp/lazypr-actions.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if logFile != nil {
-		fmt.Fprintf(logFile, "[%s] Action: %s\n", time.Now().Format(time.RFC3339), action.Name)
-		fmt.Fprintf(logFile, "[%s] Command: %s\n", time.Now().Format(time.RFC3339), cmd)
-		logFile.Close()
-	}
+	logAction("Action: %s", action.Name)
+	logAction("Command: %s", cmd)
 
 	if action.Interactive {
 		// Interactive: suspend TUI and run with full terminal access
 		c := exec.Command("bash", "-c", cmd)
 		return tea.ExecProcess(c, func(err error) tea.Msg {
 			if err != nil {
-				if logFile, _ := os.OpenFile("/tmp/lazypr-actions.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); logFile != nil {
-					fmt.Fprintf(logFile, "[%s] Interactive error: %v\n", time.Now().Format(time.RFC3339), err)
-					logFile.Close()
-				}
+				logAction("Interactive error: %v", err)
 			}
 			return execDoneMsg{}
 		})
@@ -748,19 +806,16 @@ func (m Model) executeAction(action lazypr.Action, prs []lazypr.PRDetail) tea.Cm
 		err := c.Run()
 
 		// Log the result
-		if logFile, _ := os.OpenFile("/tmp/lazypr-actions.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); logFile != nil {
-			if err != nil {
-				fmt.Fprintf(logFile, "[%s] Error: %v\n", time.Now().Format(time.RFC3339), err)
-			}
-			if stdout.Len() > 0 {
-				fmt.Fprintf(logFile, "[%s] Stdout: %s\n", time.Now().Format(time.RFC3339), stdout.String())
-			}
-			if stderr.Len() > 0 {
-				fmt.Fprintf(logFile, "[%s] Stderr: %s\n", time.Now().Format(time.RFC3339), stderr.String())
-			}
-			fmt.Fprintf(logFile, "[%s] Exit code: %d\n", time.Now().Format(time.RFC3339), c.ProcessState.ExitCode())
-			logFile.Close()
+		if err != nil {
+			logAction("Error: %v", err)
+		}
+		if stdout.Len() > 0 {
+			logAction("Stdout: %s", stdout.String())
+		}
+		if stderr.Len() > 0 {
+			logAction("Stderr: %s", stderr.String())
 		}
+		logAction("Exit code: %d", c.ProcessState.ExitCode())
 
 		if err != nil {
 			errMsg := err.Error()
@@ -814,9 +869,9 @@ func (m Model) showCheckLogs(check lazypr.Check, pr lazypr.PRDetail) tea.Cmd {
 	// Detect pager
 	pager := os.Getenv("PAGER")
 	if pager == "" {
-		if commandExists("less") {
+		if tui.CommandExists("less") {
 			pager = "less"
-		} else if commandExists("more") {
+		} else if tui.CommandExists("more") {
 			pager = "more"
 		} else {
 			pager = "cat"
@@ -841,7 +896,7 @@ func getAICommand() string {
 	}
 	// Try common AI CLI tools
 	for _, cmd := range []string{"aichat", "gemini", "llm", "sgpt"} {
-		if commandExists(cmd) {
+		if tui.CommandExists(cmd) {
 			return cmd
 		}
 	}
@@ -874,7 +929,7 @@ func (m Model) explainCheckWithAI(check lazypr.Check, pr lazypr.PRDetail) tea.Cm
 	// Detect pager
 	pager := os.Getenv("PAGER")
 	if pager == "" {
-		if commandExists("less") {
+		if tui.CommandExists("less") {
 			pager = "less"
 		} else {
 			pager = "cat"
@@ -989,9 +1044,9 @@ func (m Model) showDiff() tea.Cmd {
 	// Detect pager: check $PAGER, then fall back to less, then more
 	pager := os.Getenv("PAGER")
 	if pager == "" {
-		if commandExists("less") {
+		if tui.CommandExists("less") {
 			pager = "less"
-		} else if commandExists("more") {
+		} else if tui.CommandExists("more") {
 			pager = "more"
 		} else {
 			pager = "cat" // fallback, no paging
@@ -999,7 +1054,7 @@ func (m Model) showDiff() tea.Cmd {
 	}
 
 	// Check if delta is available for syntax highlighting
-	useDelta := commandExists("delta")
+	useDelta := tui.CommandExists("delta")
 
 	// Build the command pipeline
 	var cmdStr string
@@ -1242,7 +1297,7 @@ func (m Model) renderActionsModal() string {
 	if m.config == nil || len(m.config.Actions) == 0 {
 		lines = append(lines, "No custom actions configured")
 		lines = append(lines, "")
-		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("Configure actions in ~/.config/lazypr/config.toml"))
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("Configure actions in " + lazypr.DefaultConfigPath()))
 	} else {
 		for i, action := range m.config.Actions {
 			var interactiveHint string
@@ -1309,7 +1364,11 @@ func (m Model) renderHeader() string {
 		title = fmt.Sprintf("lazypr - %d PRs", len(m.prs))
 	}
 	if m.loading {
-		title += " (loading...)"
+		if s := m.loadingState; s.Total > 0 && !s.Finished {
+			title += fmt.Sprintf(" (loading %d/%d...)", s.Done, s.Total)
+		} else {
+			title += " (loading...)"
+		}
 	}
 
 	helpHint := "[?] help"
@@ -1386,7 +1445,10 @@ func (m Model) renderListPane(width, height int) string {
 
 	if len(prs) == 0 {
 		if m.loading {
-			output = append(output, "Loading PRs...")
+			output = append(output, m.loadingText())
+			if s := m.loadingState; s.Total > 0 && !s.Finished {
+				output = append(output, m.styles.PRAuthor.Render(s.Bar(min(width, 40))))
+			}
 		} else if m.filterText != "" {
 			output = append(output, "No PRs match filter")
 		} else {
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"go.sbr.pm/x/internal/lazypr"
+	"go.sbr.pm/x/internal/tui"
 )
 
 func TestUpdateInputMode_SubmitKeys(t *testing.T) {
@@ -197,7 +200,7 @@ func TestRenderInputModal_ActionTitles(t *testing.T) {
 				},
 				inputModel: textarea.New(),
 				cursor:     0,
-				styles:     NewStyles(DefaultTheme()),
+				styles:     tui.NewStyles(tui.DefaultTheme()),
 			}
 
 			rendered := m.renderInputModal()
@@ -228,7 +231,7 @@ func TestRenderInputModal_MultiplePRs(t *testing.T) {
 		},
 		inputModel: textarea.New(),
 		cursor:     0,
-		styles:     NewStyles(DefaultTheme()),
+		styles:     tui.NewStyles(tui.DefaultTheme()),
 	}
 
 	rendered := m.renderInputModal()
@@ -268,7 +271,7 @@ func TestApproveKeyShowsInputModal(t *testing.T) {
 		},
 		cursor:   0,
 		selected: make(map[int]bool),
-		styles:   NewStyles(DefaultTheme()),
+		styles:   tui.NewStyles(tui.DefaultTheme()),
 	}
 
 	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}
@@ -285,3 +288,104 @@ func TestApproveKeyShowsInputModal(t *testing.T) {
 		t.Errorf("inputPRs should have 1 PR, got %d", len(model.inputPRs))
 	}
 }
+
+func TestUpdate_CachedPRs(t *testing.T) {
+	cached := []lazypr.PRDetail{
+		{Owner: "test", Repo: "repo", Number: 1},
+		{Owner: "test", Repo: "repo", Number: 2},
+		{Owner: "test", Repo: "repo", Number: 3},
+	}
+
+	m := Model{loading: true, selected: make(map[int]bool)}
+
+	newModel, _ := m.Update(prCachedMsg{prs: cached, age: 5 * time.Minute})
+	m = newModel.(Model)
+	if len(m.prs) != 3 {
+		t.Fatalf("cached PRs should be shown while loading, got %d", len(m.prs))
+	}
+	if !m.loading {
+		t.Error("loading should stay true until the fresh fetch completes")
+	}
+	if !strings.Contains(m.statusMsg, "5m ago") {
+		t.Errorf("statusMsg = %q, want cache age", m.statusMsg)
+	}
+
+	// Fresh PRs replace the cached ones and the cursor stays in range
+	m.cursor = 2
+	m.selected[2] = true
+	newModel, _ = m.Update(prLoadedMsg{prs: cached[:1]})
+	m = newModel.(Model)
+	if m.loading {
+		t.Error("loading should be false after fresh PRs are loaded")
+	}
+	if len(m.prs) != 1 || m.cursor != 0 {
+		t.Errorf("got %d PRs with cursor %d, want 1 PR with cursor 0", len(m.prs), m.cursor)
+	}
+	if m.selected[2] {
+		t.Error("selection out of range should be cleared")
+	}
+	if m.statusMsg != "" {
+		t.Errorf("statusMsg = %q, want cleared", m.statusMsg)
+	}
+
+	// Cached PRs arriving late don't override fresh ones
+	newModel, _ = m.Update(prCachedMsg{prs: cached})
+	m = newModel.(Model)
+	if len(m.prs) != 1 {
+		t.Errorf("late cached PRs replaced fresh ones: got %d PRs", len(m.prs))
+	}
+}
+
+func TestUpdate_RefreshErrorKeepsPRs(t *testing.T) {
+	m := Model{
+		loading: true,
+		prs:     []lazypr.PRDetail{{Owner: "test", Repo: "repo", Number: 1}},
+	}
+
+	newModel, _ := m.Update(prErrorMsg{err: errors.New("network down")})
+	m = newModel.(Model)
+	if m.err != nil {
+		t.Errorf("err = %v, want nil when PRs are already shown", m.err)
+	}
+	if !strings.Contains(m.statusMsg, "network down") {
+		t.Errorf("statusMsg = %q, want refresh error", m.statusMsg)
+	}
+
+	m = Model{loading: true}
+	newModel, _ = m.Update(prErrorMsg{err: errors.New("network down")})
+	m = newModel.(Model)
+	if m.err == nil {
+		t.Error("err should be set when there are no PRs to show")
+	}
+}
+
+func TestUpdate_LoadingProgress(t *testing.T) {
+	m := Model{
+		loading:  true,
+		selected: make(map[int]bool),
+		styles:   tui.NewStyles(tui.DefaultTheme()),
+		progress: newProgressChan(),
+	}
+
+	// The fetcher reports progress through the model's channel
+	p := m.loadProgress()
+	p.Start("Loading PRs", 4)
+	p.Step(2, "test/repo#2")
+
+	for i := 0; i < 2; i++ {
+		msg := m.waitForProgress()()
+		newModel, cmd := m.Update(msg)
+		m = newModel.(Model)
+		if cmd == nil {
+			t.Fatal("progress updates should keep waiting for the next one")
+		}
+	}
+
+	list := m.renderListPane(60, 20)
+	if !strings.Contains(list, "Loading PRs 2/4 (50%") {
+		t.Errorf("list pane should show loading progress, got:\n%s", list)
+	}
+	if !strings.Contains(m.renderHeader(), "loading 2/4") {
+		t.Errorf("header should show loading progress, got %q", m.renderHeader())
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.sbr.pm/x/internal/cache"
+	"go.sbr.pm/x/internal/cmdutil"
+	"go.sbr.pm/x/internal/lazypr"
+)
+
+// prCacheTTL is how long loaded PRs are considered fresh. Older entries are
+// still shown at startup, flagged as stale, while the list is refreshed.
+const prCacheTTL = 1 * time.Hour
+
+// prCachedMsg is sent when PRs have been loaded from cache.
+type prCachedMsg struct {
+	prs   []lazypr.PRDetail
+	age   time.Duration
+	stale bool
+}
+
+// cacheBudget bounds the lazypr cache. Every combination of repository,
+// limit and filter gets its own entry, unused ones age out after a week.
+var cacheBudget = cache.Budget{
+	MaxSize: 64 << 20,
+	MaxAge:  7 * 24 * time.Hour,
+}
+
+// openCache opens the lazypr cache
+func openCache() (*cache.Cache, error) {
+	c, err := cache.New(prCacheTTL, "lazypr")
+	if err != nil {
+		return nil, err
+	}
+	c.SetBudget(cacheBudget)
+	return c, nil
+}
+
+// newPRCache opens the lazypr cache, returning nil if it's unavailable.
+// Caching is best-effort: lazypr works the same without it, just slower.
+func newPRCache() *cache.Cache {
+	c, err := openCache()
+	if err != nil {
+		return nil
+	}
+	return c
+}
+
+// prCacheKey returns the cache key for the PRs the model loads, derived
+// from the repository, limit and filter or from the PR references.
+func (m Model) prCacheKey() string {
+	var source interface{}
+	if m.repo != nil {
+		source = struct {
+			Repo   lazypr.RepoRef
+			Limit  int
+			Filter lazypr.FilterOptions
+		}{*m.repo, m.repoLimit, m.filter}
+	} else {
+		source = m.refs
+	}
+
+	data, _ := json.Marshal(source)
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("prs-%s", hex.EncodeToString(sum[:8]))
+}
+
+// loadCachedPRs loads previously fetched PRs from cache, if any.
+func (m Model) loadCachedPRs() tea.Cmd {
+	return func() tea.Msg {
+		if m.cache == nil {
+			return nil
+		}
+		prs, age, stale, err := cache.Lookup[[]lazypr.PRDetail](m.cache, m.prCacheKey())
+		if err != nil || len(prs) == 0 {
+			return nil
+		}
+		return prCachedMsg{prs: prs, age: age, stale: stale}
+	}
+}
+
+// savePRs stores freshly fetched PRs in cache.
+func (m Model) savePRs(prs []lazypr.PRDetail) {
+	if m.cache == nil {
+		return
+	}
+	_ = m.cache.Set(m.prCacheKey(), prs)
+}
+
+// formatAge formats a cache age for status messages.
+func formatAge(age time.Duration) string {
+	return cmdutil.FormatDuration(age) + " ago"
+}
@@ -8,7 +8,9 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/cmdutil"
 	"go.sbr.pm/x/internal/lazypr"
+	"go.sbr.pm/x/internal/output"
 )
 
 var (
@@ -32,6 +34,11 @@ func init() {
 	rootCmd.Flags().StringVarP(&milestone, "milestone", "m", "", "Filter by milestone")
 	rootCmd.Flags().StringVarP(&author, "author", "a", "", "Filter by author")
 	rootCmd.Flags().StringVarP(&state, "state", "s", "open", "Filter by state (open, closed, all)")
+
+	out := output.Default()
+	cmdutil.AddOutputFlags(rootCmd, out)
+	rootCmd.AddCommand(cmdutil.CacheCmd(out, openCache))
+	rootCmd.AddCommand(cmdutil.PathsCmd(out, "lazypr"))
 }
 
 var rootCmd = &cobra.Command{
@@ -0,0 +1,48 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"go.sbr.pm/x/internal/progress"
+)
+
+// progressMsg is sent when the progress of loading PRs changes.
+type progressMsg progress.State
+
+// newProgressChan creates the channel loading progress is sent to. It is
+// buffered so fetches never wait for the view.
+func newProgressChan() chan progress.State {
+	return make(chan progress.State, 16)
+}
+
+// loadProgress returns a Progress forwarding loading progress to the
+// model, dropping updates when the view is lagging behind.
+func (m Model) loadProgress() progress.Progress {
+	if m.progress == nil {
+		return progress.Nop()
+	}
+	return progress.Func(func(s progress.State) {
+		select {
+		case m.progress <- s:
+		default:
+		}
+	})
+}
+
+// waitForProgress waits for the next loading progress update.
+func (m Model) waitForProgress() tea.Cmd {
+	if m.progress == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return progressMsg(<-m.progress)
+	}
+}
+
+// loadingText describes the loading progress, e.g. "Loading PRs 3/5 (60%)".
+func (m Model) loadingText() string {
+	s := m.loadingState
+	if s.Task == "" || s.Finished {
+		return "Loading PRs..."
+	}
+	return s.String()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"go.sbr.pm/x/internal/config"
+	"go.sbr.pm/x/internal/output"
+)
+
+// autoBaseBranch is the --base-branch value selecting the branch from each
+// host's nixpkgs input
+const autoBaseBranch = "auto"
+
+// allDevBaseBranches is the --base-branch value selecting the development
+// branches, devBranches
+const allDevBaseBranches = "all-dev"
+
+// devBranches are the branches changes to nixpkgs unstable go through
+var devBranches = []string{"master", "staging", "staging-next"}
+
+// parseBaseBranches returns the branches of an explicit --base-branch: a
+// comma-separated list, all-dev, or empty for any branch
+func parseBaseBranches(value string) []string {
+	if value == allDevBaseBranches {
+		return devBranches
+	}
+	var branches []string
+	for _, branch := range strings.Split(value, ",") {
+		branch = strings.TrimSpace(branch)
+		if branch != "" && !slices.Contains(branches, branch) {
+			branches = append(branches, branch)
+		}
+	}
+	if len(branches) == 0 {
+		return []string{""}
+	}
+	return branches
+}
+
+// detectBaseBranches returns the base branches to watch for each host.
+//
+// Explicit --base-branch branches apply to every host. Otherwise the branch
+// is derived from the nixpkgs input each host follows in flake.lock, so
+// hosts on nixos-25.05 watch release-25.05 and unstable hosts watch master
+// and staging. Each host's lock file is read from its flake in flakes,
+// --flake for hosts missing from it.
+func detectBaseBranches(out *output.Writer, flags watchFlags, hosts []string, flakes map[string]string) map[string][]string {
+	branches := make(map[string][]string, len(hosts))
+	for _, host := range hosts {
+		branches[host] = []string{"master"}
+	}
+
+	if flags.baseBranch != autoBaseBranch {
+		for _, host := range hosts {
+			branches[host] = parseBaseBranches(flags.baseBranch)
+		}
+		return branches
+	}
+
+	// Only flakes have a lock file to detect the channel from
+	if flags.depsFile != "" || flags.nixosConfig != "" {
+		return branches
+	}
+
+	configs := make(map[string]*config.Config)
+	for _, host := range hosts {
+		flake, ok := flakes[host]
+		if !ok {
+			flake = flags.flakePath
+		}
+		cfg, ok := configs[flake]
+		if !ok {
+			var err error
+			if cfg, err = config.New(flake); err != nil {
+				continue
+			}
+			configs[flake] = cfg
+		}
+
+		ref, err := cfg.HostNixpkgsRef(host)
+		if err != nil {
+			out.Warning("  %s: failed to detect nixpkgs branch, using master: %v", host, err)
+			continue
+		}
+		branches[host] = config.BaseBranchesForRef(ref)
+		out.Info("  %s: follows %s, watching %s", host, displayRef(ref), strings.Join(branches[host], ", "))
+	}
+
+	return branches
+}
+
+// displayRef returns a printable name for a nixpkgs input ref
+func displayRef(ref string) string {
+	if ref == "" {
+		return "nixpkgs (default branch)"
+	}
+	return ref
+}
+
+// groupHostsByBranch inverts a host to branches mapping
+func groupHostsByBranch(hostBranches map[string][]string) map[string][]string {
+	groups := make(map[string][]string)
+	for host, branches := range hostBranches {
+		for _, branch := range branches {
+			groups[branch] = append(groups[branch], host)
+		}
+	}
+	for _, hosts := range groups {
+		sort.Strings(hosts)
+	}
+	return groups
+}
+
+// sortedBranches returns the branches of a grouping in a stable order
+func sortedBranches(groups map[string][]string) []string {
+	branches := make([]string, 0, len(groups))
+	for branch := range groups {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	return branches
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func TestDetectBaseBranches(t *testing.T) {
+	out := output.NewWriter(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	hosts := []string{"kyushu", "sakhalin"}
+
+	tests := []struct {
+		name  string
+		flags watchFlags
+		want  map[string][]string
+	}{
+		{
+			name:  "explicit branch applies to all hosts",
+			flags: watchFlags{baseBranch: "staging"},
+			want:  map[string][]string{"kyushu": {"staging"}, "sakhalin": {"staging"}},
+		},
+		{
+			name:  "explicit empty branch means any branch",
+			flags: watchFlags{baseBranch: ""},
+			want:  map[string][]string{"kyushu": {""}, "sakhalin": {""}},
+		},
+		{
+			name:  "several branches",
+			flags: watchFlags{baseBranch: "master, staging,,master"},
+			want:  map[string][]string{"kyushu": {"master", "staging"}, "sakhalin": {"master", "staging"}},
+		},
+		{
+			name:  "development branches",
+			flags: watchFlags{baseBranch: allDevBaseBranches},
+			want:  map[string][]string{"kyushu": devBranches, "sakhalin": devBranches},
+		},
+		{
+			name:  "auto without flake falls back to master",
+			flags: watchFlags{baseBranch: autoBaseBranch, depsFile: "deps.json"},
+			want:  map[string][]string{"kyushu": {"master"}, "sakhalin": {"master"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectBaseBranches(out, tt.flags, hosts, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectBaseBranches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectBaseBranches_Flakes(t *testing.T) {
+	out := output.NewWriter(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	writeFlake := func(ref string) string {
+		dir := t.TempDir()
+		lock := `{"nodes": {"nixpkgs": {"original": {"owner": "NixOS", "ref": "` + ref + `", "repo": "nixpkgs", "type": "github"}}, "root": {"inputs": {"nixpkgs": "nixpkgs"}}}, "root": "root", "version": 7}`
+		if err := os.WriteFile(filepath.Join(dir, "flake.nix"), []byte("{ }"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "flake.lock"), []byte(lock), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	flags := watchFlags{baseBranch: autoBaseBranch, flakePath: writeFlake("nixos-unstable")}
+	infra := writeFlake("nixos-25.05")
+
+	got := detectBaseBranches(out, flags, []string{"kyushu", "sakhalin"}, map[string]string{"sakhalin": infra})
+	want := map[string][]string{"kyushu": {"master", "staging"}, "sakhalin": {"release-25.05"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("detectBaseBranches() = %v, want %v", got, want)
+	}
+}
+
+func TestGroupHostsByBranch(t *testing.T) {
+	got := groupHostsByBranch(map[string][]string{
+		"kyushu":   {"master"},
+		"sakhalin": {"release-25.05"},
+		"aomi":     {"master"},
+	})
+	want := map[string][]string{
+		"master":        {"aomi", "kyushu"},
+		"release-25.05": {"sakhalin"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupHostsByBranch() = %v, want %v", got, want)
+	}
+
+	if branches := sortedBranches(got); !reflect.DeepEqual(branches, []string{"master", "release-25.05"}) {
+		t.Errorf("sortedBranches() = %v", branches)
+	}
+}
+
+func TestFetchBranches(t *testing.T) {
+	t.Setenv("NIXPKGS_PR_WATCH_CACHE_DIR", t.TempDir())
+	c, err := openCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each branch has its own cache and cursor, so fetching is skipped
+	branches := []string{"master", "staging", "staging-next"}
+	for i, branch := range branches {
+		metadataKey, dataKey := prCacheKeys(branch)
+		prs := []pr.PullRequest{{Number: i + 1, BaseRef: branch}}
+		if err := c.Set(dataKey, prs); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Set(metadataKey, prCacheMetadata{MaxLimit: 1, FetchedAt: time.Now(), Cursor: branch}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := output.NewWriter(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	got, err := fetchBranches(context.Background(), out, c, watchFlags{limit: 1}, prFetchOptions{}, branches)
+	if err != nil {
+		t.Fatalf("fetchBranches() error = %v", err)
+	}
+	for i, branch := range branches {
+		if len(got[i]) != 1 || got[i][0].BaseRef != branch {
+			t.Errorf("PRs of %s = %+v", branch, got[i])
+		}
+	}
+}
+
+func TestBranchAnnotations(t *testing.T) {
+	staging := testMatch(2, "MERGEABLE", "SUCCESS")
+	staging.PR.BaseRef = "staging"
+	master := testMatch(1, "MERGEABLE", "SUCCESS")
+	master.PR.BaseRef = "master"
+	run := testRun(master, staging)
+
+	rep := run.report()
+	if want := []string{"staging", "staging-next", "master", "nixos-unstable"}; !reflect.DeepEqual(rep.PRChannels[2], want) {
+		t.Errorf("channels of #2 = %v, want %v", rep.PRChannels[2], want)
+	}
+
+	for _, layout := range []string{layoutFull, layoutCompact, layoutTable} {
+		var stdout bytes.Buffer
+		out := output.NewWriter(&stdout, &bytes.Buffer{}, false)
+		out.SetWidth(100)
+		if err := outputTerminal(out, run.results, run.deps, run.hosts, nil, watchFlags{layout: layout}); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"staging → staging-next → master → nixos-unstable", "master → nixos-unstable"} {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("layout %s missing %q:\n%s", layout, want, stdout.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/paths"
+)
+
+// settings are the configurable defaults of flags. Unset fields keep the
+// defaults of the flags.
+type settings struct {
+	Flake         string   `toml:"flake,omitempty"`
+	Repos         []string `toml:"repos,omitempty"` // Extra flake repositories
+	Hosts         []string `toml:"hosts,omitempty"`
+	AllHosts      *bool    `toml:"all-hosts,omitempty"`
+	Limit         int      `toml:"limit,omitempty"`
+	MinConfidence string   `toml:"min-confidence,omitempty"`
+	BaseBranch    *string  `toml:"base-branch,omitempty"` // Empty matches any branch
+	User          string   `toml:"user,omitempty"`
+	Sort          string   `toml:"sort,omitempty"`
+	Ignore        []string `toml:"ignore,omitempty"`
+	IgnorePRs     []int    `toml:"ignore-prs,omitempty"`
+	Labels        []string `toml:"labels,omitempty"`
+	ExcludeLabels []string `toml:"exclude-labels,omitempty"`
+	Notify        []string `toml:"notify,omitempty"`
+	Nixpkgs       string   `toml:"nixpkgs,omitempty"` // Local clone used by try
+	Remote        string   `toml:"remote,omitempty"`
+}
+
+// configFile is the configuration file: defaults, and named profiles
+// overriding them
+type configFile struct {
+	settings
+	Profiles map[string]settings `toml:"profile,omitempty"`
+}
+
+// defaultConfigPath returns the path of the configuration file
+func defaultConfigPath() (string, error) {
+	dir, err := paths.ConfigDir("nixpkgs-pr-watch")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// loadConfigFile reads the configuration file at path. A missing file is
+// an empty configuration.
+func loadConfigFile(path string) (*configFile, error) {
+	cfg := &configFile{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	md, err := toml.Decode(string(data), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown key %q in config %s", undecoded[0].String(), path)
+	}
+	return cfg, nil
+}
+
+// resolve returns the defaults, overridden by the settings of a profile
+func (c *configFile) resolve(profile string) (settings, error) {
+	if profile == "" {
+		return c.settings, nil
+	}
+	p, ok := c.Profiles[profile]
+	if !ok {
+		available := slices.Sorted(maps.Keys(c.Profiles))
+		if len(available) == 0 {
+			return settings{}, fmt.Errorf("unknown profile %q (no profiles configured)", profile)
+		}
+		return settings{}, fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(available, ", "))
+	}
+	return c.settings.merge(p), nil
+}
+
+// merge returns s, overridden by the fields set in o
+func (s settings) merge(o settings) settings {
+	if o.Flake != "" {
+		s.Flake = o.Flake
+	}
+	if o.Repos != nil {
+		s.Repos = o.Repos
+	}
+	// Hosts and all-hosts both select the hosts to analyze
+	if o.Hosts != nil || o.AllHosts != nil {
+		s.Hosts = o.Hosts
+		s.AllHosts = o.AllHosts
+	}
+	if o.Limit != 0 {
+		s.Limit = o.Limit
+	}
+	if o.MinConfidence != "" {
+		s.MinConfidence = o.MinConfidence
+	}
+	if o.BaseBranch != nil {
+		s.BaseBranch = o.BaseBranch
+	}
+	if o.User != "" {
+		s.User = o.User
+	}
+	if o.Sort != "" {
+		s.Sort = o.Sort
+	}
+	if o.Ignore != nil {
+		s.Ignore = o.Ignore
+	}
+	if o.IgnorePRs != nil {
+		s.IgnorePRs = o.IgnorePRs
+	}
+	if o.Labels != nil {
+		s.Labels = o.Labels
+	}
+	if o.ExcludeLabels != nil {
+		s.ExcludeLabels = o.ExcludeLabels
+	}
+	if o.Notify != nil {
+		s.Notify = o.Notify
+	}
+	if o.Nixpkgs != "" {
+		s.Nixpkgs = o.Nixpkgs
+	}
+	if o.Remote != "" {
+		s.Remote = o.Remote
+	}
+	return s
+}
+
+// configFlag is the default a setting gives to a flag
+type configFlag struct {
+	name   string
+	values []string
+	// Flags given on the command line that override this setting, because
+	// they select another source of dependencies or hosts
+	overriddenBy []string
+}
+
+// flags returns the defaults s gives to flags
+func (s settings) flags() []configFlag {
+	var flags []configFlag
+	set := func(name string, values []string, overriddenBy ...string) {
+		flags = append(flags, configFlag{name: name, values: values, overriddenBy: overriddenBy})
+	}
+
+	if s.Flake != "" {
+		set("flake", []string{expandHome(s.Flake)}, "deps-file", "nixos-config")
+	}
+	if s.Repos != nil {
+		var repos []string
+		for _, repo := range s.Repos {
+			repos = append(repos, expandHome(repo))
+		}
+		set("repo", repos, "deps-file", "nixos-config")
+	}
+	if s.Hosts != nil {
+		set("host", s.Hosts, "all-hosts", "deps-file", "nixos-config")
+	}
+	if s.AllHosts != nil {
+		set("all-hosts", []string{strconv.FormatBool(*s.AllHosts)}, "host", "deps-file", "nixos-config")
+	}
+	if s.Limit != 0 {
+		set("limit", []string{strconv.Itoa(s.Limit)})
+	}
+	if s.MinConfidence != "" {
+		set("min-confidence", []string{s.MinConfidence})
+	}
+	if s.BaseBranch != nil {
+		set("base-branch", []string{*s.BaseBranch})
+	}
+	if s.User != "" {
+		set("user", []string{s.User})
+	}
+	if s.Sort != "" {
+		set("sort", []string{s.Sort})
+	}
+	if s.Ignore != nil {
+		set("ignore", s.Ignore)
+	}
+	if s.IgnorePRs != nil {
+		var numbers []string
+		for _, n := range s.IgnorePRs {
+			numbers = append(numbers, strconv.Itoa(n))
+		}
+		set("ignore-pr", numbers)
+	}
+	if s.Labels != nil {
+		set("label", s.Labels)
+	}
+	if s.ExcludeLabels != nil {
+		set("exclude-label", s.ExcludeLabels)
+	}
+	if s.Notify != nil {
+		set("notify", s.Notify)
+	}
+	if s.Nixpkgs != "" {
+		set("nixpkgs", []string{expandHome(s.Nixpkgs)})
+	}
+	if s.Remote != "" {
+		set("remote", []string{s.Remote})
+	}
+	return flags
+}
+
+// apply sets the flags of fs that weren't given on the command line to the
+// values of s. Settings for flags fs doesn't have are skipped, as they
+// configure other commands.
+func (s settings) apply(fs *pflag.FlagSet) error {
+	for _, f := range s.flags() {
+		if flag := fs.Lookup(f.name); flag == nil || flag.Changed {
+			continue
+		}
+		if slices.ContainsFunc(f.overriddenBy, fs.Changed) {
+			continue
+		}
+		for _, v := range f.values {
+			if err := fs.Set(f.name, v); err != nil {
+				return fmt.Errorf("invalid %s in config: %w", f.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// addConfigFlags adds the --config and --profile flags to root, and sets
+// the defaults of flags of the command to run from the configuration file
+func addConfigFlags(root *cobra.Command, out *output.Writer) {
+	var configPath, profile string
+	root.PersistentFlags().StringVar(&configPath, "config", "", "Configuration file (default: $XDG_CONFIG_HOME/nixpkgs-pr-watch/config.toml)")
+	root.PersistentFlags().StringVar(&profile, "profile", "", "Configuration profile to use")
+
+	preRun := root.PersistentPreRunE
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		s, path, err := loadSettings(configPath, profile)
+		if err != nil {
+			return err
+		}
+		out.Debug("Using config %s", path)
+		if err := s.apply(cmd.Flags()); err != nil {
+			return err
+		}
+
+		if preRun != nil {
+			return preRun(cmd, args)
+		}
+		return nil
+	}
+}
+
+// loadSettings returns the settings of a profile from the configuration
+// file at path, or the default one, along with its path
+func loadSettings(path, profile string) (settings, string, error) {
+	if path == "" {
+		var err error
+		path, err = defaultConfigPath()
+		if err != nil {
+			return settings{}, "", err
+		}
+	}
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		return settings{}, "", err
+	}
+	s, err := cfg.resolve(profile)
+	if err != nil {
+		return settings{}, "", err
+	}
+	return s, path, nil
+}
+
+// settings returns the settings flags were set to
+func (f watchFlags) settings() settings {
+	return settings{
+		Flake:         f.flakePath,
+		Repos:         f.repos,
+		Hosts:         f.hosts,
+		AllHosts:      &f.allHosts,
+		Limit:         f.limit,
+		MinConfidence: f.minConfidence,
+		BaseBranch:    &f.baseBranch,
+		User:          f.user,
+		Sort:          f.sortBy,
+		Ignore:        f.ignore,
+		IgnorePRs:     f.ignorePRs,
+		Labels:        f.labels,
+		ExcludeLabels: f.excludeLabels,
+		Notify:        f.notify,
+	}
+}
+
+// configCmd returns the config command
+func configCmd(flags *watchFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration file",
+		Long: `Inspect the configuration file, setting defaults of flags and named profiles.
+
+The configuration file ($XDG_CONFIG_HOME/nixpkgs-pr-watch/config.toml) sets
+defaults for flags. Profiles, selected with --profile, override them, and
+flags given on the command line override both:
+
+  flake = "~/src/nixos-config"
+  min-confidence = "high"
+  ignore = ["python3*"]
+
+  [profile.servers]
+  hosts = ["sakhalin", "aion"]
+  repos = ["~/src/infra"]
+  base-branch = "release-25.05"
+  notify = ["ntfy:https://ntfy.sh/my-servers"]
+
+The local nixpkgs clone used by try, and the remote PRs are fetched from, are
+set with nixpkgs and remote.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration",
+		Long: `Print the effective configuration: the flag defaults, overridden by the
+configuration file, the selected profile and the flags given before or after
+the command, e.g. nixpkgs-pr-watch --limit 5 config show.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The configuration was applied to the flags of the root
+			// command this command inherits, unless given on the command
+			// line, before running it
+			configPath, _ := cmd.Flags().GetString("config")
+			profile, _ := cmd.Flags().GetString("profile")
+			s, path, err := loadSettings(configPath, profile)
+			if err != nil {
+				return err
+			}
+			effective := flags.resolve().settings()
+			// The flags of the try command aren't flags of the root command
+			effective.Nixpkgs = s.Nixpkgs
+			effective.Remote = s.Remote
+			return writeSettings(cmd.OutOrStdout(), path, profile, effective)
+		},
+	})
+
+	return cmd
+}
+
+// writeSettings writes settings as TOML, with a header describing where
+// they come from
+func writeSettings(w io.Writer, path, profile string, s settings) error {
+	fmt.Fprintf(w, "# Configuration file: %s\n", path)
+	if profile != "" {
+		fmt.Fprintf(w, "# Profile: %s\n", profile)
+	}
+	if err := toml.NewEncoder(w).Encode(s); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/deps"
+)
+
+const testConfig = `
+flake = "/src/nixos-config"
+min-confidence = "high"
+ignore = ["python3*"]
+all-hosts = true
+
+[profile.servers]
+hosts = ["sakhalin", "aion"]
+repos = ["~/src/infra"]
+base-branch = "release-25.05"
+notify = ["ntfy:https://ntfy.sh/servers"]
+ignore-prs = [42]
+exclude-labels = ["2.status: stale"]
+
+[profile.any]
+base-branch = ""
+`
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	cfg, err := loadConfigFile(writeTestConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("loadConfigFile() error = %v", err)
+	}
+	if cfg.Flake != "/src/nixos-config" || cfg.MinConfidence != "high" || len(cfg.Profiles) != 2 {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	if cfg, err := loadConfigFile(filepath.Join(t.TempDir(), "missing.toml")); err != nil || cfg.Flake != "" {
+		t.Errorf("missing config = %+v, %v", cfg, err)
+	}
+
+	_, err = loadConfigFile(writeTestConfig(t, "[profile.servers]\nhost = [\"typo\"]\n"))
+	if err == nil || !strings.Contains(err.Error(), `unknown key "profile.servers.host"`) {
+		t.Errorf("unknown key error = %v", err)
+	}
+}
+
+func TestConfigFile_Resolve(t *testing.T) {
+	cfg, err := loadConfigFile(writeTestConfig(t, testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := cfg.resolve("servers")
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if s.Flake != "/src/nixos-config" || s.MinConfidence != "high" || !slices.Equal(s.Ignore, []string{"python3*"}) {
+		t.Errorf("defaults not inherited: %+v", s)
+	}
+	if !slices.Equal(s.Hosts, []string{"sakhalin", "aion"}) || s.AllHosts != nil {
+		t.Errorf("profile hosts should replace all-hosts: hosts %v, all-hosts %v", s.Hosts, s.AllHosts)
+	}
+	if *s.BaseBranch != "release-25.05" || len(s.Notify) != 1 || !slices.Equal(s.IgnorePRs, []int{42}) {
+		t.Errorf("profile not applied: %+v", s)
+	}
+
+	if s, _ := cfg.resolve("any"); s.BaseBranch == nil || *s.BaseBranch != "" {
+		t.Errorf("empty base branch should be kept: %v", s.BaseBranch)
+	}
+
+	_, err = cfg.resolve("desktops")
+	if err == nil || !strings.Contains(err.Error(), "available: any, servers") {
+		t.Errorf("unknown profile error = %v", err)
+	}
+}
+
+func TestSettings_Apply(t *testing.T) {
+	path := writeTestConfig(t, testConfig)
+
+	tests := []struct {
+		name    string
+		profile string
+		args    []string
+		check   func(t *testing.T, flags watchFlags)
+	}{
+		{
+			name: "defaults",
+			args: []string{},
+			check: func(t *testing.T, flags watchFlags) {
+				if flags.flakePath != "/src/nixos-config" || !flags.allHosts || flags.minConfidence != "high" || flags.limit != 500 {
+					t.Errorf("flags = %+v", flags)
+				}
+			},
+		},
+		{
+			name: "flags override config",
+			args: []string{"--min-confidence", "low", "--ignore", "go"},
+			check: func(t *testing.T, flags watchFlags) {
+				if flags.minConfidence != "low" || !slices.Equal(flags.ignore, []string{"go"}) {
+					t.Errorf("flags = %+v", flags)
+				}
+			},
+		},
+		{
+			name:    "profile",
+			profile: "servers",
+			args:    []string{"--limit", "50"},
+			check: func(t *testing.T, flags watchFlags) {
+				if flags.allHosts || !slices.Equal(flags.hosts, []string{"sakhalin", "aion"}) || flags.baseBranch != "release-25.05" || flags.limit != 50 {
+					t.Errorf("flags = %+v", flags)
+				}
+				if len(flags.repos) != 1 || !strings.HasSuffix(flags.repos[0], "/src/infra") || strings.HasPrefix(flags.repos[0], "~") {
+					t.Errorf("repos = %v", flags.repos)
+				}
+				if !slices.Equal(flags.notify, []string{"ntfy:https://ntfy.sh/servers"}) || !slices.Equal(flags.ignorePRs, []int{42}) || !slices.Equal(flags.excludeLabels, []string{"2.status: stale"}) {
+					t.Errorf("flags = %+v", flags)
+				}
+			},
+		},
+		{
+			name: "host flag overrides all-hosts",
+			args: []string{"--host", "kyushu"},
+			check: func(t *testing.T, flags watchFlags) {
+				if flags.allHosts || !slices.Equal(flags.hosts, []string{"kyushu"}) {
+					t.Errorf("flags = %+v", flags)
+				}
+			},
+		},
+		{
+			name: "another source of dependencies overrides the flake",
+			args: []string{"--deps-file", "deps.json"},
+			check: func(t *testing.T, flags watchFlags) {
+				if flags.flakePath != "." || flags.allHosts {
+					t.Errorf("flags = %+v", flags)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flags watchFlags
+			cmd := &cobra.Command{Use: "test"}
+			addMatchFlags(cmd, cmd.Flags(), &flags)
+			addNotifyFlags(cmd.Flags(), &flags)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			s, _, err := loadSettings(path, tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.apply(cmd.Flags()); err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if err := cmd.ValidateFlagGroups(); err != nil {
+				t.Errorf("config conflicts with flags: %v", err)
+			}
+			tt.check(t, flags)
+		})
+	}
+}
+
+func TestConfigShow(t *testing.T) {
+	path := writeTestConfig(t, testConfig)
+
+	var stdout bytes.Buffer
+	cmd := rootCmd()
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"--limit", "5", "config", "show", "--config", path, "--profile", "servers"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config show error = %v", err)
+	}
+
+	got := stdout.String()
+	for _, want := range []string{
+		"# Configuration file: " + path,
+		"# Profile: servers",
+		`flake = "/src/nixos-config"`,
+		`hosts = ["sakhalin", "aion"]`,
+		"limit = 5",
+		`min-confidence = "high"`,
+		`base-branch = "release-25.05"`,
+		`sort = "created"`,
+		`ignore = ["python3*"]`,
+		"ignore-prs = [42]",
+		`exclude-labels = ["2.status: stale"]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("config show missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestIgnoreDependencies(t *testing.T) {
+	host := &deps.Dependencies{
+		Packages: []deps.Package{{Name: "python3"}, {Name: "python3.12-requests"}, {Name: "git"}},
+		Services: []string{"nginx", "postgresql"},
+	}
+	got := ignoreDependencies(map[string]*deps.Dependencies{"kyushu": host}, []string{"python3*", "nginx"})["kyushu"]
+
+	var names []string
+	for _, p := range got.Packages {
+		names = append(names, p.Name)
+	}
+	if !slices.Equal(names, []string{"git"}) || !slices.Equal(got.Services, []string{"postgresql"}) {
+		t.Errorf("kept packages %v and services %v", names, got.Services)
+	}
+	if len(host.Packages) != 3 || len(host.Services) != 2 {
+		t.Error("ignoreDependencies() modified the extracted dependencies")
+	}
+}
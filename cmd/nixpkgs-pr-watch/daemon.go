@@ -0,0 +1,457 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/atomicfile"
+	"go.sbr.pm/x/internal/cache"
+	"go.sbr.pm/x/internal/cmdutil"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// Watch events
+const (
+	eventNew           = "new"
+	eventStatusChanged = "status-changed"
+	eventMerged        = "merged"
+	eventClosed        = "closed"
+)
+
+const (
+	// defaultWatchInterval is the default time between two checks
+	defaultWatchInterval = 30 * time.Minute
+
+	// watchRetryDelay is the delay before retrying a failed check, doubled
+	// on each consecutive failure up to the interval
+	watchRetryDelay = time.Minute
+
+	// forgetAfter is how long an open PR that no longer matches is kept in
+	// the state, so it isn't reported as new if it matches again
+	forgetAfter = 7 * 24 * time.Hour
+
+	// watchStateVersion is the version of the state file format
+	watchStateVersion = 1
+)
+
+// watchEvent is a change of a PR matching the configuration
+type watchEvent struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Number     int       `json:"number"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	Confidence string    `json:"confidence,omitempty"`
+	Security   bool      `json:"security,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+}
+
+func init() {
+	output.Register(output.Spec[[]watchEvent, watchEvent]{
+		Items: func(events []watchEvent) []watchEvent { return events },
+		Columns: []output.Column[watchEvent]{
+			{Name: "time", Value: func(e watchEvent) string { return e.Time.Format(time.RFC3339) }},
+			{Name: "type", Value: func(e watchEvent) string { return e.Type }},
+			{Name: "number", Value: func(e watchEvent) string { return strconv.Itoa(e.Number) }},
+			{Name: "title", Value: func(e watchEvent) string { return e.Title }},
+			{Name: "detail", Value: func(e watchEvent) string { return e.Detail }},
+			{Name: "url", Value: func(e watchEvent) string { return e.URL }},
+		},
+		Custom: map[string]func(io.Writer, []watchEvent) error{
+			"text": outputEventsText,
+		},
+	})
+}
+
+// outputEventsText writes one line per event, suited to logs and journald
+func outputEventsText(w io.Writer, events []watchEvent) error {
+	for _, e := range events {
+		line := fmt.Sprintf("%s %s #%d %s", e.Time.Format(time.RFC3339), strings.ToUpper(e.Type), e.Number, e.Title)
+		if e.Detail != "" {
+			line += " (" + e.Detail + ")"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", line, e.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seenMatch is what the watch state remembers of a matching PR
+type seenMatch struct {
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Confidence  string    `json:"confidence"`
+	Security    bool      `json:"security,omitempty"`
+	Mergeable   string    `json:"mergeable,omitempty"`
+	StatusState string    `json:"status_state,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitzero"` // Last update of the PR
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// watchState is the state kept between checks
+type watchState struct {
+	Version   int               `json:"version"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Matches   map[int]seenMatch `json:"matches"`
+}
+
+// defaultStatePath returns the default state file of the watch daemon for
+// a profile
+func defaultStatePath(profile string) (string, error) {
+	return profileStatePath("watch-state", profile)
+}
+
+// loadWatchState reads the state file at path. It returns an empty state
+// and false if the file doesn't exist yet.
+func loadWatchState(path string) (*watchState, bool, error) {
+	state := &watchState{Version: watchStateVersion, Matches: make(map[int]seenMatch)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, false, fmt.Errorf("failed to parse watch state %s: %w", path, err)
+	}
+	if state.Version != watchStateVersion {
+		return nil, false, fmt.Errorf("unsupported watch state version %d in %s", state.Version, path)
+	}
+	if state.Matches == nil {
+		state.Matches = make(map[int]seenMatch)
+	}
+	return state, true, nil
+}
+
+// save writes the state to path, replacing it atomically so an interrupted
+// write never leaves a corrupted state behind
+func (s *watchState) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode watch state: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(path, data); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	return nil
+}
+
+// newSeenMatch records a match result seen at now
+func newSeenMatch(r pr.MatchResult, now time.Time) seenMatch {
+	return seenMatch{
+		Number:      r.PR.Number,
+		Title:       r.PR.Title,
+		URL:         r.PR.URL,
+		Confidence:  r.HighestConfidence(),
+		Security:    r.PR.IsSecurity(),
+		Mergeable:   r.PR.Mergeable,
+		StatusState: r.PR.StatusState,
+		UpdatedAt:   r.PR.UpdatedAt,
+		FirstSeen:   now,
+		LastSeen:    now,
+	}
+}
+
+// diffMatches updates the state with the current results and returns the
+// events for new matches and status changes, along with the previously
+// seen PRs that no longer match. Those may have been merged or closed, or
+// just fallen out of the fetched PRs, see resolveMissing.
+func diffMatches(state *watchState, results []pr.MatchResult, now time.Time) ([]watchEvent, []seenMatch) {
+	var events []watchEvent
+	current := make(map[int]bool, len(results))
+
+	for _, r := range results {
+		current[r.PR.Number] = true
+		seen := newSeenMatch(r, now)
+
+		prev, ok := state.Matches[r.PR.Number]
+		if !ok {
+			state.Matches[r.PR.Number] = seen
+			events = append(events, watchEvent{
+				Time:       now,
+				Type:       eventNew,
+				Number:     seen.Number,
+				Title:      seen.Title,
+				URL:        seen.URL,
+				Confidence: seen.Confidence,
+				Security:   seen.Security,
+			})
+			continue
+		}
+
+		seen.FirstSeen = prev.FirstSeen
+		// GitHub reports an unknown status while computing it, keep the
+		// last known one rather than reporting a change back and forth
+		if !knownStatus(seen.Mergeable) {
+			seen.Mergeable = prev.Mergeable
+		}
+		if !knownStatus(seen.StatusState) {
+			seen.StatusState = prev.StatusState
+		}
+		state.Matches[r.PR.Number] = seen
+
+		var changes []string
+		if seen.Mergeable != prev.Mergeable && prev.Mergeable != "" {
+			changes = append(changes, fmt.Sprintf("mergeable: %s → %s", strings.ToLower(prev.Mergeable), strings.ToLower(seen.Mergeable)))
+		}
+		if seen.StatusState != prev.StatusState && prev.StatusState != "" {
+			changes = append(changes, fmt.Sprintf("CI: %s → %s", strings.ToLower(prev.StatusState), strings.ToLower(seen.StatusState)))
+		}
+		if len(changes) > 0 {
+			events = append(events, watchEvent{
+				Time:       now,
+				Type:       eventStatusChanged,
+				Number:     seen.Number,
+				Title:      seen.Title,
+				URL:        seen.URL,
+				Confidence: seen.Confidence,
+				Security:   seen.Security,
+				Detail:     strings.Join(changes, ", "),
+			})
+		}
+	}
+
+	var missing []seenMatch
+	for number, seen := range state.Matches {
+		if !current[number] {
+			missing = append(missing, seen)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Number < missing[j].Number })
+
+	return events, missing
+}
+
+// knownStatus reports whether a mergeable or CI status is known
+func knownStatus(status string) bool {
+	return status != "" && status != "UNKNOWN"
+}
+
+// resolveMissing looks up the state of PRs that no longer match, using
+// stateOf, and returns merged and closed events. Merged and closed PRs are
+// dropped from the state, open ones are forgotten after a while. PRs whose
+// state can't be looked up are kept, to be checked again next time.
+func resolveMissing(out *output.Writer, state *watchState, missing []seenMatch, stateOf func(int) (string, error), now time.Time) []watchEvent {
+	var events []watchEvent
+	for _, seen := range missing {
+		prState, err := stateOf(seen.Number)
+		if err != nil {
+			out.Warning("Failed to check state of PR #%d: %v", seen.Number, err)
+			continue
+		}
+
+		event := watchEvent{
+			Time:       now,
+			Number:     seen.Number,
+			Title:      seen.Title,
+			URL:        seen.URL,
+			Confidence: seen.Confidence,
+			Security:   seen.Security,
+		}
+		switch prState {
+		case "MERGED":
+			event.Type = eventMerged
+		case "CLOSED":
+			event.Type = eventClosed
+		default:
+			if now.Sub(seen.LastSeen) > forgetAfter {
+				out.Verbose("Forgetting PR #%d, not matching for %s", seen.Number, cmdutil.FormatDuration(now.Sub(seen.LastSeen)))
+				delete(state.Matches, seen.Number)
+			}
+			continue
+		}
+		events = append(events, event)
+		delete(state.Matches, seen.Number)
+	}
+	return events
+}
+
+// daemon checks matching PRs periodically and emits events
+type daemon struct {
+	out         *output.Writer
+	cache       *cache.Cache
+	flags       watchFlags
+	statePath   string
+	format      output.FormatOptions
+	emitInitial bool
+	stdout      io.Writer
+	stateOf     func(int) (string, error)
+	notifier    *notifier
+}
+
+// check fetches and matches PRs once, then emits the events since the
+// previous check and saves the state. Cached PRs are updated with those
+// changed since they were fetched, instead of being fetched again.
+func (d *daemon) check(ctx context.Context) error {
+	run, err := matchPRs(ctx, d.out, d.cache, d.flags, prFetchOptions{update: true})
+	if err != nil {
+		return err
+	}
+
+	state, existed, err := loadWatchState(d.statePath)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	events, missing := diffMatches(state, run.results, now)
+	events = append(events, resolveMissing(d.out, state, missing, d.stateOf, now)...)
+	state.UpdatedAt = now
+	if err := state.save(d.statePath); err != nil {
+		return err
+	}
+
+	// The first check only records what is already there, unless asked
+	// otherwise, so starting the daemon doesn't flood with events
+	if !existed && !d.emitInitial {
+		d.out.Info("Recorded %d matching PRs in %s", len(state.Matches), d.statePath)
+		return nil
+	}
+
+	d.out.Info("%d events", len(events))
+	if len(events) == 0 {
+		return nil
+	}
+	if err := output.Render(d.stdout, d.format, events); err != nil {
+		return err
+	}
+
+	// A failed notification doesn't fail the check, events were already
+	// recorded and wouldn't be emitted again
+	if d.notifier != nil {
+		if err := d.notifier.send(ctx, eventNotifications(events)); err != nil {
+			d.out.Warning("%v", err)
+		}
+	}
+	return nil
+}
+
+// run checks PRs every interval until ctx is done. Failed checks are
+// retried sooner, with an increasing delay.
+func (d *daemon) run(ctx context.Context, interval time.Duration) error {
+	failures := 0
+	for {
+		wait := interval
+		if err := d.check(ctx); err != nil {
+			failures++
+			wait = min(watchRetryDelay<<min(failures-1, 10), interval)
+			d.out.Warning("Check failed (%d in a row), retrying in %s: %v", failures, wait, err)
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			d.out.Info("Stopping")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+func watchCmd(out *output.Writer) *cobra.Command {
+	var (
+		flags       watchFlags
+		interval    time.Duration
+		statePath   string
+		once        bool
+		emitInitial bool
+		format      output.FormatOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch matching PRs and report changes",
+		Long: `Check PRs matching your configuration periodically and report changes as
+events: new matching PR, status changed (conflicts or CI), merged and closed.
+
+Previously seen matches are kept in a state file, so only changes are
+reported across restarts. The first check only records the current matches,
+unless --emit-initial is given.
+
+Each check only fetches the PRs updated since the previous one. Failed checks
+are retried, and SIGINT or SIGTERM stop the daemon, cancelling the current
+check, so it can run as a systemd user service.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("invalid interval %s", interval)
+			}
+			flags.profile, _ = cmd.Flags().GetString("profile")
+			if statePath == "" {
+				path, err := defaultStatePath(flags.profile)
+				if err != nil {
+					return err
+				}
+				statePath = path
+			}
+
+			n, err := newNotifier(out, flags.notify, flags.profile)
+			if err != nil {
+				return err
+			}
+
+			c, err := openCache()
+			if err != nil {
+				return fmt.Errorf("failed to initialize cache: %w", err)
+			}
+
+			// Stopping cancels the check in flight
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fetcher := pr.NewFetcher()
+			fetcher.SetContext(ctx)
+			d := &daemon{
+				out:         out,
+				cache:       c,
+				flags:       flags.resolve(),
+				statePath:   statePath,
+				format:      format,
+				emitInitial: emitInitial,
+				stdout:      cmd.OutOrStdout(),
+				stateOf:     fetcher.FetchNixpkgsPRState,
+				notifier:    n,
+			}
+			if once {
+				return d.check(ctx)
+			}
+
+			out.Info("Checking PRs every %s", interval)
+			return d.run(ctx, interval)
+		},
+	}
+
+	addMatchFlags(cmd, cmd.Flags(), &flags)
+	addNotifyFlags(cmd.Flags(), &flags)
+	cmd.Flags().DurationVar(&interval, "interval", defaultWatchInterval, "Time between checks")
+	cmd.Flags().StringVar(&statePath, "state-file", "", "State file of previously seen matches (default: $XDG_STATE_HOME/nixpkgs-pr-watch/watch-state.json, or watch-state-<profile>.json with --profile)")
+	cmd.Flags().BoolVar(&once, "once", false, "Check once and exit")
+	cmd.Flags().BoolVar(&emitInitial, "emit-initial", false, "Report current matches as new when there is no state yet")
+	cmdutil.AddFormatFlags(cmd, &format, []watchEvent{}, "text")
+
+	return cmd
+}
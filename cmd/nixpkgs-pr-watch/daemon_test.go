@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func testMatch(number int, mergeable, status string) pr.MatchResult {
+	return pr.MatchResult{
+		PR: pr.PullRequest{
+			Number:      number,
+			Title:       "foo: 1.0 -> 1.1",
+			URL:         fmt.Sprintf("https://github.com/NixOS/nixpkgs/pull/%d", number),
+			Mergeable:   mergeable,
+			StatusState: status,
+		},
+		Matches: []pr.Match{{Type: "package", Dependency: "foo", Confidence: "high"}},
+	}
+}
+
+func eventTypes(events []watchEvent) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestDiffMatches(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		previous    map[int]seenMatch
+		results     []pr.MatchResult
+		wantTypes   []string
+		wantDetail  string
+		wantMissing int
+	}{
+		{
+			name:      "new match",
+			results:   []pr.MatchResult{testMatch(1, "MERGEABLE", "PENDING")},
+			wantTypes: []string{eventNew},
+		},
+		{
+			name:     "unchanged",
+			previous: map[int]seenMatch{1: {Number: 1, Mergeable: "MERGEABLE", StatusState: "PENDING", FirstSeen: earlier}},
+			results:  []pr.MatchResult{testMatch(1, "MERGEABLE", "PENDING")},
+		},
+		{
+			name:       "conflicts",
+			previous:   map[int]seenMatch{1: {Number: 1, Mergeable: "MERGEABLE", StatusState: "SUCCESS", FirstSeen: earlier}},
+			results:    []pr.MatchResult{testMatch(1, "CONFLICTING", "SUCCESS")},
+			wantTypes:  []string{eventStatusChanged},
+			wantDetail: "mergeable: mergeable → conflicting",
+		},
+		{
+			name:       "CI failing",
+			previous:   map[int]seenMatch{1: {Number: 1, Mergeable: "MERGEABLE", StatusState: "PENDING", FirstSeen: earlier}},
+			results:    []pr.MatchResult{testMatch(1, "MERGEABLE", "FAILURE")},
+			wantTypes:  []string{eventStatusChanged},
+			wantDetail: "CI: pending → failure",
+		},
+		{
+			name:     "unknown status keeps the last known one",
+			previous: map[int]seenMatch{1: {Number: 1, Mergeable: "MERGEABLE", StatusState: "SUCCESS", FirstSeen: earlier}},
+			results:  []pr.MatchResult{testMatch(1, "UNKNOWN", "")},
+		},
+		{
+			name:        "no longer matching",
+			previous:    map[int]seenMatch{1: {Number: 1}, 2: {Number: 2}},
+			results:     []pr.MatchResult{testMatch(2, "", "")},
+			wantMissing: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &watchState{Matches: make(map[int]seenMatch)}
+			for n, m := range tt.previous {
+				state.Matches[n] = m
+			}
+
+			events, missing := diffMatches(state, tt.results, now)
+
+			if got := eventTypes(events); strings.Join(got, ",") != strings.Join(tt.wantTypes, ",") {
+				t.Errorf("events = %v, want %v", got, tt.wantTypes)
+			}
+			if tt.wantDetail != "" && events[0].Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", events[0].Detail, tt.wantDetail)
+			}
+			if len(missing) != tt.wantMissing {
+				t.Errorf("missing = %v, want %d", missing, tt.wantMissing)
+			}
+			for _, r := range tt.results {
+				seen := state.Matches[r.PR.Number]
+				if !seen.LastSeen.Equal(now) {
+					t.Errorf("PR #%d last seen = %v, want %v", r.PR.Number, seen.LastSeen, now)
+				}
+				if prev, ok := tt.previous[r.PR.Number]; ok && !seen.FirstSeen.Equal(prev.FirstSeen) {
+					t.Errorf("PR #%d first seen = %v, want %v", r.PR.Number, seen.FirstSeen, prev.FirstSeen)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveMissing(t *testing.T) {
+	now := time.Now()
+	state := &watchState{Matches: map[int]seenMatch{
+		1: {Number: 1, LastSeen: now},
+		2: {Number: 2, LastSeen: now},
+		3: {Number: 3, LastSeen: now},
+		4: {Number: 4, LastSeen: now.Add(-2 * forgetAfter)},
+		5: {Number: 5, LastSeen: now},
+	}}
+	states := map[int]string{1: "MERGED", 2: "CLOSED", 3: "OPEN", 4: "OPEN"}
+	stateOf := func(number int) (string, error) {
+		if s, ok := states[number]; ok {
+			return s, nil
+		}
+		return "", errors.New("rate limited")
+	}
+
+	var stderr bytes.Buffer
+	out := output.NewWriter(&bytes.Buffer{}, &stderr, false)
+	var missing []seenMatch
+	for n := 1; n <= 5; n++ {
+		missing = append(missing, state.Matches[n])
+	}
+
+	events := resolveMissing(out, state, missing, stateOf, now)
+
+	if got := strings.Join(eventTypes(events), ","); got != "merged,closed" {
+		t.Errorf("events = %s, want merged,closed", got)
+	}
+	for n, want := range map[int]bool{1: false, 2: false, 3: true, 4: false, 5: true} {
+		if _, ok := state.Matches[n]; ok != want {
+			t.Errorf("PR #%d kept = %v, want %v", n, ok, want)
+		}
+	}
+	if !strings.Contains(stderr.String(), "Failed to check state of PR #5") {
+		t.Errorf("missing warning for failed lookup:\n%s", stderr.String())
+	}
+}
+
+func TestWatchState_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "watch-state.json")
+
+	state, existed, err := loadWatchState(path)
+	if err != nil {
+		t.Fatalf("loadWatchState() error = %v", err)
+	}
+	if existed || len(state.Matches) != 0 {
+		t.Fatalf("loadWatchState() = %+v, %v, want empty new state", state, existed)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	state.Matches[42] = seenMatch{Number: 42, Title: "foo", Mergeable: "MERGEABLE", FirstSeen: now, LastSeen: now}
+	if err := state.save(path); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	loaded, existed, err := loadWatchState(path)
+	if err != nil {
+		t.Fatalf("loadWatchState() error = %v", err)
+	}
+	if !existed {
+		t.Error("loadWatchState() should report an existing state")
+	}
+	if got := loaded.Matches[42]; got.Title != "foo" || got.Mergeable != "MERGEABLE" || !got.FirstSeen.Equal(now) {
+		t.Errorf("loaded match = %+v", got)
+	}
+}
+
+func TestOutputEventsText(t *testing.T) {
+	var buf bytes.Buffer
+	events := []watchEvent{{
+		Time:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Type:   eventStatusChanged,
+		Number: 42,
+		Title:  "foo: 1.0 -> 1.1",
+		URL:    "https://github.com/NixOS/nixpkgs/pull/42",
+		Detail: "CI: pending → failure",
+	}}
+	if err := output.Render(&buf, output.FormatOptions{Format: "text"}, events); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "2026-01-02T03:04:05Z STATUS-CHANGED #42 foo: 1.0 -> 1.1 (CI: pending → failure) https://github.com/NixOS/nixpkgs/pull/42\n"
+	if buf.String() != want {
+		t.Errorf("text output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestStatePaths_PerProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NIXPKGS_PR_WATCH_STATE_DIR", dir)
+
+	tests := []struct {
+		name string
+		path func(profile string) (string, error)
+		want map[string]string
+	}{
+		{
+			name: "watch state",
+			path: defaultStatePath,
+			want: map[string]string{"": "watch-state.json", "servers": "watch-state-servers.json"},
+		},
+		{
+			name: "seen state",
+			path: seenStatePath,
+			want: map[string]string{"": "seen.json", "servers": "seen-servers.json"},
+		},
+	}
+	for _, tt := range tests {
+		for profile, want := range tt.want {
+			got, err := tt.path(profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != filepath.Join(dir, want) {
+				t.Errorf("%s of profile %q = %s, want %s", tt.name, profile, got, want)
+			}
+		}
+	}
+
+	// Notifications delivered in a profile don't hide them in another
+	out := output.NewWriter(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	for _, profile := range []string{"", "servers"} {
+		n, err := newNotifier(out, []string{"webhook:http://127.0.0.1:1"}, profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.seen.Add(profile+"#42", time.Time{})
+		if err := n.seen.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"notified.json", "notified-servers.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("notification state %s: %v", name, err)
+		}
+	}
+	servers, err := newNotifier(out, []string{"webhook:http://127.0.0.1:1"}, "servers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if servers.seen.Has("#42") {
+		t.Error("notification delivered without a profile recorded in the servers profile")
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.sbr.pm/x/internal/cache"
+	"go.sbr.pm/x/internal/config"
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/progress"
+)
+
+// defaultDepsTTL is how long dependencies are cached when they can't be
+// keyed by a content fingerprint, or when the fingerprint doesn't cover
+// everything they depend on (the channel of a non-flake configuration)
+const defaultDepsTTL = 24 * time.Hour
+
+// loadDependencies resolves the hosts to analyze and extracts their
+// dependencies, either from a dependencies file, a channel-based
+// configuration.nix, or the flake (default) and the extra flake
+// repositories. It also returns the flake of each host, if any.
+func loadDependencies(out *output.Writer, depsCache *cache.Cache, flags watchFlags) ([]string, map[string]*deps.Dependencies, map[string]string, error) {
+	switch {
+	case flags.depsFile != "":
+		hosts, allDeps, err := loadDependenciesFile(out, flags)
+		return hosts, allDeps, nil, err
+	case flags.nixosConfig != "":
+		hosts, allDeps, err := loadNixOSConfigDependencies(out, depsCache, flags)
+		return hosts, allDeps, nil, err
+	default:
+		return loadFlakeDependencies(out, depsCache, flags)
+	}
+}
+
+// loadDependenciesFile reads dependencies from --deps-file.
+// This doesn't require Nix and is meant for CI or pre-computed lists.
+func loadDependenciesFile(out *output.Writer, flags watchFlags) ([]string, map[string]*deps.Dependencies, error) {
+	hostDeps, err := deps.LoadFile(flags.depsFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostname, err := singleHost(flags, "--deps-file")
+	if err != nil {
+		return nil, nil, err
+	}
+	if hostname == "" {
+		hostname = strings.TrimSuffix(filepath.Base(flags.depsFile), filepath.Ext(flags.depsFile))
+	}
+
+	out.Info("  %s: loaded from %s (%d packages, %d modules)", hostname, flags.depsFile, len(hostDeps.Packages), len(hostDeps.Modules))
+
+	return []string{hostname}, map[string]*deps.Dependencies{hostname: &hostDeps}, nil
+}
+
+// loadNixOSConfigDependencies evaluates a channel-based configuration.nix
+func loadNixOSConfigDependencies(out *output.Writer, depsCache *cache.Cache, flags watchFlags) ([]string, map[string]*deps.Dependencies, error) {
+	hostname, err := singleHost(flags, "--nixos-config")
+	if err != nil {
+		return nil, nil, err
+	}
+	if hostname == "" {
+		hostname, err = config.ShortHostname()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	out.Info("Analyzing hosts: [%s]", hostname)
+
+	// Key the cache by the configuration files, so edits are re-extracted
+	// right away. The channel can be updated without any file changing, so
+	// entries still expire.
+	fingerprint, err := config.ConfigurationFingerprint(flags.nixosConfig)
+	if err != nil {
+		out.Warning("Failed to fingerprint %s, caching dependencies for 24h: %v", flags.nixosConfig, err)
+	}
+
+	extractor := deps.NewNixOSConfigExtractor(flags.nixosConfig)
+	hostDeps, err := extractCached(out, depsCache, depsCacheKey(hostname, fingerprint), defaultDepsTTL, hostname, flags.refreshDeps, extractor)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract dependencies from %s: %w", flags.nixosConfig, err)
+	}
+
+	return []string{hostname}, map[string]*deps.Dependencies{hostname: hostDeps}, nil
+}
+
+// loadFlakeDependencies extracts dependencies for the flake's hosts, and
+// for all hosts of the extra repositories, returning the flake of each host
+func loadFlakeDependencies(out *output.Writer, depsCache *cache.Cache, flags watchFlags) ([]string, map[string]*deps.Dependencies, map[string]string, error) {
+	cfg, err := config.New(flags.flakePath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load flake configuration: %w", err)
+	}
+
+	// Determine which hosts to analyze
+	var hostsToAnalyze []string
+	if flags.allHosts {
+		hostsToAnalyze, err = cfg.AllHosts()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get all hosts: %w", err)
+		}
+	} else if len(flags.hosts) > 0 {
+		hostsToAnalyze = flags.hosts
+	} else {
+		hostname, err := cfg.CurrentHost()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to determine current host: %w", err)
+		}
+		hostsToAnalyze = []string{hostname}
+	}
+
+	flakes := make(map[string]string)
+	for _, host := range hostsToAnalyze {
+		flakes[host] = flags.flakePath
+	}
+
+	// All hosts of extra repositories are analyzed, hosts are told apart by
+	// name so the first flake defining one wins
+	for _, repo := range flags.repos {
+		repoCfg, err := config.New(repo)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load repository %s: %w", repo, err)
+		}
+		hosts, err := repoCfg.AllHosts()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get hosts of repository %s: %w", repo, err)
+		}
+		for _, host := range hosts {
+			if other, ok := flakes[host]; ok {
+				out.Warning("  %s: defined by both %s and %s, analyzing the one of %s", host, other, repo, other)
+				continue
+			}
+			flakes[host] = repo
+			hostsToAnalyze = append(hostsToAnalyze, host)
+		}
+	}
+
+	out.Info("Analyzing hosts: %v", hostsToAnalyze)
+
+	// Extract dependencies for each host
+	allDeps := make(map[string]*deps.Dependencies)
+	fingerprints := make(map[string]string)
+	for _, hostname := range hostsToAnalyze {
+		flake := flakes[hostname]
+		fingerprint, ok := fingerprints[flake]
+		if !ok {
+			fingerprint = flakeFingerprint(out, flake)
+			fingerprints[flake] = fingerprint
+		}
+
+		// Key dependency cache entries by the flake's content fingerprint,
+		// so a changed configuration or flake.lock is re-extracted
+		// automatically and an unchanged one can stay cached indefinitely.
+		depsTTL := cache.NoExpiry
+		if fingerprint == "" {
+			depsTTL = defaultDepsTTL
+		}
+
+		extractor := deps.NewExtractor(flake, hostname)
+		hostDeps, err := extractCached(out, depsCache, depsCacheKey(hostname, fingerprint), depsTTL, hostname, flags.refreshDeps, extractor)
+		if err != nil {
+			out.Warning("  %s: failed to extract dependencies: %v", hostname, err)
+			continue
+		}
+		allDeps[hostname] = hostDeps
+	}
+
+	return hostsToAnalyze, allDeps, flakes, nil
+}
+
+// flakeFingerprint returns the content fingerprint of a flake, or an empty
+// one if it can't be fingerprinted
+func flakeFingerprint(out *output.Writer, flakePath string) string {
+	cfg, err := config.New(flakePath)
+	if err == nil {
+		var fingerprint string
+		if fingerprint, err = cfg.Fingerprint(); err == nil {
+			return fingerprint
+		}
+	}
+	out.Warning("Failed to fingerprint flake %s, caching dependencies for 24h: %v", flakePath, err)
+	return ""
+}
+
+// singleHost returns the host named by --host, for sources describing a
+// single host
+func singleHost(flags watchFlags, source string) (string, error) {
+	switch len(flags.hosts) {
+	case 0:
+		return "", nil
+	case 1:
+		return flags.hosts[0], nil
+	default:
+		return "", fmt.Errorf("%s describes a single host, got %d hosts", source, len(flags.hosts))
+	}
+}
+
+// ignoreDependencies returns the dependencies of each host without the
+// packages and services matching one of the ignore globs
+func ignoreDependencies(allDeps map[string]*deps.Dependencies, globs []string) map[string]*deps.Dependencies {
+	if len(globs) == 0 {
+		return allDeps
+	}
+	ignored := func(name string) bool {
+		return slices.ContainsFunc(globs, func(glob string) bool {
+			ok, _ := path.Match(glob, name)
+			return ok
+		})
+	}
+
+	filtered := make(map[string]*deps.Dependencies, len(allDeps))
+	for host, d := range allDeps {
+		kept := *d
+		kept.Packages = slices.DeleteFunc(slices.Clone(d.Packages), func(p deps.Package) bool { return ignored(p.Name) })
+		kept.Services = slices.DeleteFunc(slices.Clone(d.Services), ignored)
+		filtered[host] = &kept
+	}
+	return filtered
+}
+
+// extractCached returns the cached dependencies for key, extracting and
+// caching them when missing or when refresh is requested
+func extractCached(out *output.Writer, depsCache *cache.Cache, key string, ttl time.Duration, hostname string, refresh bool, extractor *deps.Extractor) (*deps.Dependencies, error) {
+	out.Debug("  %s: dependency cache key %s", hostname, key)
+
+	// Try to load from cache
+	if !refresh {
+		if hostDeps, err := cache.Get[deps.Dependencies](depsCache, key); err == nil && len(hostDeps.Packages) > 0 {
+			out.Info("  %s: loaded from cache (%d packages, %d modules)", hostname, len(hostDeps.Packages), len(hostDeps.Modules))
+			return &hostDeps, nil
+		}
+	}
+
+	// Extract dependencies
+	out.Info("  %s: extracting dependencies...", hostname)
+	extractor.SetProgress(progress.New(out))
+	hostDeps, err := extractor.Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the results
+	if err := depsCache.SetWithTTL(key, hostDeps, ttl); err != nil {
+		out.Warning("  %s: failed to cache dependencies: %v", hostname, err)
+	} else if err := removeOldDeps(depsCache, hostname, key); err != nil {
+		out.Warning("  %s: failed to remove outdated dependencies from cache: %v", hostname, err)
+	}
+
+	out.Info("  %s: found %d packages, %d modules", hostname, len(hostDeps.Packages), len(hostDeps.Modules))
+	return &hostDeps, nil
+}
+
+// removeOldDeps removes the dependencies of hostname cached under another
+// fingerprint than the one of key. They are never read again once the flake
+// changed, and would otherwise pile up with every flake.lock update.
+func removeOldDeps(depsCache *cache.Cache, hostname, key string) error {
+	entries, err := depsCache.List()
+	if err != nil {
+		return err
+	}
+
+	prefix := depsCacheKey(hostname, "") + "-"
+	for _, entry := range entries {
+		fingerprint, ok := strings.CutPrefix(entry.Key, prefix)
+		// Keys of other hosts sharing the prefix (e.g. "host-2") don't end
+		// with a fingerprint
+		if !ok || entry.Key == key || !isFingerprint(fingerprint) {
+			continue
+		}
+		if err := depsCache.Delete(entry.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isFingerprint reports whether s looks like a fingerprint returned by
+// config.Fingerprint or config.ConfigurationFingerprint
+func isFingerprint(s string) bool {
+	if len(s) != 16 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// depsCacheKey returns the dependency cache key for a host.
+// The fingerprint is omitted when the flake could not be fingerprinted.
+func depsCacheKey(hostname, fingerprint string) string {
+	if fingerprint == "" {
+		return fmt.Sprintf("deps-%s", hostname)
+	}
+	return fmt.Sprintf("deps-%s-%s", hostname, fingerprint)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"go.sbr.pm/x/internal/deps"
+)
+
+func TestRemoveOldDeps(t *testing.T) {
+	t.Setenv("NIXPKGS_PR_WATCH_CACHE_DIR", t.TempDir())
+	c, err := openCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{
+		depsCacheKey("host", "0123456789abcdef"),
+		depsCacheKey("host", "fedcba9876543210"),
+		depsCacheKey("host", ""),
+		depsCacheKey("host-2", "0123456789abcdef"),
+		depsCacheKey("other", "0123456789abcdef"),
+	}
+	for _, key := range keys {
+		if err := c.Set(key, deps.Dependencies{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := removeOldDeps(c, "host", depsCacheKey("host", "fedcba9876543210")); err != nil {
+		t.Fatalf("removeOldDeps() error = %v", err)
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Key)
+	}
+	want := []string{
+		"deps-host",
+		"deps-host-2-0123456789abcdef",
+		"deps-host-fedcba9876543210",
+		"deps-other-0123456789abcdef",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining keys = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.sbr.pm/x/internal/atomicfile"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// Feed output formats
+const (
+	formatAtom = "atom"
+	formatRSS  = "rss"
+)
+
+// maxFeedEntries caps the number of entries kept when merging into a feed
+// file, dropping the least recently updated ones
+const maxFeedEntries = 500
+
+// feedEntry is a PR in a feed, independent of the feed format
+type feedEntry struct {
+	ID         string
+	Title      string
+	URL        string
+	Author     string
+	Published  time.Time
+	Updated    time.Time
+	Categories []string
+	Content    string // HTML
+}
+
+// feed is a feed of matching PRs
+type feed struct {
+	Title   string
+	Link    string
+	Updated time.Time
+	Entries []feedEntry
+}
+
+// newFeed builds a feed of the matches of a report
+func newFeed(r report) feed {
+	f := feed{
+		Title:   "nixpkgs PRs for " + formatHosts(r.Metadata.HostsAnalyzed),
+		Link:    "https://github.com/NixOS/nixpkgs/pulls",
+		Updated: time.Now(),
+	}
+	if t, err := time.Parse(time.RFC3339, r.Metadata.Timestamp); err == nil {
+		f.Updated = t
+	}
+	for _, m := range r.Matches {
+		f.Entries = append(f.Entries, newFeedEntry(m))
+	}
+	return f
+}
+
+// newFeedEntry builds the entry of a match. Its ID is the PR URL, so it is
+// stable across runs.
+func newFeedEntry(r pr.MatchResult) feedEntry {
+	updated := r.PR.UpdatedAt
+	if updated.IsZero() {
+		updated = r.PR.CreatedAt
+	}
+	return feedEntry{
+		ID:         r.PR.URL,
+		Title:      fmt.Sprintf("#%d %s", r.PR.Number, r.PR.Title),
+		URL:        r.PR.URL,
+		Author:     r.PR.Author,
+		Published:  r.PR.CreatedAt,
+		Updated:    updated,
+		Categories: r.PR.Labels,
+		Content:    feedContent(r),
+	}
+}
+
+// feedContent describes a match in HTML: matches, labels and status
+func feedContent(r pr.MatchResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p>%s confidence match, by @%s",
+		html.EscapeString(r.HighestConfidence()), html.EscapeString(r.PR.Author))
+	if r.PR.BaseRef != "" {
+		fmt.Fprintf(&b, " targeting <code>%s</code>", html.EscapeString(r.PR.BaseRef))
+	}
+	b.WriteString(".</p>\n")
+	if status := formatStatusIndicators(r.PR); status != "" {
+		fmt.Fprintf(&b, "<p>Status: %s</p>\n", html.EscapeString(status))
+	}
+
+	b.WriteString("<ul>\n")
+	for _, m := range r.Matches {
+		fmt.Fprintf(&b, "<li>%s <code>%s</code> (%s)", html.EscapeString(m.Type), html.EscapeString(m.Dependency), html.EscapeString(m.Confidence))
+		if m.FilePath != "" {
+			fmt.Fprintf(&b, ": <code>%s</code>", html.EscapeString(m.FilePath))
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ul>\n")
+
+	if len(r.PR.Labels) > 0 {
+		fmt.Fprintf(&b, "<p>Labels: %s</p>\n", html.EscapeString(strings.Join(r.PR.Labels, ", ")))
+	}
+	return b.String()
+}
+
+// mergeFeedEntries merges entries into existing ones, replacing entries
+// with the same ID. Entries are sorted by most recent update and capped to
+// maxFeedEntries.
+func mergeFeedEntries(existing, entries []feedEntry) []feedEntry {
+	byID := make(map[string]feedEntry, len(existing)+len(entries))
+	for _, e := range existing {
+		byID[e.ID] = e
+	}
+	for _, e := range entries {
+		byID[e.ID] = e
+	}
+
+	merged := make([]feedEntry, 0, len(byID))
+	for _, e := range byID {
+		merged = append(merged, e)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if !merged[i].Updated.Equal(merged[j].Updated) {
+			return merged[i].Updated.After(merged[j].Updated)
+		}
+		return merged[i].ID < merged[j].ID
+	})
+	if len(merged) > maxFeedEntries {
+		merged = merged[:maxFeedEntries]
+	}
+	return merged
+}
+
+// writeFeedFile merges the entries of f into the feed file at path, in the
+// given format, creating it if needed. The file is replaced atomically, so
+// readers never see a partial feed.
+func writeFeedFile(path, format string, f feed) error {
+	existing, err := readFeedFile(path, format)
+	if err != nil {
+		return err
+	}
+	f.Entries = mergeFeedEntries(existing, f.Entries)
+
+	err = atomicfile.Write(path, func(w io.Writer) error {
+		return writeFeed(w, format, f)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
+
+// readFeedFile reads the entries of the feed file at path, if it exists
+func readFeedFile(path, format string) ([]feedEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	var entries []feedEntry
+	switch format {
+	case formatAtom:
+		entries, err = parseAtom(data)
+	case formatRSS:
+		entries, err = parseRSS(data)
+	default:
+		return nil, fmt.Errorf("invalid feed format %q (valid: atom, rss)", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s feed %s: %w", format, path, err)
+	}
+	return entries, nil
+}
+
+// writeFeed writes f in the given format
+func writeFeed(w io.Writer, format string, f feed) error {
+	switch format {
+	case formatAtom:
+		return writeAtom(w, f)
+	case formatRSS:
+		return writeRSS(w, f)
+	default:
+		return fmt.Errorf("invalid feed format %q (valid: atom, rss)", format)
+	}
+}
+
+// encodeXML writes v as an indented XML document
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Atom (RFC 4287) documents
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Link      atomLink    `xml:"link"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// formatAtomTime formats t as an RFC 3339 date, empty for the zero time
+func formatAtomTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeAtom(w io.Writer, f feed) error {
+	doc := atomFeed{
+		Title:     f.Title,
+		ID:        f.Link,
+		Updated:   formatAtomTime(f.Updated),
+		Link:      atomLink{Href: f.Link},
+		Generator: "nixpkgs-pr-watch",
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			Title:     e.Title,
+			ID:        e.ID,
+			Link:      atomLink{Href: e.URL, Rel: "alternate"},
+			Published: formatAtomTime(e.Published),
+			Updated:   formatAtomTime(e.Updated),
+			Content:   atomContent{Type: "html", Body: e.Content},
+		}
+		if e.Author != "" {
+			entry.Author = &atomAuthor{Name: e.Author}
+		}
+		for _, c := range e.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return encodeXML(w, doc)
+}
+
+func parseAtom(data []byte) ([]feedEntry, error) {
+	var doc atomFeed
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	entries := make([]feedEntry, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		entry := feedEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			URL:     e.Link.Href,
+			Content: e.Content.Body,
+		}
+		entry.Published, _ = time.Parse(time.RFC3339, e.Published)
+		entry.Updated, _ = time.Parse(time.RFC3339, e.Updated)
+		if e.Author != nil {
+			entry.Author = e.Author.Name
+		}
+		for _, c := range e.Categories {
+			entry.Categories = append(entry.Categories, c.Term)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// RSS 2.0 documents
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// formatRSSTime formats t as an RFC 822 date, empty for the zero time
+func formatRSSTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+// writeRSS writes f as RSS. RSS items have a single date, the last update
+// of the PR, so feed readers show updated PRs again.
+func writeRSS(w io.Writer, f feed) error {
+	doc := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   "NixOS/nixpkgs pull requests matching the configuration, by nixpkgs-pr-watch",
+			LastBuildDate: formatRSSTime(f.Updated),
+			Generator:     "nixpkgs-pr-watch",
+		},
+	}
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.URL,
+			GUID:        rssGUID{IsPermaLink: e.ID == e.URL, Value: e.ID},
+			PubDate:     formatRSSTime(e.Updated),
+			Creator:     e.Author,
+			Categories:  e.Categories,
+			Description: e.Content,
+		})
+	}
+	return encodeXML(w, doc)
+}
+
+func parseRSS(data []byte) ([]feedEntry, error) {
+	var doc rssFeed
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	entries := make([]feedEntry, 0, len(doc.Channel.Items))
+	for _, item := range doc.Channel.Items {
+		entry := feedEntry{
+			ID:         item.GUID.Value,
+			Title:      item.Title,
+			URL:        item.Link,
+			Author:     item.Creator,
+			Categories: item.Categories,
+			Content:    item.Description,
+		}
+		if entry.ID == "" {
+			entry.ID = item.Link
+		}
+		entry.Updated, _ = time.Parse(time.RFC1123Z, item.PubDate)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func feedMatch(number int, title string, updated time.Time) pr.MatchResult {
+	return pr.MatchResult{
+		PR: pr.PullRequest{
+			Number:    number,
+			Title:     title,
+			URL:       fmt.Sprintf("https://github.com/NixOS/nixpkgs/pull/%d", number),
+			Author:    "r-ryantm",
+			BaseRef:   "master",
+			Labels:    []string{"10.rebuild-linux: 1-10"},
+			CreatedAt: updated.Add(-time.Hour),
+			UpdatedAt: updated,
+		},
+		Matches: []pr.Match{{Type: "package", Dependency: "foo", FilePath: "pkgs/by-name/fo/foo/package.nix", Confidence: "high"}},
+	}
+}
+
+func feedReport(results ...pr.MatchResult) report {
+	return newReport(results, &deps.Dependencies{}, []string{"kyushu"})
+}
+
+func TestFeed_Formats(t *testing.T) {
+	updated := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	r := feedReport(feedMatch(42, "foo: 1.0 -> 1.1 <beta>", updated))
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{
+			format: formatAtom,
+			want: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom">`,
+				"<title>nixpkgs PRs for kyushu</title>",
+				"<title>#42 foo: 1.0 -&gt; 1.1 &lt;beta&gt;</title>",
+				"<id>https://github.com/NixOS/nixpkgs/pull/42</id>",
+				"<updated>2026-03-04T05:06:07Z</updated>",
+				"<published>2026-03-04T04:06:07Z</published>",
+				`<category term="10.rebuild-linux: 1-10"></category>`,
+				"&lt;code&gt;pkgs/by-name/fo/foo/package.nix&lt;/code&gt;",
+				"Labels: 10.rebuild-linux: 1-10",
+			},
+		},
+		{
+			format: formatRSS,
+			want: []string{
+				`<rss version="2.0">`,
+				`<guid isPermaLink="true">https://github.com/NixOS/nixpkgs/pull/42</guid>`,
+				"<pubDate>Wed, 04 Mar 2026 05:06:07 +0000</pubDate>",
+				"<category>10.rebuild-linux: 1-10</category>",
+				"high confidence match, by @r-ryantm",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := output.Render(&buf, output.FormatOptions{Format: tt.format}, r); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			got := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("%s output missing %q:\n%s", tt.format, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFeedFile_Merge(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	for _, format := range []string{formatAtom, formatRSS} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prs.xml")
+
+			first := feedReport(feedMatch(1, "foo: 1.0 -> 1.1", day1), feedMatch(2, "bar: 2.0 -> 2.1", day1))
+			if err := writeFeedFile(path, format, newFeed(first)); err != nil {
+				t.Fatalf("writeFeedFile() error = %v", err)
+			}
+
+			// PR 1 was updated and PR 3 is new, while PR 2 no longer
+			// matches but stays in the feed
+			second := feedReport(feedMatch(1, "foo: 1.0 -> 1.2", day2), feedMatch(3, "baz: 3.0 -> 3.1", day2))
+			if err := writeFeedFile(path, format, newFeed(second)); err != nil {
+				t.Fatalf("writeFeedFile() error = %v", err)
+			}
+
+			entries, err := readFeedFile(path, format)
+			if err != nil {
+				t.Fatalf("readFeedFile() error = %v", err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Title)
+			}
+			want := []string{"#1 foo: 1.0 -> 1.2", "#3 baz: 3.0 -> 3.1", "#2 bar: 2.0 -> 2.1"}
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("entries = %q, want %q", got, want)
+			}
+			if !entries[0].Updated.Equal(day2) || !entries[2].Updated.Equal(day1) {
+				t.Errorf("updated timestamps = %v, %v", entries[0].Updated, entries[2].Updated)
+			}
+			if entries[2].Content == "" || len(entries[2].Categories) != 1 {
+				t.Errorf("merged entry lost details: %+v", entries[2])
+			}
+		})
+	}
+}
+
+func TestWriteFeedFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prs.xml")
+	if err := os.WriteFile(path, []byte("not a feed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFeedFile(path, formatAtom, newFeed(feedReport())); err == nil {
+		t.Error("writeFeedFile() should fail to merge into an invalid feed")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "not a feed" {
+		t.Errorf("invalid feed file was overwritten: %q", data)
+	}
+}
+
+func TestMergeFeedEntries_Cap(t *testing.T) {
+	start := time.Now()
+	var existing []feedEntry
+	for i := 0; i < maxFeedEntries; i++ {
+		existing = append(existing, feedEntry{ID: fmt.Sprint(i), Updated: start.Add(time.Duration(i) * time.Minute)})
+	}
+	merged := mergeFeedEntries(existing, []feedEntry{{ID: "new", Updated: start.Add(time.Hour * 24)}})
+
+	if len(merged) != maxFeedEntries {
+		t.Fatalf("got %d entries, want %d", len(merged), maxFeedEntries)
+	}
+	if merged[0].ID != "new" || merged[len(merged)-1].ID != "1" {
+		t.Errorf("oldest entry should be dropped, got first %s and last %s", merged[0].ID, merged[len(merged)-1].ID)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.sbr.pm/x/internal/pr"
+)
+
+// formatHTML is the HTML dashboard output format
+const formatHTML = "html"
+
+// dashboardTemplate is a self-contained page, with inline CSS and JS, so it
+// can be published as a single file
+//
+//go:embed dashboard.html
+var dashboardTemplate string
+
+var dashboardTmpl = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(dashboardTemplate))
+
+// versionBumpRe matches update PR titles, e.g. "git: 2.43.0 -> 2.44.0"
+var versionBumpRe = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)?([\w.+-]+):\s*(\S+)\s*->\s*(\S+)`)
+
+// versionBump extracts the old and new versions from an update PR title
+func versionBump(title string) (from, to string, ok bool) {
+	m := versionBumpRe.FindStringSubmatch(title)
+	if m == nil {
+		return "", "", false
+	}
+	return m[2], m[3], true
+}
+
+// dashboard is the data of the HTML dashboard
+type dashboard struct {
+	Title        string
+	Generated    string
+	Hosts        []string
+	Packages     int
+	Modules      int
+	Services     int
+	Conflicts    int
+	Failing      int
+	Security     int
+	ByConfidence map[string]int
+	Rows         []dashboardRow
+	Live         bool // Served by serve, with updates streamed from /api/events
+}
+
+// dashboardRow is a matching PR in the dashboard
+type dashboardRow struct {
+	Number         int
+	Title          string
+	URL            string
+	Author         string
+	Packages       []string
+	Bump           string
+	Confidence     string
+	ConfidenceRank int
+	Score          int
+	Status         string
+	StatusClass    string
+	Security       bool
+	Hosts          []string
+	Labels         []string
+	UpdatedAt      string
+	UpdatedUnix    int64
+	Age            string
+	Search         string
+}
+
+// newDashboard builds the dashboard of a report
+func newDashboard(r report) dashboard {
+	d := dashboard{
+		Title:        "nixpkgs PRs for " + formatHosts(r.Metadata.HostsAnalyzed),
+		Generated:    r.Metadata.Timestamp,
+		Hosts:        r.Metadata.HostsAnalyzed,
+		Packages:     r.Metadata.TotalDependencies,
+		Modules:      r.Metadata.TotalModules,
+		Services:     r.Metadata.TotalServices,
+		ByConfidence: make(map[string]int),
+	}
+	if t, err := time.Parse(time.RFC3339, r.Metadata.Timestamp); err == nil {
+		d.Generated = t.Format("2006-01-02 15:04 MST")
+	}
+
+	for _, m := range r.Matches {
+		row := dashboardRow{
+			Number:     m.PR.Number,
+			Title:      m.PR.Title,
+			URL:        m.PR.URL,
+			Author:     m.PR.Author,
+			Confidence: m.HighestConfidence(),
+			Score:      m.Score,
+			Status:     prStatus(m.PR),
+			Security:   m.PR.IsSecurity(),
+			Hosts:      r.PRHosts[m.PR.Number],
+			Labels:     m.PR.Labels,
+		}
+		row.ConfidenceRank = pr.ConfidenceRank(row.Confidence)
+		row.StatusClass = row.Status
+		if row.Status == "-" {
+			row.StatusClass = "none"
+		}
+		if names := matchedDependencies(m.Matches); names != "" {
+			row.Packages = strings.Split(names, ", ")
+		}
+		if from, to, ok := versionBump(m.PR.Title); ok {
+			row.Bump = from + " → " + to
+		}
+		if !m.PR.UpdatedAt.IsZero() {
+			row.UpdatedAt = m.PR.UpdatedAt.Format(time.RFC3339)
+			row.UpdatedUnix = m.PR.UpdatedAt.Unix()
+			row.Age = formatDate(m.PR.UpdatedAt)
+		}
+		search := slices.Concat([]string{m.PR.Title, m.PR.Author, row.Bump}, row.Packages, row.Labels)
+		row.Search = strings.ToLower(strings.Join(search, " "))
+
+		d.ByConfidence[row.Confidence]++
+		if m.PR.HasConflicts() {
+			d.Conflicts++
+		}
+		if m.PR.HasBuildFailure() {
+			d.Failing++
+		}
+		if row.Security {
+			d.Security++
+		}
+		d.Rows = append(d.Rows, row)
+	}
+	return d
+}
+
+// writeHTML writes the HTML dashboard of a report
+func writeHTML(w io.Writer, r report) error {
+	return dashboardTmpl.Execute(w, newDashboard(r))
+}
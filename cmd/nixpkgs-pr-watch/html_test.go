@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		title    string
+		from, to string
+		ok       bool
+	}{
+		{title: "git: 2.43.0 -> 2.44.0", from: "2.43.0", to: "2.44.0", ok: true},
+		{title: "python3Packages.requests: 2.31.0 -> 2.32.3", from: "2.31.0", to: "2.32.3", ok: true},
+		{title: "[Backport release-25.05] curl: 8.7.1 -> 8.8.0", from: "8.7.1", to: "8.8.0", ok: true},
+		{title: "nixos/nginx: add option"},
+		{title: "treewide: remove foo"},
+	}
+
+	for _, tt := range tests {
+		from, to, ok := versionBump(tt.title)
+		if from != tt.from || to != tt.to || ok != tt.ok {
+			t.Errorf("versionBump(%q) = %q, %q, %v, want %q, %q, %v", tt.title, from, to, ok, tt.from, tt.to, tt.ok)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	results := []pr.MatchResult{
+		{
+			PR: pr.PullRequest{
+				Number:      42,
+				Title:       "openssl: 3.0.1 -> 3.0.2 <script>alert(1)</script>",
+				URL:         "https://github.com/NixOS/nixpkgs/pull/42",
+				Author:      "r-ryantm",
+				Mergeable:   "CONFLICTING",
+				StatusState: "FAILURE",
+				Labels:      []string{"1.severity: security"},
+				UpdatedAt:   time.Now().Add(-2 * time.Hour),
+			},
+			Score:   90,
+			Matches: []pr.Match{{Type: "package", Dependency: "openssl", Confidence: "high"}},
+		},
+		{
+			PR: pr.PullRequest{
+				Number: 43,
+				Title:  "nixos/nginx: add option",
+				URL:    "https://github.com/NixOS/nixpkgs/pull/43",
+				Author: "someone",
+			},
+			Matches: []pr.Match{{Type: "module", Dependency: "nginx", Confidence: "medium"}},
+		},
+	}
+	r := newReport(results, &deps.Dependencies{Packages: []deps.Package{{Name: "openssl"}}}, []string{"kyushu", "aomi"})
+	r.PRHosts = map[int][]string{42: {"kyushu", "aomi"}, 43: {"aomi"}}
+
+	var buf bytes.Buffer
+	if err := output.Render(&buf, output.FormatOptions{Format: formatHTML}, r); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"<title>nixpkgs PRs for 2 hosts</title>",
+		"Analyzed kyushu, aomi",
+		`<a href="https://github.com/NixOS/nixpkgs/pull/42">#42</a>`,
+		"openssl: 3.0.1 -&gt; 3.0.2 &lt;script&gt;",
+		`<span class="badge status-conflicts">conflicts</span>`,
+		`<span class="badge security">security</span>`,
+		"3.0.1 → 3.0.2",
+		`data-hosts="kyushu aomi"`,
+		`<span class="badge status-none">-</span>`,
+		`<option value="packages">package</option>`,
+		"<b>1</b>with conflicts",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+
+	// The page must be self-contained
+	for _, external := range []string{"<link", "<script src", "@import", "url("} {
+		if strings.Contains(got, external) {
+			t.Errorf("HTML references external assets: %q", external)
+		}
+	}
+}
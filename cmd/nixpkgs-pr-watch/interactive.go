@@ -0,0 +1,467 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"go.sbr.pm/x/internal/pr"
+	"go.sbr.pm/x/internal/tui"
+)
+
+// nixpkgsRepo is the repository PRs are opened in, by gh and lazypr
+const nixpkgsRepo = "NixOS/nixpkgs"
+
+// interactiveItem is a matching PR in the interactive list
+type interactiveItem struct {
+	result pr.MatchResult
+	hosts  []string
+	bump   string // Version bump, e.g. "1.0 → 1.1"
+}
+
+// interactiveModel is the bubbletea model of the interactive mode, listing
+// matches and acting on the selected PRs
+type interactiveModel struct {
+	items    []interactiveItem
+	cursor   int
+	offset   int          // First visible item
+	selected map[int]bool // Selected PR numbers
+	width    int
+	height   int
+	status   string
+	styles   tui.Styles
+
+	snoozes   *snoozes
+	now       func() time.Time
+	subscribe func(number int) error
+	copyText  func(text string) error
+}
+
+// interactiveDoneMsg reports the outcome of an asynchronous action
+type interactiveDoneMsg struct {
+	message string
+	err     error
+}
+
+// newInteractiveModel returns the interactive list of the results of run
+func newInteractiveModel(run *matchRun, s *snoozes) interactiveModel {
+	m := interactiveModel{
+		selected:  make(map[int]bool),
+		styles:    tui.NewStyles(tui.DefaultTheme()),
+		snoozes:   s,
+		now:       time.Now,
+		subscribe: pr.NewFetcher().SubscribeNixpkgsPR,
+		copyText:  tui.CopyToClipboard,
+	}
+	for _, r := range run.results {
+		item := interactiveItem{result: r, hosts: run.prHosts[r.PR.Number]}
+		if from, to, ok := versionBump(r.PR.Title); ok {
+			item.bump = from + " → " + to
+		}
+		m.items = append(m.items, item)
+	}
+	return m
+}
+
+// runInteractive lets the user browse the results of run and act on them
+func runInteractive(run *matchRun, s *snoozes) error {
+	p := tea.NewProgram(newInteractiveModel(run, s), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("error running TUI: %w", err)
+	}
+	return nil
+}
+
+// Init implements tea.Model
+func (m interactiveModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ensureCursorVisible()
+		return m, tea.ClearScreen
+
+	case interactiveDoneMsg:
+		m.status = msg.message
+		if msg.err != nil {
+			m.status = fmt.Sprintf("%s: %v", msg.message, msg.err)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		m.status = ""
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+
+		case "j", "down":
+			if m.cursor < len(m.items)-1 {
+				m.cursor++
+			}
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "g", "home":
+			m.cursor = 0
+		case "G", "end":
+			m.cursor = max(len(m.items)-1, 0)
+
+		case " ":
+			if len(m.items) > 0 {
+				number := m.items[m.cursor].result.PR.Number
+				if m.selected[number] {
+					delete(m.selected, number)
+				} else {
+					m.selected[number] = true
+				}
+				if m.cursor < len(m.items)-1 {
+					m.cursor++
+				}
+			}
+		case "v":
+			// Select all, or clear the selection if everything is selected
+			if len(m.selected) == len(m.items) {
+				m.selected = make(map[int]bool)
+			} else {
+				for _, item := range m.items {
+					m.selected[item.result.PR.Number] = true
+				}
+			}
+
+		case "o":
+			return m, openInBrowser(m.targets())
+		case "enter", "l":
+			return m, openInLazypr(m.targets())
+		case "s":
+			return m.snooze(), nil
+		case "S":
+			return m, m.subscribeTo(m.targets())
+		case "y":
+			return m, m.copyMarkdown(m.targets())
+		}
+		m.ensureCursorVisible()
+	}
+	return m, nil
+}
+
+// targets returns the items actions apply to: the selected ones, or the one
+// under the cursor
+func (m interactiveModel) targets() []interactiveItem {
+	var items []interactiveItem
+	for _, item := range m.items {
+		if m.selected[item.result.PR.Number] {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 && m.cursor < len(m.items) {
+		items = append(items, m.items[m.cursor])
+	}
+	return items
+}
+
+// ensureCursorVisible scrolls the list so the cursor is visible
+func (m *interactiveModel) ensureCursorVisible() {
+	visible := m.visibleItems()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+}
+
+// visibleItems returns the number of items fitting in the list pane
+func (m interactiveModel) visibleItems() int {
+	// 2 lines per item, in a pane below the header and above the footer
+	return max((m.height-6)/2, 1)
+}
+
+// snooze hides the targeted PRs for defaultSnoozeDuration, or until they
+// are updated
+func (m interactiveModel) snooze() interactiveModel {
+	targets := m.targets()
+	if len(targets) == 0 {
+		return m
+	}
+
+	now := m.now()
+	for _, item := range targets {
+		m.snoozes.add(item.result.PR, now.Add(defaultSnoozeDuration))
+	}
+	if err := m.snoozes.save(now); err != nil {
+		m.status = fmt.Sprintf("Failed to snooze: %v", err)
+		return m
+	}
+
+	m.items = slices.DeleteFunc(m.items, func(item interactiveItem) bool {
+		return m.snoozes.active(item.result.PR, now)
+	})
+	m.selected = make(map[int]bool)
+	m.cursor = min(m.cursor, max(len(m.items)-1, 0))
+	m.status = fmt.Sprintf("Snoozed %s for %s, or until updated", pluralPRs(len(targets)), formatDays(defaultSnoozeDuration))
+	m.ensureCursorVisible()
+	return m
+}
+
+// subscribeTo subscribes to notifications of the targeted PRs on GitHub
+func (m interactiveModel) subscribeTo(items []interactiveItem) tea.Cmd {
+	subscribe := m.subscribe
+	return func() tea.Msg {
+		var errs []error
+		for _, item := range items {
+			if err := subscribe(item.result.PR.Number); err != nil {
+				errs = append(errs, fmt.Errorf("#%d: %w", item.result.PR.Number, err))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return interactiveDoneMsg{message: "Failed to subscribe", err: err}
+		}
+		return interactiveDoneMsg{message: "Subscribed to " + pluralPRs(len(items))}
+	}
+}
+
+// copyMarkdown copies the targeted PRs to the clipboard, as a Markdown list
+func (m interactiveModel) copyMarkdown(items []interactiveItem) tea.Cmd {
+	copyText := m.copyText
+	return func() tea.Msg {
+		if err := copyText(markdownList(items)); err != nil {
+			return interactiveDoneMsg{message: "Failed to copy", err: err}
+		}
+		return interactiveDoneMsg{message: fmt.Sprintf("Copied %s as Markdown", pluralPRs(len(items)))}
+	}
+}
+
+// markdownList formats PRs as a Markdown list, e.g. to paste in an issue
+func markdownList(items []interactiveItem) string {
+	var b strings.Builder
+	for _, item := range items {
+		p := item.result.PR
+		fmt.Fprintf(&b, "- [#%d](%s) %s", p.Number, p.URL, p.Title)
+		if names := matchedDependencies(item.result.Matches); names != "" {
+			fmt.Fprintf(&b, " (%s)", names)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// openInBrowser opens PRs in the browser with gh
+func openInBrowser(items []interactiveItem) tea.Cmd {
+	return func() tea.Msg {
+		for _, item := range items {
+			cmd := exec.Command("gh", "pr", "view", "--web", "-R", nixpkgsRepo, fmt.Sprintf("%d", item.result.PR.Number))
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return interactiveDoneMsg{message: "Failed to open in browser", err: fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))}
+			}
+		}
+		return nil
+	}
+}
+
+// openInLazypr suspends the list to browse PRs in lazypr
+func openInLazypr(items []interactiveItem) tea.Cmd {
+	if len(items) == 0 {
+		return nil
+	}
+	if !tui.CommandExists("lazypr") {
+		return func() tea.Msg {
+			return interactiveDoneMsg{message: "lazypr not found in PATH"}
+		}
+	}
+
+	var refs []string
+	for _, item := range items {
+		refs = append(refs, fmt.Sprintf("%s#%d", nixpkgsRepo, item.result.PR.Number))
+	}
+	return tea.ExecProcess(exec.Command("lazypr", refs...), func(err error) tea.Msg {
+		if err != nil {
+			return interactiveDoneMsg{message: "lazypr failed", err: err}
+		}
+		return nil
+	})
+}
+
+// pluralPRs formats a number of PRs, e.g. "1 PR" or "3 PRs"
+func pluralPRs(n int) string {
+	if n == 1 {
+		return "1 PR"
+	}
+	return fmt.Sprintf("%d PRs", n)
+}
+
+// formatDays formats a duration in days
+func formatDays(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// View implements tea.Model
+func (m interactiveModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	header := m.styles.Header.Width(m.width).Render(fmt.Sprintf("nixpkgs-pr-watch - %s", pluralPRs(len(m.items))))
+
+	contentHeight := max(m.height-4, 1)
+	listWidth := m.width * 62 / 100
+	detailWidth := m.width - listWidth
+	list := m.styles.FocusedPane.Width(listWidth - 2).Height(contentHeight).Render(m.renderList(listWidth-4, contentHeight-2))
+	detail := m.styles.DetailPane.Width(detailWidth - 2).Height(contentHeight).Render(m.renderDetail(detailWidth-4, contentHeight-2))
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, lipgloss.JoinHorizontal(lipgloss.Top, list, detail), m.renderFooter())
+}
+
+// renderList renders the visible items, 2 lines each
+func (m interactiveModel) renderList(width, height int) string {
+	if len(m.items) == 0 {
+		return m.styles.PRAuthor.Render("No matching PRs")
+	}
+
+	var lines []string
+	end := min(m.offset+max(height/2, 1), len(m.items))
+	for i := m.offset; i < end; i++ {
+		item := m.items[i]
+		p := item.result.PR
+
+		marker := " "
+		if m.selected[p.Number] {
+			marker = "●"
+		}
+		confidence := item.result.HighestConfidence()
+		first := fmt.Sprintf("%s %s %s %s", marker, m.statusStyle(p).Render(statusIcon(p)), m.styles.PRNumber.Render(fmt.Sprintf("#%d", p.Number)), p.Title)
+
+		details := []string{confidence}
+		if names := matchedDependencies(item.result.Matches); names != "" {
+			details = append(details, names)
+		}
+		if item.bump != "" {
+			details = append(details, item.bump)
+		}
+		if len(item.hosts) > 0 {
+			details = append(details, strings.Join(item.hosts, ", "))
+		}
+		second := "    " + m.styles.PRAuthor.Render(strings.Join(details, " · "))
+
+		line := ansi.Truncate(first, width-2, "…") + "\n" + ansi.Truncate(second, width-2, "…")
+		if i == m.cursor {
+			line = m.styles.PRItemSelected.Width(width).Render(line)
+		} else {
+			line = m.styles.PRItem.Width(width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDetail renders the matches, versions and hosts of the item under
+// the cursor
+func (m interactiveModel) renderDetail(width, height int) string {
+	if m.cursor >= len(m.items) {
+		return ""
+	}
+	item := m.items[m.cursor]
+	p := item.result.PR
+
+	var lines []string
+	section := func(title string, values ...string) {
+		lines = append(lines, "", m.styles.SectionTitle.Render(title))
+		for _, v := range values {
+			lines = append(lines, m.styles.SectionBody.Render(v))
+		}
+	}
+
+	lines = append(lines,
+		m.styles.PRNumber.Render(fmt.Sprintf("#%d", p.Number))+" "+m.styles.PRTitle.Render(p.Title),
+		m.styles.PRAuthor.Render(fmt.Sprintf("@%s · %s · updated %s", p.Author, p.BaseRef, formatDate(p.UpdatedAt))),
+		m.styles.PRAuthor.Render(p.URL),
+	)
+
+	var matches []string
+	for _, match := range item.result.Matches {
+		matches = append(matches, fmt.Sprintf("%-6s %s", match.Confidence, matchReason(match)))
+	}
+	section(fmt.Sprintf("Matches (%s, score %d)", item.result.HighestConfidence(), item.result.Score), matches...)
+
+	if item.bump != "" {
+		section("Version", item.bump)
+	}
+	section("Status", m.statusStyle(p).Render(prStatus(p)))
+	if len(item.hosts) > 0 {
+		section("Hosts", strings.Join(item.hosts, ", "))
+	}
+	if len(p.Labels) > 0 {
+		var labels []string
+		for _, l := range p.Labels {
+			labels = append(labels, m.styles.Label.Render(l))
+		}
+		section("Labels", strings.Join(labels, " "))
+	}
+
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "…")
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderFooter renders the status message, or the key bindings
+func (m interactiveModel) renderFooter() string {
+	if m.status != "" {
+		status := lipgloss.NewStyle().Foreground(m.styles.Theme.WarnFg).Bold(true)
+		return m.styles.Footer.Width(m.width).Render(status.Render(m.status))
+	}
+
+	hints := []string{"j/k: navigate", "Space: select", "v: all"}
+	if len(m.selected) > 0 {
+		hints = append(hints, fmt.Sprintf("[%d selected]", len(m.selected)))
+	}
+	hints = append(hints, "o: open", "Enter: lazypr", "s: snooze", "S: subscribe", "y: copy Markdown", "q: quit")
+	return m.styles.Footer.Width(m.width).Render(strings.Join(hints, "  "))
+}
+
+// statusIcon returns the icon of the status of a PR
+func statusIcon(p pr.PullRequest) string {
+	switch prStatus(p) {
+	case "conflicts":
+		return "⚠"
+	case "failing":
+		return "✗"
+	case "passing":
+		return "✓"
+	case "pending":
+		return "○"
+	default:
+		return "?"
+	}
+}
+
+// statusStyle returns the style of the status of a PR
+func (m interactiveModel) statusStyle(p pr.PullRequest) lipgloss.Style {
+	switch prStatus(p) {
+	case "conflicts", "failing":
+		return m.styles.StatusError
+	case "passing":
+		return m.styles.StatusSuccess
+	case "pending":
+		return m.styles.StatusPending
+	default:
+		return m.styles.StatusUnknown
+	}
+}
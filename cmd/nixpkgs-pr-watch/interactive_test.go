@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func testInteractiveModel(t *testing.T) interactiveModel {
+	t.Helper()
+	s, err := loadSnoozes(filepath.Join(t.TempDir(), "snoozed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := testRun(testMatch(1, "MERGEABLE", "SUCCESS"), testMatch(2, "CONFLICTING", ""), testMatch(3, "MERGEABLE", "PENDING"))
+	m := newInteractiveModel(run, s)
+	m.now = func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) }
+	return m
+}
+
+func press(t *testing.T, m interactiveModel, keys ...string) (interactiveModel, tea.Cmd) {
+	t.Helper()
+	var cmd tea.Cmd
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(key)}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		var model tea.Model
+		model, cmd = m.Update(msg)
+		m = model.(interactiveModel)
+	}
+	return m, cmd
+}
+
+func targetNumbers(m interactiveModel) []int {
+	var numbers []int
+	for _, item := range m.targets() {
+		numbers = append(numbers, item.result.PR.Number)
+	}
+	return numbers
+}
+
+func TestInteractive_Selection(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []int
+	}{
+		{name: "cursor without selection", keys: []string{"j"}, want: []int{2}},
+		{name: "space selects and moves down", keys: []string{" ", " "}, want: []int{1, 2}},
+		{name: "space toggles", keys: []string{" ", " ", "k", " "}, want: []int{1}},
+		{name: "select all", keys: []string{"v"}, want: []int{1, 2, 3}},
+		{name: "clear all", keys: []string{"v", "v", "G"}, want: []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := press(t, testInteractiveModel(t), tt.keys...)
+			got := targetNumbers(m)
+			if len(got) != len(tt.want) {
+				t.Fatalf("targets = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("targets = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestInteractive_Snooze(t *testing.T) {
+	m, _ := press(t, testInteractiveModel(t), " ", "j", " ", "s")
+
+	if len(m.items) != 1 || m.items[0].result.PR.Number != 2 || m.cursor != 0 {
+		t.Fatalf("items after snoozing = %+v, cursor %d", m.items, m.cursor)
+	}
+	if !strings.Contains(m.status, "Snoozed 2 PRs for 7 days") {
+		t.Errorf("status = %q", m.status)
+	}
+
+	// Snoozes are persisted and hide PRs until they expire or are updated
+	s, err := loadSnoozes(m.snoozes.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := m.now()
+	results := []pr.MatchResult{testMatch(1, "", ""), testMatch(2, "", ""), testMatch(3, "", "")}
+	results[2].PR.UpdatedAt = now.Add(time.Hour)
+	kept, hidden := s.filter(results, now)
+	if hidden != 1 || len(kept) != 2 || kept[0].PR.Number != 2 || kept[1].PR.Number != 3 {
+		t.Errorf("filter() = %d kept, %d hidden", len(kept), hidden)
+	}
+	if kept, _ := s.filter(results, now.Add(defaultSnoozeDuration)); len(kept) != 3 {
+		t.Errorf("expired snoozes still hide PRs: %d kept", len(kept))
+	}
+}
+
+func TestInteractive_Actions(t *testing.T) {
+	m := testInteractiveModel(t)
+	var subscribed []int
+	m.subscribe = func(number int) error {
+		subscribed = append(subscribed, number)
+		if number == 3 {
+			return errors.New("forbidden")
+		}
+		return nil
+	}
+	var copied string
+	m.copyText = func(text string) error {
+		copied = text
+		return nil
+	}
+
+	m, cmd := press(t, m, " ", "S")
+	if msg := cmd().(interactiveDoneMsg); msg.err != nil || msg.message != "Subscribed to 1 PR" || len(subscribed) != 1 {
+		t.Errorf("subscribe = %+v, subscribed to %v", msg, subscribed)
+	}
+	m, cmd = press(t, m, "G", " ", "S")
+	msg := cmd().(interactiveDoneMsg)
+	if msg.err == nil || !strings.Contains(msg.err.Error(), "#3: forbidden") {
+		t.Errorf("subscribe error = %v", msg.err)
+	}
+
+	// The outcome of actions is shown in the footer
+	model, _ := m.Update(msg)
+	m = model.(interactiveModel)
+	if !strings.Contains(m.status, "Failed to subscribe") {
+		t.Errorf("status = %q", m.status)
+	}
+
+	_, cmd = press(t, m, "y")
+	cmd()
+	want := "- [#1](https://github.com/NixOS/nixpkgs/pull/1) foo: 1.0 -> 1.1 (foo)\n" +
+		"- [#3](https://github.com/NixOS/nixpkgs/pull/3) foo: 1.0 -> 1.1 (foo)\n"
+	if copied != want {
+		t.Errorf("copied:\n%s\nwant:\n%s", copied, want)
+	}
+}
+
+func TestInteractive_View(t *testing.T) {
+	model, _ := testInteractiveModel(t).Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m, _ := press(t, model.(interactiveModel), "j", " ", "k")
+	view := m.View()
+
+	for _, want := range []string{"nixpkgs-pr-watch - 3 PRs", "#2", "1.0 → 1.1", "changes installed package foo", "conflicts", "kyushu", "[1 selected]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := ansi.StringWidth(line); w > 120 {
+			t.Errorf("line wider than the terminal (%d): %q", w, line)
+		}
+	}
+}
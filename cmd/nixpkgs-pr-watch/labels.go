@@ -0,0 +1,153 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.sbr.pm/x/internal/pr"
+)
+
+// Groupings of the terminal report
+const (
+	groupByConfidence  = "confidence"
+	groupByLabelPrefix = "label-prefix"
+	groupByPackage     = "package"
+)
+
+// groupings are the valid --group-by values
+var groupings = []string{groupByConfidence, groupByLabelPrefix, groupByPackage}
+
+// otherLabels is the family of labels without a prefix, and noLabels the
+// group of PRs without labels
+const (
+	otherLabels = "other"
+	noLabels    = "no labels"
+)
+
+// validateLabelGlobs checks the --label and --exclude-label globs
+func validateLabelGlobs(globs []string) error {
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid label glob %q: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// matchesLabel reports whether one of the labels matches one of the globs
+func matchesLabel(labels, globs []string) bool {
+	return slices.ContainsFunc(labels, func(label string) bool {
+		return slices.ContainsFunc(globs, func(glob string) bool {
+			ok, _ := path.Match(glob, label)
+			return ok
+		})
+	})
+}
+
+// filterByLabels returns the PRs with a label matching one of the include
+// globs, if any, and none matching the exclude globs
+func filterByLabels(prs []pr.PullRequest, include, exclude []string) []pr.PullRequest {
+	return slices.DeleteFunc(slices.Clone(prs), func(p pr.PullRequest) bool {
+		if len(include) > 0 && !matchesLabel(p.Labels, include) {
+			return true
+		}
+		return matchesLabel(p.Labels, exclude)
+	})
+}
+
+// splitLabel splits a nixpkgs label into its family and value, e.g.
+// "6.topic: python" into "6.topic" and "python". Labels without a prefix
+// are in the other family.
+func splitLabel(label string) (family, value string) {
+	family, value, ok := strings.Cut(label, ":")
+	if !ok {
+		return otherLabels, label
+	}
+	return strings.TrimSpace(family), strings.TrimSpace(value)
+}
+
+// labelGroup is the results with a label
+type labelGroup struct {
+	label   string // Value of the label in its family
+	results []pr.MatchResult
+}
+
+// labelFamily is the results with labels of a family, by label
+type labelFamily struct {
+	name   string
+	groups []labelGroup
+}
+
+// groupByLabels buckets results by label family, then label, in the order
+// of their numeric prefixes (e.g. 2.status before 10.rebuild-linux, and
+// 11-100 before 101-500 rebuilds). A PR is in the group of each of its
+// labels, and PRs without labels are grouped last.
+func groupByLabels(results []pr.MatchResult) []labelFamily {
+	byFamily := make(map[string]map[string][]pr.MatchResult)
+	var unlabeled []pr.MatchResult
+	for _, r := range results {
+		if len(r.PR.Labels) == 0 {
+			unlabeled = append(unlabeled, r)
+			continue
+		}
+		for _, label := range r.PR.Labels {
+			family, value := splitLabel(label)
+			if byFamily[family] == nil {
+				byFamily[family] = make(map[string][]pr.MatchResult)
+			}
+			// PRs may have the same label twice in their family once split
+			if !slices.ContainsFunc(byFamily[family][value], func(o pr.MatchResult) bool { return o.PR.Number == r.PR.Number }) {
+				byFamily[family][value] = append(byFamily[family][value], r)
+			}
+		}
+	}
+
+	var families []labelFamily
+	for family, labels := range byFamily {
+		f := labelFamily{name: family}
+		for label, results := range labels {
+			f.groups = append(f.groups, labelGroup{label: label, results: results})
+		}
+		slices.SortFunc(f.groups, func(a, b labelGroup) int { return compareNumbered(a.label, b.label) })
+		families = append(families, f)
+	}
+	slices.SortFunc(families, func(a, b labelFamily) int {
+		// Labels without a prefix come last
+		if (a.name == otherLabels) != (b.name == otherLabels) {
+			if a.name == otherLabels {
+				return 1
+			}
+			return -1
+		}
+		return compareNumbered(a.name, b.name)
+	})
+	if len(unlabeled) > 0 {
+		families = append(families, labelFamily{name: noLabels, groups: []labelGroup{{results: unlabeled}}})
+	}
+	return families
+}
+
+// compareNumbered compares strings by their leading number, if both have
+// one, then alphabetically
+func compareNumbered(a, b string) int {
+	na, oka := leadingNumber(a)
+	nb, okb := leadingNumber(b)
+	if oka && okb && na != nb {
+		return cmp.Compare(na, nb)
+	}
+	return strings.Compare(a, b)
+}
+
+// leadingNumber returns the number s starts with
+func leadingNumber(s string) (int, bool) {
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(s)
+	}
+	n, err := strconv.Atoi(s[:end])
+	return n, err == nil
+}
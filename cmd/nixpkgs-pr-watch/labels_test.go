@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func labeledMatch(number int, labels ...string) pr.MatchResult {
+	r := testMatch(number, "MERGEABLE", "SUCCESS")
+	r.PR.Labels = labels
+	return r
+}
+
+func TestFilterByLabels(t *testing.T) {
+	prs := []pr.PullRequest{
+		{Number: 1, Labels: []string{"6.topic: python", "10.rebuild-linux: 1-10"}},
+		{Number: 2, Labels: []string{"6.topic: python3.12", "2.status: stale"}},
+		{Number: 3, Labels: []string{"6.topic: rust"}},
+		{Number: 4},
+	}
+
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []int
+	}{
+		{name: "no filter", want: []int{1, 2, 3, 4}},
+		{name: "include glob", include: []string{"6.topic: python*"}, want: []int{1, 2}},
+		{name: "include any glob", include: []string{"6.topic: rust", "10.rebuild-linux: *"}, want: []int{1, 3}},
+		{name: "exclude", exclude: []string{"2.status: stale"}, want: []int{1, 3, 4}},
+		{name: "include and exclude", include: []string{"6.topic: *"}, exclude: []string{"2.status: *"}, want: []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, p := range filterByLabels(prs, tt.include, tt.exclude) {
+				got = append(got, p.Number)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterByLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := validateLabelGlobs([]string{"6.topic: [python"}); err == nil {
+		t.Error("validateLabelGlobs() should reject malformed globs")
+	}
+}
+
+func TestGroupByLabels(t *testing.T) {
+	results := []pr.MatchResult{
+		labeledMatch(1, "10.rebuild-linux: 101-500", "6.topic: python"),
+		labeledMatch(2, "10.rebuild-linux: 11-100", "backport release-25.05"),
+		labeledMatch(3, "2.status: merge conflict", "6.topic: python"),
+		labeledMatch(4),
+	}
+
+	var got []string
+	for _, family := range groupByLabels(results) {
+		for _, g := range family.groups {
+			var numbers []string
+			for _, r := range g.results {
+				numbers = append(numbers, "#"+strings.TrimPrefix(r.PR.URL, "https://github.com/NixOS/nixpkgs/pull/"))
+			}
+			got = append(got, family.name+"/"+g.label+" "+strings.Join(numbers, ","))
+		}
+	}
+	want := []string{
+		"2.status/merge conflict #3",
+		"6.topic/python #1,#3",
+		"10.rebuild-linux/11-100 #2",
+		"10.rebuild-linux/101-500 #1",
+		"other/backport release-25.05 #2",
+		"no labels/ #4",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("groupByLabels() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestOutputTerminal_GroupByLabelPrefix(t *testing.T) {
+	var stdout bytes.Buffer
+	out := output.NewWriter(&stdout, &bytes.Buffer{}, false)
+	out.SetWidth(80)
+
+	results := []pr.MatchResult{labeledMatch(1, "6.topic: python"), labeledMatch(2)}
+	d := &deps.Dependencies{Packages: []deps.Package{{Name: "foo"}}}
+	if err := outputTerminal(out, results, d, []string{"kyushu"}, nil, watchFlags{layout: layoutCompact, groupBy: groupByLabelPrefix}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stdout.String()
+	for _, want := range []string{"6.TOPIC", "python (1)", "NO LABELS", "[#2]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "CONFIDENCE MATCHES") {
+		t.Errorf("output grouped by confidence:\n%s", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.sbr.pm/x/internal/cmdutil"
 	"go.sbr.pm/x/internal/output"
 )
 
@@ -21,19 +23,8 @@ func rootCmd() *cobra.Command {
 	out := output.Default()
 
 	var (
-		host          string
-		allHosts      bool
-		flakePath     string
-		limit         int
-		outputFormat  string
-		minConfidence string
-		user          string
-		baseBranch    string
-		refreshDeps   bool
-		refreshPRs    bool
-		refresh       bool
-		compact       bool
-		sortBy        string
+		flags   watchFlags
+		compact bool
 	)
 
 	cmd := &cobra.Command{
@@ -45,43 +36,89 @@ to your system. Helps track updates and security fixes for packages you use.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runWatch(out, watchFlags{
-				host:          host,
-				allHosts:      allHosts,
-				flakePath:     flakePath,
-				limit:         limit,
-				outputFormat:  outputFormat,
-				minConfidence: minConfidence,
-				user:          user,
-				baseBranch:    baseBranch,
-				refreshDeps:   refreshDeps || refresh,
-				refreshPRs:    refreshPRs || refresh,
-				compact:       compact,
-				sortBy:        sortBy,
-			})
+			if compact {
+				flags.layout = layoutCompact
+			}
+			if err := validateTerminalFlags(flags.layout, flags.collapse, flags.groupBy); err != nil {
+				return err
+			}
+			if flags.feedFile != "" {
+				if !cmd.Flags().Changed("output") {
+					flags.format.Format = formatAtom
+				}
+				if flags.format.Format != formatAtom && flags.format.Format != formatRSS {
+					return fmt.Errorf("--feed-file requires -o atom or -o rss")
+				}
+			}
+			flags.profile, _ = cmd.Flags().GetString("profile")
+			return runWatch(cmd.Context(), out, flags.resolve())
 		},
 	}
 
-	cmd.Flags().StringVar(&host, "host", "", "Analyze specific host (default: current host)")
-	cmd.Flags().BoolVar(&allHosts, "all-hosts", false, "Analyze all hosts in flake")
-	cmd.Flags().StringVar(&flakePath, "flake", ".", "Path to flake directory")
-	cmd.Flags().IntVar(&limit, "limit", 500, "Maximum number of PRs to fetch")
-	cmd.Flags().StringVarP(&outputFormat, "output", "o", "terminal", "Output format (terminal, json, urls)")
-	cmd.Flags().StringVar(&minConfidence, "min-confidence", "medium", "Minimum confidence level (high, medium, low)")
-	cmd.Flags().StringVar(&user, "user", "", "Filter PRs by author username (e.g., r-ryantm)")
-	cmd.Flags().StringVar(&baseBranch, "base-branch", "master", "Filter PRs by base branch (default: master)")
-	cmd.Flags().BoolVar(&refreshDeps, "refresh-deps", false, "Refresh dependency cache")
-	cmd.Flags().BoolVar(&refreshPRs, "refresh-prs", false, "Refresh PR cache")
-	cmd.Flags().BoolVar(&refresh, "refresh", false, "Refresh all caches")
-	cmd.Flags().BoolVar(&compact, "compact", false, "Compact output (2 lines per PR)")
-	cmd.Flags().StringVar(&sortBy, "sort", "created", "Sort PRs by: created, updated")
+	// Flags set by the configuration file are persistent, so config show
+	// prints the configuration they override
+	addMatchFlags(cmd, cmd.PersistentFlags(), &flags)
+	addNotifyFlags(cmd.PersistentFlags(), &flags)
+	cmd.PersistentFlags().StringVar(&flags.sortBy, "sort", "created", "Sort PRs by: created, updated")
+	cmdutil.AddFormatFlags(cmd, &flags.format, report{}, "terminal")
+	cmd.Flags().BoolVar(&compact, "compact", false, "Compact output (2 lines per PR, same as --layout compact)")
+	cmd.Flags().StringVar(&flags.layout, "layout", layoutFull, "Terminal layout (full, compact, table)")
+	cmd.Flags().StringSliceVar(&flags.collapse, "collapse", nil, "Confidence levels to collapse to a one-line summary (high, medium, low)")
+	cmd.Flags().StringVar(&flags.groupBy, "group-by", groupByConfidence, "Group the report by: confidence, label-prefix, package (also in JSON)")
+	cmd.Flags().StringVar(&flags.feedFile, "feed-file", "", "Merge matches into an Atom or RSS feed file instead of printing them (default format: atom)")
+	cmd.Flags().BoolVarP(&flags.interactive, "interactive", "i", false, "Browse matches in an interactive list, to open, snooze or subscribe to PRs")
+	cmd.Flags().BoolVar(&flags.includeSnoozed, "include-snoozed", false, "Include snoozed PRs in the interactive list")
+	cmd.Flags().BoolVar(&flags.onlyNew, "new", false, "Only show PRs not seen before (see mark-seen)")
+	cmd.Flags().BoolVar(&flags.onlyChanged, "changed", false, "Only show PRs updated or whose status changed since seen (see mark-seen)")
+	cmd.MarkFlagsMutuallyExclusive("compact", "layout")
+	cmd.MarkFlagsMutuallyExclusive("feed-file", "format")
+	cmd.MarkFlagsMutuallyExclusive("interactive", "output")
+	cmd.MarkFlagsMutuallyExclusive("interactive", "format")
+	cmd.MarkFlagsMutuallyExclusive("interactive", "feed-file")
+
+	addConfigFlags(cmd, out)
+	cmdutil.AddOutputFlags(cmd, out)
 
 	cmd.AddCommand(versionCmd())
+	cmd.AddCommand(watchCmd(out))
+	cmd.AddCommand(serveCmd(out))
+	cmd.AddCommand(markSeenCmd(out))
+	cmd.AddCommand(tryCmd(out))
+	cmd.AddCommand(configCmd(&flags))
 	cmd.AddCommand(cacheCmd(out))
+	cmd.AddCommand(cmdutil.PathsCmd(out, "nixpkgs-pr-watch"))
 
 	return cmd
 }
 
+// addMatchFlags adds the flags selecting dependencies and PRs to match to
+// fs, one of the flag sets of cmd, shared by the report and the watch daemon
+func addMatchFlags(cmd *cobra.Command, fs *pflag.FlagSet, flags *watchFlags) {
+	fs.StringSliceVar(&flags.hosts, "host", nil, "Analyze specific hosts, comma-separated or repeated (default: current host)")
+	fs.BoolVar(&flags.allHosts, "all-hosts", false, "Analyze all hosts in flake")
+	fs.StringVar(&flags.flakePath, "flake", ".", "Path to flake directory")
+	fs.StringSliceVar(&flags.repos, "repo", nil, "Extra flake repositories whose hosts are all analyzed too, comma-separated or repeated")
+	fs.StringVar(&flags.depsFile, "deps-file", "", "Read dependencies from a file (JSON Dependencies document or list of package names)")
+	fs.StringVar(&flags.nixosConfig, "nixos-config", "", "Path to a channel-based configuration.nix (evaluated with nix-instantiate)")
+	fs.IntVar(&flags.limit, "limit", 500, "Maximum number of PRs to fetch")
+	fs.StringVar(&flags.minConfidence, "min-confidence", "medium", "Minimum confidence level (high, medium, low)")
+	fs.StringVar(&flags.user, "user", "", "Filter PRs by author username (e.g., r-ryantm)")
+	fs.StringVar(&flags.baseBranch, "base-branch", autoBaseBranch, "Filter PRs by base branches, comma-separated (auto: detect per host from flake.lock, all-dev: master, staging and staging-next, empty: any branch)")
+	fs.BoolVar(&flags.refreshDeps, "refresh-deps", false, "Refresh dependency cache")
+	fs.BoolVar(&flags.refreshPRs, "refresh-prs", false, "Refresh PR cache")
+	fs.BoolVar(&flags.refresh, "refresh", false, "Refresh all caches")
+	fs.StringSliceVar(&flags.ignore, "ignore", nil, "Ignore packages and services matching a glob (e.g. 'python3*'), comma-separated or repeated")
+	fs.IntSliceVar(&flags.ignorePRs, "ignore-pr", nil, "Ignore PRs by number, comma-separated or repeated")
+	fs.StringSliceVar(&flags.labels, "label", nil, "Only match PRs with a label matching a glob (e.g. '6.topic: python*'), comma-separated or repeated")
+	fs.StringSliceVar(&flags.excludeLabels, "exclude-label", nil, "Don't match PRs with a label matching a glob (e.g. '2.status: stale'), comma-separated or repeated")
+
+	cmd.MarkFlagsMutuallyExclusive("deps-file", "nixos-config", "all-hosts")
+	cmd.MarkFlagsMutuallyExclusive("deps-file", "flake")
+	cmd.MarkFlagsMutuallyExclusive("deps-file", "repo")
+	cmd.MarkFlagsMutuallyExclusive("nixos-config", "repo")
+	cmd.MarkFlagsMutuallyExclusive("nixos-config", "flake")
+}
+
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
@@ -93,16 +130,42 @@ func versionCmd() *cobra.Command {
 }
 
 type watchFlags struct {
-	host          string
-	allHosts      bool
-	flakePath     string
-	limit         int
-	outputFormat  string
-	minConfidence string
-	user          string
-	baseBranch    string
-	refreshDeps   bool
-	refreshPRs    bool
-	compact       bool
-	sortBy        string
+	hosts          []string
+	allHosts       bool
+	flakePath      string
+	repos          []string // Extra flake repositories
+	depsFile       string
+	nixosConfig    string
+	limit          int
+	format         output.FormatOptions
+	minConfidence  string
+	user           string
+	baseBranch     string
+	refreshDeps    bool
+	refreshPRs     bool
+	refresh        bool
+	ignore         []string
+	ignorePRs      []int
+	labels         []string // Label globs PRs must match one of
+	excludeLabels  []string
+	layout         string
+	collapse       []string
+	groupBy        string
+	sortBy         string
+	notify         []string
+	feedFile       string
+	interactive    bool
+	includeSnoozed bool
+	onlyNew        bool
+	onlyChanged    bool
+	profile        string // Configuration profile, keeping its own seen state
+}
+
+// resolve applies flags implying others
+func (f watchFlags) resolve() watchFlags {
+	if f.refresh {
+		f.refreshDeps = true
+		f.refreshPRs = true
+	}
+	return f
 }
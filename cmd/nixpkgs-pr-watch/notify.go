@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.sbr.pm/x/internal/notify"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// addNotifyFlags adds the flag selecting notification targets to fs
+func addNotifyFlags(fs *pflag.FlagSet, flags *watchFlags) {
+	fs.StringArrayVar(&flags.notify, "notify", nil,
+		"Notify new matches to a target (ntfy:<url>, webhook:<url>, desktop, smtp://...; repeatable)")
+}
+
+// notifier delivers notifications to the --notify targets, remembering
+// delivered ones in the state directory
+type notifier struct {
+	notifier *notify.Notifier
+	seen     *notify.Seen
+}
+
+// newNotifier parses notification targets, returning nil without targets.
+// Delivered notifications are recorded per profile.
+func newNotifier(out *output.Writer, specs []string, profile string) (*notifier, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	var targets []*notify.Target
+	for _, spec := range specs {
+		target, err := notify.Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	path, err := profileStatePath("notified", profile)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := notify.LoadSeen(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notifier{notifier: notify.New(out, seen, targets...), seen: seen}, nil
+}
+
+// send delivers notifications and records them as delivered
+func (n *notifier) send(ctx context.Context, notifications []notify.Notification) error {
+	err := n.notifier.Notify(ctx, notifications)
+	if saveErr := n.seen.Save(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// matchNotifications returns notifications announcing matching PRs
+func matchNotifications(results []pr.MatchResult) []notify.Notification {
+	now := time.Now()
+	notifications := make([]notify.Notification, len(results))
+	for i, r := range results {
+		notifications[i] = notify.Notification{
+			Event:      eventNew,
+			Number:     r.PR.Number,
+			Title:      r.PR.Title,
+			URL:        r.PR.URL,
+			Confidence: r.HighestConfidence(),
+			Security:   r.PR.IsSecurity(),
+			Time:       now,
+		}
+	}
+	return notifications
+}
+
+// eventNotifications returns notifications announcing watch events
+func eventNotifications(events []watchEvent) []notify.Notification {
+	notifications := make([]notify.Notification, len(events))
+	for i, e := range events {
+		notifications[i] = notify.Notification{
+			Event:      e.Type,
+			Number:     e.Number,
+			Title:      e.Title,
+			URL:        e.URL,
+			Confidence: e.Confidence,
+			Security:   e.Security,
+			Detail:     e.Detail,
+			Time:       e.Time,
+		}
+	}
+	return notifications
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.sbr.pm/x/internal/pr"
+)
+
+// statusRanks orders PRs of a package group, from the closest to being
+// merged
+var statusRanks = map[string]int{"passing": 0, "pending": 1, "-": 2, "failing": 3, "conflicts": 4}
+
+// packageGroup is the PRs touching a dependency
+type packageGroup struct {
+	Dependency string      `json:"dependency"`
+	Type       string      `json:"type"` // Type of the first match: package, module, service or title
+	PRs        []packagePR `json:"prs"`
+	// Versions PRs update the dependency to, competing when there are
+	// several of them
+	Versions  []string `json:"versions,omitempty"`
+	Competing bool     `json:"competing,omitempty"`
+}
+
+// packagePR is a PR of a package group
+type packagePR struct {
+	Number      int       `json:"number"`
+	Status      string    `json:"status"` // passing, pending, failing, conflicts or -
+	From        string    `json:"from,omitempty"`
+	To          string    `json:"to,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	DuplicateOf int       `json:"duplicate_of,omitempty"` // Older PR updating to the same version
+
+	result pr.MatchResult
+}
+
+// groupByPackages lists the PRs touching each matched dependency, sorted
+// by status then age, oldest first. PRs updating a dependency to the same
+// version as an older one are duplicates of it, and PRs updating it to
+// different versions compete. Groups with the most PRs come first.
+func groupByPackages(results []pr.MatchResult) []packageGroup {
+	var groups []packageGroup
+	index := make(map[string]int)
+	for _, r := range results {
+		for _, m := range r.Matches {
+			// Only the package in the title is updated to its versions,
+			// not the other dependencies the PR touches
+			from, to := dependencyBump(r.PR.Title, m.Dependency)
+			p := packagePR{
+				Number:    r.PR.Number,
+				Status:    prStatus(r.PR),
+				From:      from,
+				To:        to,
+				CreatedAt: r.PR.CreatedAt,
+				result:    r,
+			}
+			i, ok := index[m.Dependency]
+			if !ok {
+				i = len(groups)
+				index[m.Dependency] = i
+				groups = append(groups, packageGroup{Dependency: m.Dependency, Type: m.Type})
+			}
+			if !slices.ContainsFunc(groups[i].PRs, func(o packagePR) bool { return o.Number == p.Number }) {
+				groups[i].PRs = append(groups[i].PRs, p)
+			}
+		}
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		slices.SortFunc(g.PRs, func(a, b packagePR) int { return a.CreatedAt.Compare(b.CreatedAt) })
+		first := make(map[string]int)
+		for j, p := range g.PRs {
+			if p.To == "" {
+				continue
+			}
+			if number, ok := first[p.To]; ok {
+				g.PRs[j].DuplicateOf = number
+				continue
+			}
+			first[p.To] = p.Number
+			g.Versions = append(g.Versions, p.To)
+		}
+		g.Competing = len(g.Versions) > 1
+		slices.SortStableFunc(g.PRs, func(a, b packagePR) int { return cmp.Compare(statusRanks[a.Status], statusRanks[b.Status]) })
+	}
+
+	slices.SortStableFunc(groups, func(a, b packageGroup) int {
+		if c := cmp.Compare(len(b.PRs), len(a.PRs)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Dependency, b.Dependency)
+	})
+	return groups
+}
+
+// dependencyBump returns the old and new versions of dependency in an update
+// PR title, if the title updates it, e.g. "python3Packages.requests: 2.31.0
+// -> 2.32.0" for requests
+func dependencyBump(title, dependency string) (from, to string) {
+	m := versionBumpRe.FindStringSubmatch(title)
+	if m == nil {
+		return "", ""
+	}
+	attr := m[1]
+	name := attr[strings.LastIndex(attr, ".")+1:]
+	if !strings.EqualFold(attr, dependency) && !strings.EqualFold(name, dependency) {
+		return "", ""
+	}
+	return m[2], m[3]
+}
+
+// duplicates returns the PRs of the group duplicating an older one
+func (g packageGroup) duplicates() []packagePR {
+	return slices.DeleteFunc(slices.Clone(g.PRs), func(p packagePR) bool { return p.DuplicateOf == 0 })
+}
+
+// results returns the matches of the PRs of the group
+func (g packageGroup) results() []pr.MatchResult {
+	results := make([]pr.MatchResult, len(g.PRs))
+	for i, p := range g.PRs {
+		results[i] = p.result
+	}
+	return results
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func packageMatch(number int, title, status string, created time.Time, dependencies ...string) pr.MatchResult {
+	r := testMatch(number, "MERGEABLE", status)
+	r.PR.Title = title
+	r.PR.CreatedAt = created
+	r.Matches = nil
+	for _, d := range dependencies {
+		r.Matches = append(r.Matches, pr.Match{Type: "package", Dependency: d, Confidence: "high"})
+	}
+	return r
+}
+
+func testPackageResults() []pr.MatchResult {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	return []pr.MatchResult{
+		packageMatch(1, "firefox: 121.0 -> 122.0", "FAILURE", day, "firefox"),
+		packageMatch(2, "firefox: 121.0 -> 123.0", "SUCCESS", day.Add(24*time.Hour), "firefox"),
+		packageMatch(3, "firefox: 121.0 -> 122.0", "SUCCESS", day.Add(48*time.Hour), "firefox"),
+		packageMatch(4, "git: 2.43.0 -> 2.44.0", "SUCCESS", day, "git", "git-lfs"),
+	}
+}
+
+func TestGroupByPackages(t *testing.T) {
+	groups := groupByPackages(testPackageResults())
+
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Dependency)
+	}
+	if !slices.Equal(names, []string{"firefox", "git", "git-lfs"}) {
+		t.Fatalf("groups = %v", names)
+	}
+
+	firefox := groups[0]
+	var numbers []int
+	for _, p := range firefox.PRs {
+		numbers = append(numbers, p.Number)
+	}
+	// Passing PRs first, oldest first
+	if !slices.Equal(numbers, []int{2, 3, 1}) {
+		t.Errorf("firefox PRs = %v, want [2 3 1]", numbers)
+	}
+	if !firefox.Competing || !slices.Equal(firefox.Versions, []string{"122.0", "123.0"}) {
+		t.Errorf("firefox competing = %v, versions %v", firefox.Competing, firefox.Versions)
+	}
+	if dups := firefox.duplicates(); len(dups) != 1 || dups[0].Number != 3 || dups[0].DuplicateOf != 1 {
+		t.Errorf("firefox duplicates = %+v", dups)
+	}
+
+	if git := groups[1]; git.Competing || len(git.duplicates()) != 0 || len(git.PRs) != 1 || git.PRs[0].To != "2.44.0" {
+		t.Errorf("git = %+v", git)
+	}
+	// The PR updates git, not git-lfs it also touches
+	if lfs := groups[2]; len(lfs.PRs) != 1 || lfs.PRs[0].To != "" || lfs.Versions != nil {
+		t.Errorf("git-lfs = %+v", lfs)
+	}
+}
+
+func TestDependencyBump(t *testing.T) {
+	tests := []struct {
+		title, dependency string
+		from, to          string
+	}{
+		{title: "git: 2.43.0 -> 2.44.0", dependency: "git", from: "2.43.0", to: "2.44.0"},
+		{title: "git: 2.43.0 -> 2.44.0", dependency: "git-lfs"},
+		{title: "python3Packages.requests: 2.31.0 -> 2.32.0", dependency: "requests", from: "2.31.0", to: "2.32.0"},
+		{title: "python3Packages.requests: 2.31.0 -> 2.32.0", dependency: "python3Packages.requests", from: "2.31.0", to: "2.32.0"},
+		{title: "git: fix build", dependency: "git"},
+	}
+
+	for _, tt := range tests {
+		from, to := dependencyBump(tt.title, tt.dependency)
+		if from != tt.from || to != tt.to {
+			t.Errorf("dependencyBump(%q, %q) = %q, %q, want %q, %q", tt.title, tt.dependency, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestGroupByPackage_JSON(t *testing.T) {
+	run := testRun(testPackageResults()...)
+	run.results = run.all
+	rep := run.report()
+	rep.Packages = groupByPackages(run.results)
+
+	var buf bytes.Buffer
+	if err := output.Render(&buf, output.FormatOptions{Format: "json"}, rep); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Packages []struct {
+			Dependency string   `json:"dependency"`
+			Competing  bool     `json:"competing"`
+			Versions   []string `json:"versions"`
+			PRs        []struct {
+				Number      int    `json:"number"`
+				Status      string `json:"status"`
+				To          string `json:"to"`
+				DuplicateOf int    `json:"duplicate_of"`
+			} `json:"prs"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Packages) != 3 || got.Packages[0].Dependency != "firefox" || !got.Packages[0].Competing {
+		t.Fatalf("packages = %+v", got.Packages)
+	}
+	if p := got.Packages[0].PRs[1]; p.Number != 3 || p.DuplicateOf != 1 || p.To != "122.0" || p.Status != "passing" {
+		t.Errorf("duplicate PR = %+v", p)
+	}
+}
+
+func TestOutputTerminal_GroupByPackage(t *testing.T) {
+	var stdout bytes.Buffer
+	out := output.NewWriter(&stdout, &bytes.Buffer{}, false)
+	out.SetWidth(100)
+
+	d := &deps.Dependencies{Packages: []deps.Package{{Name: "firefox"}, {Name: "git"}}}
+	if err := outputTerminal(out, testPackageResults(), d, []string{"kyushu"}, nil, watchFlags{layout: layoutTable, groupBy: groupByPackage}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stdout.String()
+	for _, want := range []string{"firefox (package, 3 PRs) COMPETING: 122.0, 123.0", "#3 duplicates #1 (→ 122.0)", "git (package, 1 PR)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
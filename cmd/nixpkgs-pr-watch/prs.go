@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.sbr.pm/x/internal/cache"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+	"go.sbr.pm/x/internal/progress"
+)
+
+// prCacheTTL is how long fetched PRs are considered fresh
+const prCacheTTL = 6 * time.Hour
+
+// prCacheMetadata tracks how many PRs are cached for a branch and where to
+// resume fetching
+type prCacheMetadata struct {
+	MaxLimit  int       `json:"max_limit"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Cursor    string    `json:"cursor"` // GraphQL cursor for pagination
+}
+
+// prFetchOptions controls how fetchPRs uses the PR cache
+type prFetchOptions struct {
+	// update updates cached PRs with those updated since they were
+	// fetched, even when they are fresh. Long-running commands use it to
+	// see changes without fetching all PRs again.
+	update bool
+
+	// background, when set, runs refreshes of stale cached PRs, which are
+	// then used right away instead of waiting for the refresh
+	background *staleRefresh
+}
+
+// maxUpdatedPRs is the number of updated PRs above which fetching all PRs
+// again is cheaper than updating cached ones
+const maxUpdatedPRs = 500
+
+// updateOverlap is subtracted from the time of the last fetch when looking
+// for updated PRs, to cover PRs updated while it was running
+const updateOverlap = 5 * time.Minute
+
+// staleRefresh tracks refreshes of stale cached PRs running in the
+// background
+type staleRefresh struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	updated bool
+}
+
+// refresh runs f in the background. f reports whether it updated the cache.
+func (r *staleRefresh) refresh(f func() bool) {
+	r.wg.Go(func() {
+		if f() {
+			r.mu.Lock()
+			r.updated = true
+			r.mu.Unlock()
+		}
+	})
+}
+
+// wait waits for the background refreshes, and reports whether any of them
+// updated the cache
+func (r *staleRefresh) wait() bool {
+	r.wg.Wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.updated
+}
+
+// prCacheKeys returns the metadata and data cache keys for a base branch
+func prCacheKeys(baseBranch string) (metadataKey, dataKey string) {
+	branch := baseBranch
+	if branch == "" {
+		branch = "any"
+	}
+	return fmt.Sprintf("prs-%s-metadata", branch), fmt.Sprintf("prs-%s-data", branch)
+}
+
+// fetchBranches returns the open PRs targeting each branch, fetched
+// concurrently, each branch with its own cache and cursor
+func fetchBranches(ctx context.Context, out *output.Writer, prCache *cache.Cache, flags watchFlags, opts prFetchOptions, branches []string) ([][]pr.PullRequest, error) {
+	if len(branches) == 1 {
+		prs, err := fetchPRs(ctx, out, prCache, flags, opts, branches[0], progress.New(out))
+		return [][]pr.PullRequest{prs}, err
+	}
+
+	prs := make([][]pr.PullRequest, len(branches))
+	errs := make([]error, len(branches))
+	var wg sync.WaitGroup
+	for i, branch := range branches {
+		wg.Go(func() {
+			// Progress bars of concurrent fetches would overwrite each
+			// other, log their progress instead
+			var err error
+			prs[i], err = fetchPRs(ctx, out, prCache, flags, opts, branch, progress.NewLog(out))
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", branch, err)
+			}
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return prs, nil
+}
+
+// cachedPRs are the PRs cached for a base branch
+type cachedPRs struct {
+	metadata prCacheMetadata
+	prs      []pr.PullRequest
+	age      time.Duration
+	stale    bool
+}
+
+// loadCachedPRs returns the PRs cached for baseBranch, even if stale, or
+// nil if there are none
+func loadCachedPRs(prCache *cache.Cache, baseBranch string) *cachedPRs {
+	metadataKey, prsKey := prCacheKeys(baseBranch)
+	metadata, _, metaStale, err := cache.Lookup[prCacheMetadata](prCache, metadataKey)
+	if err != nil {
+		return nil
+	}
+	prs, age, stale, err := cache.Lookup[[]pr.PullRequest](prCache, prsKey)
+	if err != nil || len(prs) == 0 {
+		return nil
+	}
+	return &cachedPRs{metadata: metadata, prs: prs, age: age, stale: stale || metaStale}
+}
+
+// fetchPRs returns open PRs targeting baseBranch (empty for any branch),
+// using the incremental cache and fetching only what's missing.
+//
+// Stale cached PRs, or all cached PRs with opts.update, are updated with the
+// PRs updated since they were fetched. Stale PRs are used right away and
+// updated in the background when opts has a background refresh, and are
+// otherwise a fallback in case updating them fails.
+func fetchPRs(ctx context.Context, out *output.Writer, prCache *cache.Cache, flags watchFlags, opts prFetchOptions, baseBranch string, p progress.Progress) ([]pr.PullRequest, error) {
+	// Fetch PRs using incremental cache with smart merging
+	if baseBranch != "" {
+		out.Info("Fetching nixpkgs PRs targeting %s (limit: %d)...", baseBranch, flags.limit)
+	} else {
+		out.Info("Fetching nixpkgs PRs (limit: %d)...", flags.limit)
+	}
+
+	metadataKey, prsKey := prCacheKeys(baseBranch)
+	out.Debug("PR cache keys: %s, %s", metadataKey, prsKey)
+
+	// No cache or refresh requested - fetch fresh data using cursor-based API
+	var cached *cachedPRs
+	if !flags.refreshPRs {
+		cached = loadCachedPRs(prCache, baseBranch)
+	}
+	if cached == nil {
+		return fetchAndCachePRs(ctx, out, prCache, flags.limit, baseBranch, p)
+	}
+
+	switch {
+	case cached.stale && opts.background != nil:
+		// Serve stale PRs at once, the next fetch uses the updated ones
+		out.Info("Using %d stale PRs from cache (age: %v), updating them in the background",
+			len(cached.prs), cached.age.Round(time.Minute))
+		opts.background.refresh(func() bool {
+			// The progress of a background update would garble the output
+			// shown meanwhile
+			if _, err := updateCachedPRs(ctx, out, prCache, cached, flags.limit, baseBranch, progress.Nop()); err != nil {
+				out.Warning("Failed to update stale PRs: %v", err)
+				return false
+			}
+			return true
+		})
+		return truncatePRs(cached.prs, flags.limit), nil
+	case cached.stale || opts.update:
+		updated, err := updateCachedPRs(ctx, out, prCache, cached, flags.limit, baseBranch, p)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			out.Warning("Failed to update cached PRs: %v", err)
+			out.Warning("Using %d cached PRs (age: %v)", len(cached.prs), cached.age.Round(time.Minute))
+			return truncatePRs(cached.prs, flags.limit), nil
+		}
+		cached = updated
+	}
+
+	if cached.metadata.MaxLimit >= flags.limit {
+		// Cache has enough PRs, use them
+		prs := truncatePRs(cached.prs, flags.limit)
+		out.Info("Loaded %d PRs from cache (cached: %d, age: %v)",
+			len(prs), len(cached.prs), time.Since(cached.metadata.FetchedAt).Round(time.Minute))
+		return prs, nil
+	}
+
+	// Cache has some PRs but not enough - fetch additional PRs using cursor
+	deltaNeeded := flags.limit - cached.metadata.MaxLimit
+	out.Info("Cache has %d PRs, fetching %d more using cursor...", cached.metadata.MaxLimit, deltaNeeded)
+
+	fetcher := newPRFetcher(ctx, out, p)
+	newPRs, newCursor, err := fetcher.FetchNixpkgsPRsWithCursor(deltaNeeded, cached.metadata.Cursor, baseBranch)
+
+	// Merge cached PRs with any new PRs we got (even if there was an error)
+	prs := append(cached.prs, newPRs...)
+
+	// Update cache with combined results if we got new data
+	if len(newPRs) > 0 {
+		cachePRs(out, prCache, baseBranch, prs, newCursor)
+	}
+
+	if err != nil {
+		out.Warning("Failed to fetch additional PRs: %v", err)
+		if len(newPRs) > 0 {
+			out.Info("Cached partial results: %d previous + %d new = %d total PRs", len(cached.prs), len(newPRs), len(prs))
+		} else {
+			out.Info("Using cached %d PRs instead", len(cached.prs))
+		}
+	} else {
+		out.Info("Fetched %d additional PRs, total: %d", len(newPRs), len(prs))
+	}
+	return prs, nil
+}
+
+// updateCachedPRs updates cached PRs with the PRs updated since they were
+// fetched, removing those closed or merged since, and caches the result.
+// When too many PRs were updated, the limit most recent PRs are fetched
+// again instead.
+func updateCachedPRs(ctx context.Context, out *output.Writer, prCache *cache.Cache, cached *cachedPRs, limit int, baseBranch string, p progress.Progress) (*cachedPRs, error) {
+	since := cached.metadata.FetchedAt.Add(-updateOverlap)
+	out.Verbose("Fetching PRs updated since %s...", since.Format(time.RFC3339))
+
+	fetcher := newPRFetcher(ctx, out, p)
+	updated, err := fetcher.FetchNixpkgsPRsUpdatedSince(since, baseBranch, maxUpdatedPRs)
+	if errors.Is(err, pr.ErrTooManyUpdates) {
+		out.Info("More than %d PRs updated since the last fetch, fetching all PRs again", maxUpdatedPRs)
+		prs, err := fetchAndCachePRs(ctx, out, prCache, max(limit, cached.metadata.MaxLimit), baseBranch, p)
+		if err != nil {
+			return nil, err
+		}
+		return loadCachedOr(prCache, baseBranch, prs), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prs := mergeUpdatedPRs(cached.prs, updated)
+	cachePRs(out, prCache, baseBranch, prs, cached.metadata.Cursor)
+	out.Info("Updated cached PRs with %d PRs changed since the last fetch", len(updated))
+
+	return loadCachedOr(prCache, baseBranch, prs), nil
+}
+
+// loadCachedOr returns the PRs just cached for baseBranch, or prs as if
+// freshly cached if the cache couldn't be written
+func loadCachedOr(prCache *cache.Cache, baseBranch string, prs []pr.PullRequest) *cachedPRs {
+	if cached := loadCachedPRs(prCache, baseBranch); cached != nil {
+		return cached
+	}
+	return &cachedPRs{
+		metadata: prCacheMetadata{MaxLimit: len(prs), FetchedAt: time.Now()},
+		prs:      prs,
+	}
+}
+
+// mergeUpdatedPRs returns cached PRs with updated PRs replacing them, open
+// PRs not cached yet added, and PRs no longer open removed, newest first
+func mergeUpdatedPRs(cached, updated []pr.PullRequest) []pr.PullRequest {
+	byNumber := make(map[int]pr.PullRequest, len(updated))
+	for _, p := range updated {
+		byNumber[p.Number] = p
+	}
+
+	merged := make([]pr.PullRequest, 0, len(cached)+len(updated))
+	for _, p := range cached {
+		if u, ok := byNumber[p.Number]; ok {
+			p = u
+			delete(byNumber, p.Number)
+		}
+		if p.State == "" || p.State == "OPEN" {
+			merged = append(merged, p)
+		}
+	}
+	for _, p := range updated {
+		if _, ok := byNumber[p.Number]; ok && p.State == "OPEN" {
+			merged = append(merged, p)
+		}
+	}
+
+	sortByCreatedDesc(merged)
+	return merged
+}
+
+// newPRFetcher returns a PR fetcher cancelled by ctx, reporting to out and p
+func newPRFetcher(ctx context.Context, out *output.Writer, p progress.Progress) *pr.Fetcher {
+	fetcher := pr.NewFetcher()
+	fetcher.SetContext(ctx)
+	fetcher.SetOutput(out)
+	fetcher.SetProgress(p)
+	return fetcher
+}
+
+// fetchAndCachePRs fetches the limit most recent open PRs targeting
+// baseBranch and caches them. Partial results are cached and returned if
+// the fetch fails midway; it only fails if nothing was fetched.
+func fetchAndCachePRs(ctx context.Context, out *output.Writer, prCache *cache.Cache, limit int, baseBranch string, p progress.Progress) ([]pr.PullRequest, error) {
+	fetcher := newPRFetcher(ctx, out, p)
+	prs, cursor, err := fetcher.FetchNixpkgsPRsWithCursor(limit, "", baseBranch)
+	if len(prs) == 0 {
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch PRs: %w", err)
+		}
+		return prs, nil
+	}
+
+	// Cache partial results even if there was an error
+	cachePRs(out, prCache, baseBranch, prs, cursor)
+	if err != nil {
+		out.Warning("Fetch incomplete due to error: %v", err)
+		out.Info("Using %d PRs fetched before error", len(prs))
+	} else {
+		out.Info("Fetched %d PRs", len(prs))
+	}
+	return prs, nil
+}
+
+// cachePRs stores the PRs fetched for baseBranch, and the cursor to resume
+// fetching older ones
+func cachePRs(out *output.Writer, prCache *cache.Cache, baseBranch string, prs []pr.PullRequest, cursor string) {
+	metadataKey, prsKey := prCacheKeys(baseBranch)
+	metadata := prCacheMetadata{
+		MaxLimit:  len(prs),
+		FetchedAt: time.Now(),
+		Cursor:    cursor,
+	}
+	if err := prCache.SetWithTTL(prsKey, prs, prCacheTTL); err != nil {
+		out.Warning("Failed to cache PRs: %v", err)
+	}
+	if err := prCache.SetWithTTL(metadataKey, metadata, prCacheTTL); err != nil {
+		out.Warning("Failed to cache metadata: %v", err)
+	}
+}
+
+// truncatePRs returns the first limit PRs
+func truncatePRs(prs []pr.PullRequest, limit int) []pr.PullRequest {
+	if len(prs) > limit {
+		return prs[:limit]
+	}
+	return prs
+}
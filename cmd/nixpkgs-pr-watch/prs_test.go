@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/pr"
+)
+
+func TestMergeUpdatedPRs(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(number int, state string) pr.PullRequest {
+		return pr.PullRequest{Number: number, State: state, CreatedAt: day.Add(time.Duration(number) * time.Hour)}
+	}
+
+	cached := []pr.PullRequest{at(4, ""), at(3, "OPEN"), at(2, "OPEN"), at(1, "")}
+	updated := []pr.PullRequest{
+		at(5, "OPEN"),   // new
+		at(6, "MERGED"), // opened and merged since
+		at(3, "CLOSED"), // closed since
+		at(1, "OPEN"),   // changed
+	}
+	updated[3].Title = "changed"
+
+	got := mergeUpdatedPRs(cached, updated)
+
+	var numbers []int
+	for _, p := range got {
+		numbers = append(numbers, p.Number)
+	}
+	if want := []int{5, 4, 2, 1}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("mergeUpdatedPRs() = %v, want %v", numbers, want)
+	}
+	if got[3].Title != "changed" {
+		t.Errorf("mergeUpdatedPRs() kept outdated PR %+v", got[3])
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// report is the result of a watch run, rendered by every output format but
+// terminal. Its items are the matches, so templates see a pr.MatchResult
+// (e.g. '{{.PR.Number}} {{.PR.Title}}').
+type report struct {
+	Metadata     reportMetadata     `json:"metadata"`
+	Dependencies reportDependencies `json:"dependencies"`
+	Matches      []pr.MatchResult   `json:"matches"`
+	PRHosts      map[int][]string   `json:"pr_hosts,omitempty"` // Hosts each PR is relevant to
+	Marks        map[int]string     `json:"marks,omitempty"`    // NEW, UPDATED or STATUS-CHANGED since seen
+	Packages     []packageGroup     `json:"packages,omitempty"` // PRs by dependency, with --group-by package
+	// Base branch of each PR, then the branches and channel its changes
+	// are expected to go through
+	PRChannels map[int][]string `json:"pr_channels,omitempty"`
+}
+
+type reportMetadata struct {
+	Timestamp         string   `json:"timestamp"`
+	HostsAnalyzed     []string `json:"hosts_analyzed"`
+	TotalDependencies int      `json:"total_dependencies"`
+	TotalModules      int      `json:"total_modules"`
+	TotalServices     int      `json:"total_services"`
+	TotalPRsMatched   int      `json:"total_prs_matched"`
+}
+
+type reportDependencies struct {
+	Packages []deps.Package    `json:"packages"`
+	Modules  []deps.ModulePath `json:"modules"`
+	Services []string          `json:"services"`
+}
+
+func init() {
+	output.Register(output.Spec[report, pr.MatchResult]{
+		Items: func(r report) []pr.MatchResult { return r.Matches },
+		Columns: []output.Column[pr.MatchResult]{
+			{Name: "number", Value: func(r pr.MatchResult) string { return strconv.Itoa(r.PR.Number) }},
+			{Name: "title", Value: func(r pr.MatchResult) string { return r.PR.Title }},
+			{Name: "author", Value: func(r pr.MatchResult) string { return r.PR.Author }},
+			{Name: "base", Value: func(r pr.MatchResult) string { return r.PR.BaseRef }},
+			{Name: "confidence", Value: func(r pr.MatchResult) string { return r.HighestConfidence() }},
+			{Name: "score", Value: func(r pr.MatchResult) string { return strconv.Itoa(r.Score) }},
+			{Name: "matches", Value: func(r pr.MatchResult) string { return matchedDependencies(r.Matches) }},
+			{Name: "url", Value: func(r pr.MatchResult) string { return r.PR.URL }},
+		},
+		Custom: map[string]func(io.Writer, report) error{
+			"urls":     func(w io.Writer, r report) error { return outputURLs(w, r.Matches) },
+			formatAtom: func(w io.Writer, r report) error { return writeAtom(w, newFeed(r)) },
+			formatRSS:  func(w io.Writer, r report) error { return writeRSS(w, newFeed(r)) },
+			formatHTML: writeHTML,
+		},
+	})
+}
+
+// newReport builds the report of a watch run
+func newReport(results []pr.MatchResult, d *deps.Dependencies, hosts []string) report {
+	return report{
+		Metadata: reportMetadata{
+			Timestamp:         time.Now().Format(time.RFC3339),
+			HostsAnalyzed:     hosts,
+			TotalDependencies: len(d.Packages),
+			TotalModules:      len(d.Modules),
+			TotalServices:     len(d.Services),
+			TotalPRsMatched:   len(results),
+		},
+		Dependencies: reportDependencies{
+			Packages: d.Packages,
+			Modules:  d.Modules,
+			Services: d.Services,
+		},
+		Matches: results,
+	}
+}
+
+// matchedDependencies lists the distinct dependencies of matches
+func matchedDependencies(matches []pr.Match) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if !seen[m.Dependency] {
+			seen[m.Dependency] = true
+			names = append(names, m.Dependency)
+		}
+	}
+	return strings.Join(names, ", ")
+}
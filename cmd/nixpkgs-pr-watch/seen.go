@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/paths"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// Triage marks of matches, compared to the seen state acknowledged with
+// mark-seen
+const (
+	markNew           = "NEW"
+	markUpdated       = "UPDATED"
+	markStatusChanged = "STATUS-CHANGED"
+)
+
+// seenStatePath returns the path of the seen state of a profile, using the
+// watch state format
+func seenStatePath(profile string) (string, error) {
+	return profileStatePath("seen", profile)
+}
+
+// profileStatePath returns the path of the name.json state file of a
+// profile, name-<profile>.json, so profiles don't share their state
+func profileStatePath(name, profile string) (string, error) {
+	dir, err := paths.StateDir("nixpkgs-pr-watch")
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		return filepath.Join(dir, name+".json"), nil
+	}
+	return filepath.Join(dir, name+"-"+profile+".json"), nil
+}
+
+// triageMarks returns the mark of each result not acknowledged as is: new
+// PRs, PRs whose mergeable or CI status changed, and PRs updated since
+func triageMarks(state *watchState, results []pr.MatchResult) map[int]string {
+	marks := make(map[int]string)
+	for _, r := range results {
+		prev, ok := state.Matches[r.PR.Number]
+		switch {
+		case !ok:
+			marks[r.PR.Number] = markNew
+		case statusChanged(prev.Mergeable, r.PR.Mergeable) || statusChanged(prev.StatusState, r.PR.StatusState):
+			marks[r.PR.Number] = markStatusChanged
+		case r.PR.UpdatedAt.After(prev.UpdatedAt):
+			marks[r.PR.Number] = markUpdated
+		}
+	}
+	return marks
+}
+
+// statusChanged reports whether a known status differs from the seen one
+func statusChanged(seen, current string) bool {
+	return seen != "" && knownStatus(current) && current != seen
+}
+
+// filterByMarks returns the results marked new, if onlyNew, or updated or
+// with a changed status, if onlyChanged
+func filterByMarks(results []pr.MatchResult, marks map[int]string, onlyNew, onlyChanged bool) []pr.MatchResult {
+	var filtered []pr.MatchResult
+	for _, r := range results {
+		mark := marks[r.PR.Number]
+		if (onlyNew && mark == markNew) || (onlyChanged && (mark == markUpdated || mark == markStatusChanged)) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// markSeen records the matching results numbered in numbers, or all of
+// them if empty, in the state as acknowledged at now. Other matching PRs
+// keep the state they were acknowledged in, and PRs that no longer match
+// are forgotten after a while, as in the watch state.
+func markSeen(state *watchState, results []pr.MatchResult, numbers []int, now time.Time) {
+	matching := make(map[int]bool, len(results))
+	for _, r := range results {
+		matching[r.PR.Number] = true
+		if len(numbers) > 0 && !slices.Contains(numbers, r.PR.Number) {
+			if seen, ok := state.Matches[r.PR.Number]; ok {
+				seen.LastSeen = now
+				state.Matches[r.PR.Number] = seen
+			}
+			continue
+		}
+
+		seen := newSeenMatch(r, now)
+		if prev, ok := state.Matches[r.PR.Number]; ok {
+			seen.FirstSeen = prev.FirstSeen
+			// Keep the last known status while GitHub computes it
+			if !knownStatus(seen.Mergeable) {
+				seen.Mergeable = prev.Mergeable
+			}
+			if !knownStatus(seen.StatusState) {
+				seen.StatusState = prev.StatusState
+			}
+		}
+		state.Matches[r.PR.Number] = seen
+	}
+	for number, seen := range state.Matches {
+		if !matching[number] && now.Sub(seen.LastSeen) > forgetAfter {
+			delete(state.Matches, number)
+		}
+	}
+	state.UpdatedAt = now
+}
+
+// markSeenCmd returns the mark-seen command
+func markSeenCmd(out *output.Writer) *cobra.Command {
+	var flags watchFlags
+
+	cmd := &cobra.Command{
+		Use:   "mark-seen [pr...]",
+		Short: "Acknowledge matching PRs, so they are no longer marked as new or changed",
+		Long: `Acknowledge the current matching PRs, or only the given ones, so they are
+no longer marked NEW, UPDATED or STATUS-CHANGED, nor shown by --new and
+--changed, until they change again.
+
+The seen state is kept per profile, in $XDG_STATE_HOME/nixpkgs-pr-watch.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var numbers []int
+			for _, arg := range args {
+				n, err := strconv.Atoi(arg)
+				if err != nil {
+					return fmt.Errorf("invalid PR number %q", arg)
+				}
+				numbers = append(numbers, n)
+			}
+			flags.profile, _ = cmd.Flags().GetString("profile")
+			return runMarkSeen(cmd.Context(), out, flags.resolve(), numbers)
+		},
+	}
+
+	addMatchFlags(cmd, cmd.Flags(), &flags)
+
+	return cmd
+}
+
+// runMarkSeen records the current matches in the seen state, only those
+// numbered if any
+func runMarkSeen(ctx context.Context, out *output.Writer, flags watchFlags, numbers []int) error {
+	c, err := openCache()
+	if err != nil {
+		return fmt.Errorf("failed to initialize cache: %w", err)
+	}
+
+	run, err := matchPRs(ctx, out, c, flags, prFetchOptions{})
+	if err != nil {
+		return err
+	}
+
+	marked := len(run.results)
+	if len(numbers) > 0 {
+		marked = 0
+		for _, n := range numbers {
+			if slices.ContainsFunc(run.results, func(r pr.MatchResult) bool { return r.PR.Number == n }) {
+				marked++
+			} else {
+				out.Warning("PR #%d doesn't match, not marking it as seen", n)
+			}
+		}
+	}
+
+	path, err := seenStatePath(flags.profile)
+	if err != nil {
+		return err
+	}
+	state, _, err := loadWatchState(path)
+	if err != nil {
+		return err
+	}
+	markSeen(state, run.results, numbers, time.Now())
+	if err := state.save(path); err != nil {
+		return err
+	}
+
+	out.Success("Marked %d PRs as seen", marked)
+	return nil
+}
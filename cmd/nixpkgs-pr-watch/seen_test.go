@@ -0,0 +1,150 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/pr"
+)
+
+func seenResult(number int, mergeable, status string, updated time.Time) pr.MatchResult {
+	r := testMatch(number, mergeable, status)
+	r.PR.UpdatedAt = updated
+	return r
+}
+
+func TestTriageMarks(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	state := &watchState{Matches: make(map[int]seenMatch)}
+	markSeen(state, []pr.MatchResult{
+		seenResult(1, "MERGEABLE", "SUCCESS", day1),
+		seenResult(2, "MERGEABLE", "SUCCESS", day1),
+		seenResult(3, "MERGEABLE", "PENDING", day1),
+		seenResult(4, "MERGEABLE", "SUCCESS", day1),
+	}, nil, day1)
+
+	tests := []struct {
+		name   string
+		result pr.MatchResult
+		want   string
+	}{
+		{name: "unchanged", result: seenResult(1, "MERGEABLE", "SUCCESS", day1), want: ""},
+		{name: "updated", result: seenResult(2, "MERGEABLE", "SUCCESS", day2), want: markUpdated},
+		{name: "CI status changed", result: seenResult(3, "MERGEABLE", "FAILURE", day2), want: markStatusChanged},
+		{name: "status being computed", result: seenResult(4, "UNKNOWN", "", day1), want: ""},
+		{name: "new", result: seenResult(5, "MERGEABLE", "SUCCESS", day1), want: markNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marks := triageMarks(state, []pr.MatchResult{tt.result})
+			if got := marks[tt.result.PR.Number]; got != tt.want {
+				t.Errorf("mark = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByMarks(t *testing.T) {
+	results := []pr.MatchResult{testMatch(1, "", ""), testMatch(2, "", ""), testMatch(3, "", ""), testMatch(4, "", "")}
+	marks := map[int]string{1: markNew, 2: markUpdated, 3: markStatusChanged}
+
+	tests := []struct {
+		name                 string
+		onlyNew, onlyChanged bool
+		want                 []int
+	}{
+		{name: "new", onlyNew: true, want: []int{1}},
+		{name: "changed", onlyChanged: true, want: []int{2, 3}},
+		{name: "new or changed", onlyNew: true, onlyChanged: true, want: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, r := range filterByMarks(results, marks, tt.onlyNew, tt.onlyChanged) {
+				got = append(got, r.PR.Number)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkSeen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.json")
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	state, _, err := loadWatchState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markSeen(state, []pr.MatchResult{seenResult(1, "MERGEABLE", "SUCCESS", day1), seenResult(2, "MERGEABLE", "SUCCESS", day1)}, nil, day1)
+	if err := state.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last known status is kept while GitHub computes it, and PRs no
+	// longer matching are forgotten after a while
+	state, _, err = loadWatchState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := day1.Add(forgetAfter + time.Hour)
+	markSeen(state, []pr.MatchResult{seenResult(1, "UNKNOWN", "", day1.Add(time.Hour))}, nil, later)
+
+	seen, ok := state.Matches[1]
+	if !ok || seen.Mergeable != "MERGEABLE" || seen.StatusState != "SUCCESS" || !seen.UpdatedAt.Equal(day1.Add(time.Hour)) || !seen.FirstSeen.Equal(day1) {
+		t.Errorf("seen = %+v", seen)
+	}
+	if _, ok := state.Matches[2]; ok {
+		t.Error("PR #2 should be forgotten")
+	}
+}
+
+func TestMarkSeen_Numbers(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	state := &watchState{Matches: make(map[int]seenMatch)}
+	markSeen(state, []pr.MatchResult{seenResult(1, "MERGEABLE", "SUCCESS", day1), seenResult(2, "MERGEABLE", "SUCCESS", day1)}, nil, day1)
+
+	// Marking only #1 long after keeps #2, still matching, as acknowledged
+	later := day1.Add(forgetAfter + time.Hour)
+	updated := day1.Add(time.Hour)
+	markSeen(state, []pr.MatchResult{seenResult(1, "MERGEABLE", "SUCCESS", updated), seenResult(2, "MERGEABLE", "SUCCESS", updated)}, []int{1}, later)
+
+	if seen := state.Matches[1]; !seen.UpdatedAt.Equal(updated) {
+		t.Errorf("#1 = %+v, want marked as seen", seen)
+	}
+	seen, ok := state.Matches[2]
+	if !ok || !seen.UpdatedAt.Equal(day1) || !seen.LastSeen.Equal(later) {
+		t.Errorf("#2 = %+v, %v, want kept as acknowledged and last seen now", seen, ok)
+	}
+
+	// Still matching, #2 isn't forgotten
+	markSeen(state, []pr.MatchResult{seenResult(2, "MERGEABLE", "SUCCESS", updated)}, []int{3}, later.Add(forgetAfter+time.Hour))
+	if _, ok := state.Matches[2]; !ok {
+		t.Error("PR #2 should be kept while it matches")
+	}
+}
+
+func TestOutputTerminal_Marks(t *testing.T) {
+	got := renderTerminal(t, 100, watchFlags{layout: layoutFull})
+	if !strings.Contains(got, "STATUS-CHANGED [#479757]") || !strings.Contains(got, "Found: 2 relevant PRs (0 new, 1 changed)") {
+		t.Errorf("marks missing:\n%s", got)
+	}
+
+	got = renderTerminal(t, 100, watchFlags{layout: layoutTable})
+	if !strings.Contains(got, "MARK") || !strings.Contains(got, "STATUS-CHANGED") {
+		t.Errorf("mark column missing:\n%s", got)
+	}
+}
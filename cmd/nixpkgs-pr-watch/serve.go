@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/cache"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// sseHeartbeat is how often a comment is sent on idle event streams, so
+// proxies don't close them
+const sseHeartbeat = 30 * time.Second
+
+// server serves the latest matches over HTTP, refreshing them periodically
+type server struct {
+	out   *output.Writer
+	cache *cache.Cache
+	flags watchFlags
+
+	mu      sync.RWMutex
+	run     *matchRun
+	updated time.Time
+	lastErr error
+	state   *watchState // Matches seen so far, to stream events
+
+	subMu       sync.Mutex
+	subscribers map[chan watchEvent]struct{}
+}
+
+func newServer(out *output.Writer, c *cache.Cache, flags watchFlags) *server {
+	return &server{
+		out:         out,
+		cache:       c,
+		flags:       flags,
+		subscribers: make(map[chan watchEvent]struct{}),
+	}
+}
+
+// refresh matches PRs again, with cached PRs updated with those changed
+// since they were fetched, then streams new matches and status changes to
+// subscribers
+func (s *server) refresh(ctx context.Context) error {
+	run, err := matchPRs(ctx, s.out, s.cache, s.flags, prFetchOptions{update: true})
+	if err != nil {
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+		return err
+	}
+	sortResults(run.results, s.flags.sortBy)
+	s.update(run, time.Now())
+	return nil
+}
+
+// update replaces the served matches with run. Events are only streamed
+// once matches have been served, not for the initial matches.
+func (s *server) update(run *matchRun, now time.Time) {
+	s.mu.Lock()
+	initial := s.state == nil
+	if initial {
+		s.state = &watchState{Version: watchStateVersion, Matches: make(map[int]seenMatch)}
+	}
+	events, missing := diffMatches(s.state, run.results, now)
+	// Merged and closed PRs aren't looked up, to save API quota
+	for _, seen := range missing {
+		delete(s.state.Matches, seen.Number)
+	}
+	s.run = run
+	s.updated = now
+	s.lastErr = nil
+	s.mu.Unlock()
+
+	if initial {
+		return
+	}
+	for _, e := range events {
+		s.broadcast(e)
+	}
+}
+
+// current returns the served matches, nil until the first refresh succeeds
+func (s *server) current() (*matchRun, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.run, s.updated, s.lastErr
+}
+
+// subscribe returns a channel receiving events, until unsubscribed
+func (s *server) subscribe() chan watchEvent {
+	ch := make(chan watchEvent, 16)
+	s.subMu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.subMu.Unlock()
+	return ch
+}
+
+func (s *server) unsubscribe(ch chan watchEvent) {
+	s.subMu.Lock()
+	delete(s.subscribers, ch)
+	s.subMu.Unlock()
+}
+
+// broadcast sends an event to subscribers, dropping it for those lagging
+// behind
+func (s *server) broadcast(e watchEvent) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// handler returns the HTTP handler of the server
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/matches", s.handleMatches)
+	mux.HandleFunc("GET /api/deps/{host}", s.handleDeps)
+	mux.HandleFunc("GET /api/prs/{number}/explain", s.handleExplain)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	return mux
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// loaded returns the served matches, or writes an error if there are none
+// yet
+func (s *server) loaded(w http.ResponseWriter) *matchRun {
+	run, _, err := s.current()
+	if run == nil {
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, "matches not loaded yet: %v", err)
+		} else {
+			writeError(w, http.StatusServiceUnavailable, "matches not loaded yet")
+		}
+	}
+	return run
+}
+
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	run, _, err := s.current()
+	if run == nil {
+		w.Header().Set("Refresh", "10")
+		msg := "Loading matches, this page reloads automatically…"
+		if err != nil {
+			msg = fmt.Sprintf("Loading matches failed, retrying: %v", err)
+		}
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+
+	d := newDashboard(run.report())
+	d.Live = true
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTmpl.Execute(w, d); err != nil {
+		s.out.Warning("Failed to render dashboard: %v", err)
+	}
+}
+
+// serverStatus is the response of /api/status
+type serverStatus struct {
+	Loaded      bool      `json:"loaded"`
+	UpdatedAt   time.Time `json:"updated_at,omitzero"`
+	Error       string    `json:"error,omitempty"`
+	Matches     int       `json:"matches"`
+	Subscribers int       `json:"subscribers"`
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	run, updated, err := s.current()
+	status := serverStatus{Loaded: run != nil, UpdatedAt: updated}
+	if run != nil {
+		status.Matches = len(run.results)
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	s.subMu.Lock()
+	status.Subscribers = len(s.subscribers)
+	s.subMu.Unlock()
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *server) handleMatches(w http.ResponseWriter, r *http.Request) {
+	if run := s.loaded(w); run != nil {
+		writeJSON(w, http.StatusOK, run.report())
+	}
+}
+
+func (s *server) handleDeps(w http.ResponseWriter, r *http.Request) {
+	run := s.loaded(w)
+	if run == nil {
+		return
+	}
+	host := r.PathValue("host")
+	d, ok := run.hostDeps[host]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown host %q (analyzed: %s)", host, formatHosts(run.hosts))
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid PR number %q", r.PathValue("number"))
+		return
+	}
+	run := s.loaded(w)
+	if run == nil {
+		return
+	}
+	e, ok := explainPR(run, number, s.flags.minConfidence)
+	if !ok {
+		writeError(w, http.StatusNotFound, "PR #%d doesn't match any dependency, or isn't among the fetched open PRs", number)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// handleEvents streams new matches and status changes as server-sent
+// events, named after the event type
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// explanation explains why a PR matches
+type explanation struct {
+	Number     int             `json:"number"`
+	Title      string          `json:"title"`
+	URL        string          `json:"url"`
+	Confidence string          `json:"confidence"`
+	Score      int             `json:"score"`
+	Included   bool            `json:"included"` // Whether it passes --min-confidence
+	Status     string          `json:"status"`
+	Hosts      []string        `json:"hosts"`
+	Reasons    []explainReason `json:"reasons"`
+}
+
+// explainReason is a match of a PR, described in words
+type explainReason struct {
+	pr.Match
+	Reason string `json:"reason"`
+}
+
+// explainPR explains why PR number matches, if it does
+func explainPR(run *matchRun, number int, minConfidence string) (explanation, bool) {
+	for _, r := range run.all {
+		if r.PR.Number != number {
+			continue
+		}
+		e := explanation{
+			Number:     r.PR.Number,
+			Title:      r.PR.Title,
+			URL:        r.PR.URL,
+			Confidence: r.HighestConfidence(),
+			Score:      r.Score,
+			Included:   shouldIncludeByConfidence(r, minConfidence),
+			Status:     prStatus(r.PR),
+			Hosts:      run.prHosts[number],
+		}
+		for _, m := range r.Matches {
+			e.Reasons = append(e.Reasons, explainReason{Match: m, Reason: matchReason(m)})
+		}
+		return e, true
+	}
+	return explanation{}, false
+}
+
+// matchReason describes a match in words
+func matchReason(m pr.Match) string {
+	switch m.Type {
+	case "package":
+		if m.FilePath != "" {
+			return fmt.Sprintf("changes %s, of installed package %s", m.FilePath, m.Dependency)
+		}
+		return fmt.Sprintf("changes installed package %s", m.Dependency)
+	case "module":
+		return fmt.Sprintf("changes NixOS module %s, used by %s", m.FilePath, m.Dependency)
+	case "service":
+		return fmt.Sprintf("changes %s, of enabled service %s", m.FilePath, m.Dependency)
+	case "title":
+		return fmt.Sprintf("title mentions %s", m.Dependency)
+	default:
+		return fmt.Sprintf("%s match on %s", m.Type, m.Dependency)
+	}
+}
+
+// loop refreshes matches every interval until ctx is done, retrying
+// failed refreshes sooner
+func (s *server) loop(ctx context.Context, interval time.Duration) {
+	failures := 0
+	for {
+		wait := interval
+		if err := s.refresh(ctx); err != nil {
+			failures++
+			wait = min(watchRetryDelay<<min(failures-1, 10), interval)
+			s.out.Warning("Refresh failed (%d in a row), retrying in %s: %v", failures, wait, err)
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func serveCmd(out *output.Writer) *cobra.Command {
+	var (
+		flags    watchFlags
+		addr     string
+		interval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve matching PRs over HTTP",
+		Long: `Refresh matching PRs periodically and serve them over HTTP, so a team can
+share one instance (and its GitHub API quota) instead of each running
+nixpkgs-pr-watch.
+
+Endpoints:
+  /                         HTML dashboard, announcing updates live
+  /api/matches              matches, as with -o json
+  /api/deps/<host>          dependencies of an analyzed host
+  /api/prs/<number>/explain why a PR matches
+  /api/events               server-sent events: new matches and status changes
+  /api/status               last refresh and errors`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("invalid interval %s", interval)
+			}
+
+			c, err := openCache()
+			if err != nil {
+				return fmt.Errorf("failed to initialize cache: %w", err)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			s := newServer(out, c, flags.resolve())
+			httpServer := &http.Server{
+				Addr:              addr,
+				Handler:           s.handler(),
+				ReadHeaderTimeout: 10 * time.Second,
+				BaseContext:       func(net.Listener) context.Context { return ctx },
+			}
+
+			go s.loop(ctx, interval)
+
+			errs := make(chan error, 1)
+			go func() {
+				out.Info("Serving on %s, refreshing every %s", addr, interval)
+				errs <- httpServer.ListenAndServe()
+			}()
+
+			select {
+			case err := <-errs:
+				return fmt.Errorf("failed to serve: %w", err)
+			case <-ctx.Done():
+			}
+
+			out.Info("Stopping")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("failed to shut down: %w", err)
+			}
+			return nil
+		},
+	}
+
+	addMatchFlags(cmd, cmd.Flags(), &flags)
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "Address to listen on")
+	cmd.Flags().DurationVar(&interval, "interval", defaultWatchInterval, "Time between refreshes")
+	cmd.Flags().StringVar(&flags.sortBy, "sort", "created", "Sort PRs by: created, updated")
+
+	return cmd
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func testServer(t *testing.T) (*server, *httptest.Server) {
+	t.Helper()
+	s := newServer(output.NewWriter(&bytes.Buffer{}, &bytes.Buffer{}, false), nil, watchFlags{minConfidence: "medium"})
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func testRun(results ...pr.MatchResult) *matchRun {
+	d := &deps.Dependencies{Packages: []deps.Package{{Name: "foo"}}}
+	run := &matchRun{
+		all:      results,
+		deps:     d,
+		hostDeps: map[string]*deps.Dependencies{"kyushu": d},
+		hosts:    []string{"kyushu"},
+		prHosts:  make(map[int][]string),
+	}
+	for _, r := range results {
+		run.prHosts[r.PR.Number] = []string{"kyushu"}
+		if shouldIncludeByConfidence(r, "medium") {
+			run.results = append(run.results, r)
+		}
+	}
+	return run
+}
+
+func getJSON(t *testing.T, url string, wantStatus int, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("GET %s: status %d, want %d", url, resp.StatusCode, wantStatus)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("GET %s: invalid JSON: %v", url, err)
+	}
+}
+
+func TestServer_NotLoaded(t *testing.T) {
+	_, ts := testServer(t)
+
+	var body map[string]string
+	getJSON(t, ts.URL+"/api/matches", http.StatusServiceUnavailable, &body)
+	if !strings.Contains(body["error"], "not loaded yet") {
+		t.Errorf("error = %q", body["error"])
+	}
+
+	var status serverStatus
+	getJSON(t, ts.URL+"/api/status", http.StatusOK, &status)
+	if status.Loaded {
+		t.Error("status should not be loaded")
+	}
+}
+
+func TestServer_API(t *testing.T) {
+	s, ts := testServer(t)
+	s.update(testRun(
+		testMatch(1, "MERGEABLE", "SUCCESS"),
+		pr.MatchResult{
+			PR:      pr.PullRequest{Number: 2, Title: "foo-docs: fix typo"},
+			Matches: []pr.Match{{Type: "title", Dependency: "foo", Confidence: "low"}},
+		},
+	), time.Now())
+
+	var rep report
+	getJSON(t, ts.URL+"/api/matches", http.StatusOK, &rep)
+	if len(rep.Matches) != 1 || rep.Matches[0].PR.Number != 1 || len(rep.PRHosts[1]) != 1 {
+		t.Errorf("matches = %+v", rep)
+	}
+
+	var d deps.Dependencies
+	getJSON(t, ts.URL+"/api/deps/kyushu", http.StatusOK, &d)
+	if len(d.Packages) != 1 || d.Packages[0].Name != "foo" {
+		t.Errorf("deps = %+v", d)
+	}
+	var body map[string]string
+	getJSON(t, ts.URL+"/api/deps/unknown", http.StatusNotFound, &body)
+
+	var e explanation
+	getJSON(t, ts.URL+"/api/prs/1/explain", http.StatusOK, &e)
+	if !e.Included || e.Confidence != "high" || len(e.Reasons) != 1 || e.Reasons[0].Reason != "changes installed package foo" {
+		t.Errorf("explanation = %+v", e)
+	}
+
+	// Matches below the minimum confidence are explained, but not included
+	getJSON(t, ts.URL+"/api/prs/2/explain", http.StatusOK, &e)
+	if e.Included || e.Reasons[0].Reason != "title mentions foo" {
+		t.Errorf("explanation = %+v", e)
+	}
+
+	getJSON(t, ts.URL+"/api/prs/3/explain", http.StatusNotFound, &body)
+	getJSON(t, ts.URL+"/api/prs/abc/explain", http.StatusBadRequest, &body)
+
+	resp, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var page bytes.Buffer
+	page.ReadFrom(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(page.String(), `new EventSource("api/events")`) {
+		t.Errorf("index: status %d, page without live updates:\n%s", resp.StatusCode, page.String())
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	s, ts := testServer(t)
+	s.update(testRun(testMatch(1, "MERGEABLE", "PENDING")), time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	if line, _ := r.ReadString('\n'); line != ": connected\n" {
+		t.Fatalf("first line = %q", line)
+	}
+
+	// A new match and a status change are streamed
+	s.update(testRun(testMatch(1, "MERGEABLE", "FAILURE"), testMatch(2, "MERGEABLE", "PENDING")), time.Now())
+
+	var got []string
+	for len(got) < 2 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+		if strings.HasPrefix(line, "event: ") {
+			got = append(got, strings.TrimSpace(strings.TrimPrefix(line, "event: ")))
+		}
+		if strings.HasPrefix(line, "data: ") && !strings.Contains(line, `"number":`) {
+			t.Errorf("invalid event data: %q", line)
+		}
+	}
+	if strings.Join(got, ",") != "status-changed,new" {
+		t.Errorf("events = %v, want status-changed and new", got)
+	}
+}
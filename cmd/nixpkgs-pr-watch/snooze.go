@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.sbr.pm/x/internal/atomicfile"
+	"go.sbr.pm/x/internal/paths"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// defaultSnoozeDuration is how long snoozed PRs are hidden, unless they are
+// updated in the meantime
+const defaultSnoozeDuration = 7 * 24 * time.Hour
+
+// snooze hides a PR from reports until a date
+type snooze struct {
+	Until     time.Time `json:"until"`
+	UpdatedAt time.Time `json:"updated_at"` // Last update of the PR when snoozed
+}
+
+// snoozes are the snoozed PRs, persisted in the state directory
+type snoozes struct {
+	path string
+	PRs  map[int]snooze `json:"prs"`
+}
+
+// defaultSnoozesPath returns the path of the snoozed PRs file
+func defaultSnoozesPath() (string, error) {
+	dir, err := paths.StateDir("nixpkgs-pr-watch")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "snoozed.json"), nil
+}
+
+// loadSnoozes reads the snoozed PRs at path, if any
+func loadSnoozes(path string) (*snoozes, error) {
+	s := &snoozes{path: path, PRs: make(map[int]snooze)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snoozed PRs: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse snoozed PRs %s: %w", path, err)
+	}
+	if s.PRs == nil {
+		s.PRs = make(map[int]snooze)
+	}
+	return s, nil
+}
+
+// add snoozes a PR until the given date, or until it is updated
+func (s *snoozes) add(p pr.PullRequest, until time.Time) {
+	s.PRs[p.Number] = snooze{Until: until, UpdatedAt: p.UpdatedAt}
+}
+
+// active returns whether a PR is snoozed at now. Updates to the PR since it
+// was snoozed wake it up.
+func (s *snoozes) active(p pr.PullRequest, now time.Time) bool {
+	sn, ok := s.PRs[p.Number]
+	if !ok || !now.Before(sn.Until) {
+		return false
+	}
+	return !p.UpdatedAt.After(sn.UpdatedAt)
+}
+
+// filter returns the results that aren't snoozed at now, and the number of
+// snoozed ones
+func (s *snoozes) filter(results []pr.MatchResult, now time.Time) ([]pr.MatchResult, int) {
+	var kept []pr.MatchResult
+	for _, r := range results {
+		if !s.active(r.PR, now) {
+			kept = append(kept, r)
+		}
+	}
+	return kept, len(results) - len(kept)
+}
+
+// save writes the snoozed PRs, forgetting expired ones
+func (s *snoozes) save(now time.Time) error {
+	for number, sn := range s.PRs {
+		if !now.Before(sn.Until) {
+			delete(s.PRs, number)
+		}
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snoozed PRs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write snoozed PRs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"go.sbr.pm/x/internal/config"
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// Terminal report layouts
+const (
+	layoutFull    = "full"
+	layoutCompact = "compact"
+	layoutTable   = "table"
+)
+
+// maxReportWidth caps the width of the terminal report on wide terminals,
+// where very long lines are harder to read
+const maxReportWidth = 120
+
+// confidenceLevels are the confidence levels, in the order sections are shown
+var confidenceLevels = []string{"high", "medium", "low"}
+
+// validateTerminalFlags checks the --layout, --collapse and --group-by
+// values
+func validateTerminalFlags(layout string, collapse []string, groupBy string) error {
+	switch layout {
+	case layoutFull, layoutCompact, layoutTable:
+	default:
+		return fmt.Errorf("invalid layout %q (valid: full, compact, table)", layout)
+	}
+	for _, level := range collapse {
+		if !slices.Contains(confidenceLevels, level) {
+			return fmt.Errorf("invalid confidence level %q (valid: high, medium, low)", level)
+		}
+	}
+	if !slices.Contains(groupings, groupBy) {
+		return fmt.Errorf("invalid grouping %q (valid: %s)", groupBy, strings.Join(groupings, ", "))
+	}
+	return nil
+}
+
+// terminalStyles are the styles of the terminal report
+type terminalStyles struct {
+	box      lipgloss.Style
+	title    lipgloss.Style
+	sections map[string]lipgloss.Style
+	prTitle  lipgloss.Style
+	warning  lipgloss.Style
+	muted    lipgloss.Style
+	header   lipgloss.Style
+	mark     lipgloss.Style
+	group    lipgloss.Style
+}
+
+// newTerminalStyles returns the report styles, without colors unless out
+// uses colors
+func newTerminalStyles(out *output.Writer) terminalStyles {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.Ascii)
+	if out.Colors() {
+		r.SetColorProfile(termenv.ANSI)
+	}
+
+	return terminalStyles{
+		box:   r.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1),
+		title: r.NewStyle().Bold(true),
+		sections: map[string]lipgloss.Style{
+			"high":   r.NewStyle().Bold(true).Foreground(lipgloss.Color("2")),
+			"medium": r.NewStyle().Bold(true).Foreground(lipgloss.Color("4")),
+			"low":    r.NewStyle().Bold(true).Foreground(lipgloss.Color("3")),
+		},
+		prTitle: r.NewStyle().Foreground(lipgloss.Color("2")),
+		warning: r.NewStyle().Foreground(lipgloss.Color("1")),
+		muted:   r.NewStyle().Foreground(lipgloss.Color("8")),
+		header:  r.NewStyle().Bold(true).Underline(true),
+		mark:    r.NewStyle().Bold(true).Foreground(lipgloss.Color("6")),
+		group:   r.NewStyle().Bold(true).Foreground(lipgloss.Color("5")),
+	}
+}
+
+// terminalReport renders match results for a terminal of a given width
+type terminalReport struct {
+	out      *output.Writer
+	styles   terminalStyles
+	width    int
+	layout   string
+	groupBy  string
+	collapse map[string]bool
+	marks    map[int]string // Triage marks, see triageMarks
+}
+
+func outputTerminal(out *output.Writer, results []pr.MatchResult, deps *deps.Dependencies, hosts []string, marks map[int]string, flags watchFlags) error {
+	report := &terminalReport{
+		out:      out,
+		styles:   newTerminalStyles(out),
+		width:    min(out.Width(), maxReportWidth),
+		layout:   flags.layout,
+		groupBy:  flags.groupBy,
+		collapse: make(map[string]bool),
+		marks:    marks,
+	}
+	for _, level := range flags.collapse {
+		report.collapse[level] = true
+	}
+	report.render(results, deps, hosts)
+	return nil
+}
+
+// render writes the summary box, then a section per confidence level,
+// label family or dependency
+func (t *terminalReport) render(results []pr.MatchResult, deps *deps.Dependencies, hosts []string) {
+	summary := strings.Join([]string{
+		t.styles.title.Render("NixOS/nixpkgs PRs matching your configuration"),
+		fmt.Sprintf("Analyzed: %s (%d packages, %d modules)", formatHosts(hosts), len(deps.Packages), len(deps.Modules)),
+		t.foundSummary(results),
+	}, "\n")
+	t.out.Println("")
+	t.out.Println("%s", t.styles.box.Width(t.width-2).Render(summary))
+	t.out.Println("")
+
+	switch t.groupBy {
+	case groupByLabelPrefix:
+		for _, family := range groupByLabels(results) {
+			t.renderFamily(family)
+		}
+		return
+	case groupByPackage:
+		for _, g := range groupByPackages(results) {
+			t.renderPackage(g)
+		}
+		return
+	}
+
+	byConfidence := make(map[string][]pr.MatchResult)
+	for _, r := range results {
+		level := r.HighestConfidence()
+		byConfidence[level] = append(byConfidence[level], r)
+	}
+
+	for _, level := range confidenceLevels {
+		if len(byConfidence[level]) > 0 {
+			t.renderSection(level, byConfidence[level])
+		}
+	}
+}
+
+// foundSummary counts the results, and those marked new or changed
+func (t *terminalReport) foundSummary(results []pr.MatchResult) string {
+	summary := fmt.Sprintf("Found: %d relevant PRs", len(results))
+	var newPRs, changed int
+	for _, r := range results {
+		switch t.marks[r.PR.Number] {
+		case markNew:
+			newPRs++
+		case markUpdated, markStatusChanged:
+			changed++
+		}
+	}
+	if newPRs > 0 || changed > 0 {
+		summary += fmt.Sprintf(" (%d new, %d changed)", newPRs, changed)
+	}
+	return summary
+}
+
+// renderSection writes the matches of a confidence level, or a one-line
+// summary if the level is collapsed
+func (t *terminalReport) renderSection(level string, results []pr.MatchResult) {
+	heading := fmt.Sprintf("%s CONFIDENCE MATCHES (%d)", strings.ToUpper(level), len(results))
+	if t.collapse[level] {
+		heading += " [collapsed]"
+	}
+	t.out.Println("%s", t.styles.sections[level].Render(heading))
+	t.out.Println("%s", strings.Repeat("═", t.width))
+
+	if t.collapse[level] {
+		numbers := make([]string, len(results))
+		for i, r := range results {
+			numbers[i] = fmt.Sprintf("#%d", r.PR.Number)
+		}
+		t.out.Println("%s", t.styles.muted.Render(t.truncate(strings.Join(numbers, " "))))
+		t.out.Println("")
+		return
+	}
+	t.out.Println("")
+	t.renderResults(results)
+}
+
+// renderFamily writes the matches of a label family, under a heading per
+// label
+func (t *terminalReport) renderFamily(family labelFamily) {
+	t.out.Println("%s", t.styles.group.Render(strings.ToUpper(family.name)))
+	t.out.Println("%s", strings.Repeat("═", t.width))
+	t.out.Println("")
+
+	for _, g := range family.groups {
+		if g.label != "" {
+			t.out.Println("%s", t.styles.title.Render(t.truncate(fmt.Sprintf("%s (%d)", g.label, len(g.results)))))
+			t.out.Println("%s", strings.Repeat("─", t.width))
+		}
+		t.renderResults(g.results)
+	}
+}
+
+// renderPackage writes the PRs touching a dependency, highlighting
+// competing version updates and duplicates
+func (t *terminalReport) renderPackage(g packageGroup) {
+	heading := t.styles.group.Render(fmt.Sprintf("%s (%s, %s)", g.Dependency, g.Type, pluralPRs(len(g.PRs))))
+	if g.Competing {
+		heading += " " + t.styles.warning.Render("COMPETING: "+strings.Join(g.Versions, ", "))
+	}
+	t.out.Println("%s", t.wrap(heading, "  "))
+	t.out.Println("%s", strings.Repeat("─", t.width))
+	for _, p := range g.duplicates() {
+		t.out.Println("%s", t.styles.warning.Render(t.truncate(fmt.Sprintf("#%d duplicates #%d (→ %s)", p.Number, p.DuplicateOf, p.To))))
+	}
+	t.out.Println("")
+	t.renderResults(g.results())
+}
+
+// renderResults writes matches in the table, full or compact layout
+func (t *terminalReport) renderResults(results []pr.MatchResult) {
+	if t.layout == layoutTable {
+		t.renderTable(results)
+		t.out.Println("")
+		return
+	}
+	for _, r := range results {
+		t.renderMatch(r)
+	}
+}
+
+// renderMatch writes a match in the full or compact layout
+func (t *terminalReport) renderMatch(r pr.MatchResult) {
+	statusIndicators := formatStatusIndicators(r.PR)
+
+	titleLine := fmt.Sprintf("[#%d] %s", r.PR.Number, r.PR.Title)
+	if t.layout == layoutCompact {
+		titleLine = fmt.Sprintf("%s (created: %s)", titleLine, formatDate(r.PR.CreatedAt))
+	}
+	titleLine = t.styles.prTitle.Render(titleLine)
+	if mark := t.marks[r.PR.Number]; mark != "" {
+		titleLine = t.styles.mark.Render(mark) + " " + titleLine
+	}
+	if statusIndicators != "" {
+		titleLine += " " + t.styles.warning.Render(statusIndicators)
+	}
+	t.out.Println("%s", t.wrap(titleLine, "  "))
+
+	if t.layout == layoutCompact {
+		author := "@" + r.PR.Author
+		if r.PR.BaseRef != "" {
+			author += " on " + formatChannelPath(r.PR.BaseRef)
+		}
+		t.out.Println("  %s by %s - %s", formatMatches(r.Matches), author, r.PR.URL)
+	} else {
+		t.out.Println("%s", t.truncate("  → Matches: "+formatMatches(r.Matches)))
+		if len(r.PR.Files) > 0 {
+			t.out.Println("%s", t.truncate("  │ Files: "+formatFiles(r.PR.Files)))
+		}
+		if len(r.PR.Labels) > 0 {
+			t.out.Println("%s", t.truncate("  │ Labels: "+formatLabels(r.PR.Labels)))
+		}
+		t.out.Println("  │ Created: %s | Updated: %s", formatDate(r.PR.CreatedAt), formatDate(r.PR.UpdatedAt))
+		t.out.Println("  │ Author: @%s", r.PR.Author)
+		if r.PR.BaseRef != "" {
+			t.out.Println("%s", t.truncate("  │ Branch: "+formatChannelPath(r.PR.BaseRef)))
+		}
+		t.out.Println("  └ %s", r.PR.URL)
+	}
+	t.out.Println("")
+}
+
+// tableColumn is a column of the table layout
+type tableColumn struct {
+	header string
+	value  func(pr.MatchResult) string
+	style  func(pr.MatchResult) lipgloss.Style
+}
+
+// minTitleWidth is the narrowest title column of the table layout. Columns
+// are dropped on terminals too narrow to keep it.
+const minTitleWidth = 20
+
+// renderTable writes matches as a table, one line per PR. The title takes
+// the remaining width and is truncated to fit. On narrow terminals, the
+// age, author, branch, status and mark columns are dropped, in that order.
+func (t *terminalReport) renderTable(results []pr.MatchResult) {
+	plain := func(pr.MatchResult) lipgloss.Style { return lipgloss.NewStyle() }
+	muted := func(pr.MatchResult) lipgloss.Style { return t.styles.muted }
+	columns := []tableColumn{
+		{"PR", func(r pr.MatchResult) string { return "#" + strconv.Itoa(r.PR.Number) },
+			func(pr.MatchResult) lipgloss.Style { return t.styles.prTitle }},
+		{"MARK", func(r pr.MatchResult) string { return t.marks[r.PR.Number] },
+			func(pr.MatchResult) lipgloss.Style { return t.styles.mark }},
+		{"PACKAGE", func(r pr.MatchResult) string {
+			if len(r.Matches) == 0 {
+				return ""
+			}
+			return r.Matches[0].Dependency
+		}, plain},
+		{"STATUS", func(r pr.MatchResult) string { return prStatus(r.PR) },
+			func(r pr.MatchResult) lipgloss.Style {
+				if r.PR.NeedsAttention() {
+					return t.styles.warning
+				}
+				return lipgloss.NewStyle()
+			}},
+		{"BRANCH", func(r pr.MatchResult) string { return formatChannelPath(r.PR.BaseRef) }, plain},
+		{"AGE", func(r pr.MatchResult) string { return formatDate(r.PR.CreatedAt) }, muted},
+		{"AUTHOR", func(r pr.MatchResult) string { return "@" + r.PR.Author }, muted},
+		{"TITLE", func(r pr.MatchResult) string { return r.PR.Title }, plain},
+	}
+	if !slices.ContainsFunc(results, func(r pr.MatchResult) bool { return t.marks[r.PR.Number] != "" }) {
+		columns = slices.DeleteFunc(columns, func(col tableColumn) bool { return col.header == "MARK" })
+	}
+	if !slices.ContainsFunc(results, func(r pr.MatchResult) bool { return r.PR.BaseRef != results[0].PR.BaseRef }) {
+		columns = slices.DeleteFunc(columns, func(col tableColumn) bool { return col.header == "BRANCH" })
+	}
+
+	// Size every column but the title to its content
+	const gap = 2
+	widthOf := func(col tableColumn) int {
+		w := lipgloss.Width(col.header)
+		for _, r := range results {
+			w = max(w, lipgloss.Width(col.value(r)))
+		}
+		return w
+	}
+	widths := make(map[string]int)
+	used := 0
+	for _, col := range columns[:len(columns)-1] {
+		widths[col.header] = widthOf(col)
+		used += widths[col.header] + gap
+	}
+	for _, drop := range []string{"AGE", "AUTHOR", "BRANCH", "STATUS", "MARK"} {
+		if t.width-used >= minTitleWidth {
+			break
+		}
+		if _, ok := widths[drop]; !ok {
+			continue
+		}
+		columns = slices.DeleteFunc(columns, func(col tableColumn) bool { return col.header == drop })
+		used -= widths[drop] + gap
+	}
+	widths["TITLE"] = max(t.width-used, minTitleWidth)
+
+	line := func(cell func(tableColumn) (string, lipgloss.Style)) string {
+		var b strings.Builder
+		for i, col := range columns {
+			text, style := cell(col)
+			text = ansi.Truncate(text, widths[col.header], "…")
+			b.WriteString(style.Render(text))
+			if i < len(columns)-1 {
+				b.WriteString(strings.Repeat(" ", widths[col.header]-lipgloss.Width(text)+gap))
+			}
+		}
+		return b.String()
+	}
+
+	t.out.Println("%s", line(func(col tableColumn) (string, lipgloss.Style) {
+		return col.header, t.styles.header
+	}))
+	for _, r := range results {
+		t.out.Println("%s", line(func(col tableColumn) (string, lipgloss.Style) {
+			return col.value(r), col.style(r)
+		}))
+	}
+}
+
+// formatChannelPath formats the path from a branch to channels, e.g.
+// "staging → staging-next → master → nixos-unstable"
+func formatChannelPath(branch string) string {
+	return strings.Join(config.ChannelPath(branch), " → ")
+}
+
+// prStatus summarizes the state of a PR in a word
+func prStatus(p pr.PullRequest) string {
+	switch {
+	case p.HasConflicts():
+		return "conflicts"
+	case p.HasBuildFailure():
+		return "failing"
+	case p.StatusState == "SUCCESS":
+		return "passing"
+	case p.StatusState == "PENDING" || p.StatusState == "EXPECTED":
+		return "pending"
+	default:
+		return "-"
+	}
+}
+
+// wrap wraps text to the report width, indenting continuation lines
+func (t *terminalReport) wrap(text, indent string) string {
+	lines := strings.Split(ansi.Wrap(text, t.width-len(indent), ""), "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = indent + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncate truncates text to the report width
+func (t *terminalReport) truncate(text string) string {
+	return ansi.Truncate(text, t.width, "…")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+func testMatchResults() []pr.MatchResult {
+	return []pr.MatchResult{
+		{
+			PR: pr.PullRequest{
+				Number:    479757,
+				Title:     "oci-cli: 3.71.4 -> 3.72.0, with a very long title that doesn't fit on narrow terminals 🚀",
+				URL:       "https://github.com/NixOS/nixpkgs/pull/479757",
+				Author:    "r-ryantm",
+				Mergeable: "CONFLICTING",
+				Files:     []pr.File{{Path: "pkgs/by-name/oc/oci-cli/package.nix", Additions: 3, Deletions: 3}},
+				CreatedAt: time.Now().Add(-48 * time.Hour),
+				UpdatedAt: time.Now().Add(-time.Hour),
+			},
+			Matches: []pr.Match{{Type: "package", Dependency: "oci-cli", Confidence: "high"}},
+		},
+		{
+			PR: pr.PullRequest{
+				Number:    479713,
+				Title:     "nautilus: 48.1 -> 48.2",
+				URL:       "https://github.com/NixOS/nixpkgs/pull/479713",
+				Author:    "bobby285271",
+				CreatedAt: time.Now().Add(-72 * time.Hour),
+				UpdatedAt: time.Now().Add(-time.Hour),
+			},
+			Matches: []pr.Match{{Type: "title", Dependency: "nautilus", Confidence: "medium"}},
+		},
+	}
+}
+
+func renderTerminal(t *testing.T, width int, flags watchFlags) string {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	out := output.NewWriter(&stdout, &bytes.Buffer{}, false)
+	out.SetWidth(width)
+
+	d := &deps.Dependencies{Packages: []deps.Package{{Name: "oci-cli"}, {Name: "nautilus"}}}
+	hosts := []string{"a-very-long-hostname-that-would-break-the-box.example.com"}
+	if err := outputTerminal(out, testMatchResults(), d, hosts, map[int]string{479757: markStatusChanged}, flags); err != nil {
+		t.Fatalf("outputTerminal() error = %v", err)
+	}
+	return stdout.String()
+}
+
+func TestOutputTerminal_FitsWidth(t *testing.T) {
+	for _, layout := range []string{layoutFull, layoutCompact, layoutTable} {
+		for _, width := range []int{40, 80, 200} {
+			got := renderTerminal(t, width, watchFlags{layout: layout})
+			for _, line := range strings.Split(got, "\n") {
+				// URLs are never truncated, to keep them usable
+				if strings.Contains(line, "https://") {
+					continue
+				}
+				if w := lipgloss.Width(line); w > min(width, maxReportWidth) {
+					t.Errorf("layout %s, width %d: line is %d wide:\n%s", layout, width, w, line)
+				}
+			}
+			if !strings.Contains(got, "#479713") {
+				t.Errorf("layout %s, width %d: missing PR:\n%s", layout, width, got)
+			}
+		}
+	}
+}
+
+func TestOutputTerminal_Table(t *testing.T) {
+	got := renderTerminal(t, 100, watchFlags{layout: layoutTable})
+
+	for _, want := range []string{"PR", "PACKAGE", "STATUS", "AGE", "AUTHOR", "#479757", "oci-cli", "conflicts", "@r-ryantm", "2d ago"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("table missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestOutputTerminal_Collapse(t *testing.T) {
+	got := renderTerminal(t, 80, watchFlags{layout: layoutFull, collapse: []string{"medium"}})
+
+	if !strings.Contains(got, "MEDIUM CONFIDENCE MATCHES (1) [collapsed]") {
+		t.Errorf("medium section should be collapsed:\n%s", got)
+	}
+	if strings.Contains(got, "nautilus: 48.1") {
+		t.Errorf("collapsed section should not show PR details:\n%s", got)
+	}
+	if !strings.Contains(got, "#479713") || !strings.Contains(got, "[#479757] oci-cli") {
+		t.Errorf("collapsed PRs should be listed and others expanded:\n%s", got)
+	}
+}
+
+func TestValidateTerminalFlags(t *testing.T) {
+	tests := []struct {
+		layout   string
+		collapse []string
+		groupBy  string
+		wantErr  bool
+	}{
+		{layout: layoutFull, groupBy: groupByConfidence},
+		{layout: layoutTable, collapse: []string{"low", "medium"}, groupBy: groupByConfidence},
+		{layout: layoutCompact, groupBy: groupByLabelPrefix},
+		{layout: "grid", groupBy: groupByConfidence, wantErr: true},
+		{layout: layoutFull, collapse: []string{"urgent"}, groupBy: groupByConfidence, wantErr: true},
+		{layout: layoutFull, groupBy: "author", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := validateTerminalFlags(tt.layout, tt.collapse, tt.groupBy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateTerminalFlags(%q, %v, %q) error = %v, wantErr %v", tt.layout, tt.collapse, tt.groupBy, err, tt.wantErr)
+		}
+	}
+}
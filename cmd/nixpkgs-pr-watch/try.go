@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/paths"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// defaultNixpkgsRemote is where PR heads are fetched from
+const defaultNixpkgsRemote = "https://github.com/NixOS/nixpkgs.git"
+
+// buildLogLines is how many lines of the log of a failed build are shown
+const buildLogLines = 20
+
+// tryFlags are the flags of the try command
+type tryFlags struct {
+	nixpkgs     string   // Local nixpkgs clone, where worktrees are added
+	remote      string   // Remote (URL or name) PR heads are fetched from
+	worktreeDir string   // Directory of the worktrees
+	attrs       []string // Attributes to build instead of the matched packages
+	toplevel    bool
+	keep        bool
+}
+
+// commandRunner runs a command in dir, returning its combined output
+type commandRunner func(ctx context.Context, dir, name string, args ...string) ([]byte, error)
+
+// runCommand runs a command in dir, returning its combined output
+func runCommand(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// buildResult is the outcome of building an attribute or a host
+type buildResult struct {
+	name     string
+	err      error
+	duration time.Duration
+	log      string // Output of the build, kept on failure
+}
+
+// trial builds what a PR changes for the hosts in a worktree of nixpkgs at
+// the head of the PR
+type trial struct {
+	out   *output.Writer
+	flags tryFlags
+	run   commandRunner
+}
+
+// worktree fetches the head of a PR from the remote and checks it out in
+// a new worktree, replacing a previous one, returning its path and commit
+func (t *trial) worktree(ctx context.Context, number int) (string, string, error) {
+	path := filepath.Join(t.flags.worktreeDir, fmt.Sprintf("pr-%d", number))
+	if _, err := os.Stat(path); err == nil {
+		t.out.Verbose("Removing previous worktree %s", path)
+		if err := t.removeWorktree(ctx, path); err != nil {
+			return "", "", err
+		}
+	}
+	if err := os.MkdirAll(t.flags.worktreeDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	t.out.Info("Fetching PR #%d from %s...", number, t.flags.remote)
+	if err := t.git(ctx, "fetch", "--no-tags", t.flags.remote, fmt.Sprintf("refs/pull/%d/head", number)); err != nil {
+		return "", "", fmt.Errorf("failed to fetch PR #%d: %w", number, err)
+	}
+	if err := t.git(ctx, "worktree", "add", "--detach", path, "FETCH_HEAD"); err != nil {
+		return "", "", fmt.Errorf("failed to add worktree: %w", err)
+	}
+
+	rev, err := t.run(ctx, path, "git", "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve the head of PR #%d: %w", number, err)
+	}
+	return path, strings.TrimSpace(string(rev)), nil
+}
+
+// removeWorktree removes a worktree of the nixpkgs clone
+func (t *trial) removeWorktree(ctx context.Context, path string) error {
+	if err := t.git(ctx, "worktree", "remove", "--force", path); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	return nil
+}
+
+// git runs git in the nixpkgs clone
+func (t *trial) git(ctx context.Context, args ...string) error {
+	combined, err := t.run(ctx, t.flags.nixpkgs, "git", args...)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(combined)))
+	}
+	return nil
+}
+
+// build runs nix build, reporting the outcome as name
+func (t *trial) build(ctx context.Context, name string, args ...string) buildResult {
+	t.out.Info("Building %s...", name)
+	start := time.Now()
+	combined, err := t.run(ctx, "", "nix", append([]string{"build", "--no-link"}, args...)...)
+	result := buildResult{name: name, err: err, duration: time.Since(start)}
+	if err != nil {
+		result.log = string(combined)
+	}
+	return result
+}
+
+// buildAttributes builds attributes of the nixpkgs worktree
+func (t *trial) buildAttributes(ctx context.Context, worktree string, attrs []string) []buildResult {
+	var results []buildResult
+	for _, attr := range attrs {
+		results = append(results, t.build(ctx, attr, "--file", worktree, attr))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results
+}
+
+// buildToplevels builds the system of hosts of a flake, with its nixpkgs
+// input overridden to the worktree
+func (t *trial) buildToplevels(ctx context.Context, worktree, flake string, hosts []string) []buildResult {
+	var results []buildResult
+	for _, host := range hosts {
+		ref := fmt.Sprintf("%s#nixosConfigurations.%s.config.system.build.toplevel", flake, host)
+		results = append(results, t.build(ctx, host+" (toplevel)", ref, "--override-input", "nixpkgs", "path:"+worktree))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results
+}
+
+// matchedAttributes returns the attributes of the packages a PR changes
+// that the hosts use, as extracted from their dependencies, and the matched
+// packages skipped because no attribute was extracted for them: a package
+// name isn't necessarily its attribute. Modules, services and titles don't
+// name a package to build.
+func matchedAttributes(result pr.MatchResult, hostDeps map[string]*deps.Dependencies, hosts []string) (attrs, skipped []string) {
+	add := func(attr string) {
+		if !slices.Contains(attrs, attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	for _, m := range result.Matches {
+		if m.Type != "package" {
+			continue
+		}
+		found := false
+		for _, host := range hosts {
+			d, ok := hostDeps[host]
+			if !ok {
+				continue
+			}
+			for _, p := range d.Packages {
+				if p.Attribute != "" && strings.EqualFold(p.Name, m.Dependency) {
+					add(p.Attribute)
+					found = true
+				}
+			}
+		}
+		if !found && !slices.Contains(skipped, m.Dependency) {
+			skipped = append(skipped, m.Dependency)
+		}
+	}
+	slices.Sort(attrs)
+	slices.Sort(skipped)
+	return attrs, skipped
+}
+
+// reportBuilds writes the outcome of builds, returning an error if any
+// failed
+func reportBuilds(out *output.Writer, results []buildResult) error {
+	failed := 0
+	for _, r := range results {
+		if r.err == nil {
+			out.Success("%s built in %s", r.name, r.duration.Round(time.Second))
+			continue
+		}
+		failed++
+		out.Error("%s failed to build after %s: %v", r.name, r.duration.Round(time.Second), r.err)
+		lines := strings.Split(strings.TrimRight(r.log, "\n"), "\n")
+		if len(lines) > buildLogLines {
+			lines = lines[len(lines)-buildLogLines:]
+		}
+		for _, line := range lines {
+			out.Print("    %s\n", line)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d builds failed", failed, len(results))
+	}
+	return nil
+}
+
+// findMatch returns the match of a PR among the matches of a run
+func findMatch(run *matchRun, number int) (pr.MatchResult, bool) {
+	i := slices.IndexFunc(run.all, func(r pr.MatchResult) bool { return r.PR.Number == number })
+	if i < 0 {
+		return pr.MatchResult{}, false
+	}
+	return run.all[i], true
+}
+
+// tryCmd returns the try command
+func tryCmd(out *output.Writer) *cobra.Command {
+	var (
+		flags watchFlags
+		opts  tryFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "try <pr>",
+		Short: "Build the packages a matching PR changes at its head",
+		Long: `Build the packages used by the hosts that a matching PR changes, in a
+worktree of a local nixpkgs clone at the head of the PR, and report whether
+each of them builds.
+
+The head of the PR is fetched from --remote into the clone given with
+--nixpkgs. With --toplevel, the systems of the hosts the PR is relevant to
+are built too, with the nixpkgs input of the flake overridden to the
+worktree.`,
+		Example: `  # Build the packages PR #412345 changes
+  nixpkgs-pr-watch try 412345 --nixpkgs ~/src/nixpkgs
+
+  # Also build the system of the hosts, and keep the worktree
+  nixpkgs-pr-watch try 412345 --nixpkgs ~/src/nixpkgs --all-hosts --toplevel --keep`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+			if err != nil {
+				return fmt.Errorf("invalid PR number %q", args[0])
+			}
+			if opts.nixpkgs == "" {
+				return errors.New("--nixpkgs is required, or nixpkgs in the configuration file")
+			}
+			if opts.toplevel && (flags.depsFile != "" || flags.nixosConfig != "") {
+				return errors.New("--toplevel requires the dependencies of a flake")
+			}
+			if opts.worktreeDir == "" {
+				dir, err := paths.CacheDir("nixpkgs-pr-watch")
+				if err != nil {
+					return err
+				}
+				opts.worktreeDir = filepath.Join(dir, "worktrees")
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runTry(ctx, out, flags.resolve(), &trial{out: out, flags: opts, run: runCommand}, number)
+		},
+	}
+
+	addMatchFlags(cmd, cmd.Flags(), &flags)
+	cmd.Flags().StringVar(&opts.nixpkgs, "nixpkgs", "", "Local nixpkgs clone to add the worktree to")
+	cmd.Flags().StringVar(&opts.remote, "remote", defaultNixpkgsRemote, "Remote of the nixpkgs clone, or URL, to fetch the PR from")
+	cmd.Flags().StringVar(&opts.worktreeDir, "worktree-dir", "", "Directory of worktrees (default: $XDG_CACHE_HOME/nixpkgs-pr-watch/worktrees)")
+	cmd.Flags().StringSliceVar(&opts.attrs, "attr", nil, "Attributes to build instead of the matched packages")
+	cmd.Flags().BoolVar(&opts.toplevel, "toplevel", false, "Also build the system of the hosts with nixpkgs overridden to the worktree")
+	cmd.Flags().BoolVar(&opts.keep, "keep", false, "Keep the worktree after building")
+
+	return cmd
+}
+
+// runTry builds what PR number changes for the hosts
+func runTry(ctx context.Context, out *output.Writer, flags watchFlags, t *trial, number int) error {
+	c, err := openCache()
+	if err != nil {
+		return fmt.Errorf("failed to initialize cache: %w", err)
+	}
+
+	run, err := matchPRs(ctx, out, c, flags, prFetchOptions{})
+	if err != nil {
+		return err
+	}
+	result, ok := findMatch(run, number)
+	if !ok {
+		return fmt.Errorf("PR #%d doesn't match the dependencies of %s (or isn't among the %d latest open PRs)", number, formatHosts(run.hosts), flags.limit)
+	}
+	if confidence := result.HighestConfidence(); confidence != "high" {
+		out.Warning("PR #%d is a %s confidence match", number, confidence)
+	}
+
+	hosts := run.prHosts[number]
+	if len(hosts) == 0 {
+		hosts = run.hosts
+	}
+	attrs := t.flags.attrs
+	if len(attrs) == 0 {
+		var skipped []string
+		attrs, skipped = matchedAttributes(result, run.hostDeps, hosts)
+		if len(skipped) > 0 {
+			out.Warning("No attribute extracted for %s, not building them, use --attr", strings.Join(skipped, ", "))
+		}
+	}
+	if len(attrs) == 0 && !t.flags.toplevel {
+		return fmt.Errorf("PR #%d changes no package used by %s, use --attr or --toplevel", number, formatHosts(hosts))
+	}
+
+	path, rev, err := t.worktree(ctx, number)
+	if err != nil {
+		return err
+	}
+	out.Info("PR #%d checked out at %s in %s", number, rev, path)
+	defer func() {
+		if t.flags.keep {
+			out.Info("Kept worktree %s", path)
+			return
+		}
+		// The build may have been interrupted, remove the worktree anyway
+		if err := t.removeWorktree(context.WithoutCancel(ctx), path); err != nil {
+			out.Warning("%v", err)
+		}
+	}()
+
+	results := t.buildAttributes(ctx, path, attrs)
+	if t.flags.toplevel && ctx.Err() == nil {
+		// Hosts of extra repositories are built from their own flake
+		var flakes []string
+		flakeHosts := make(map[string][]string)
+		for _, host := range hosts {
+			flake, ok := run.flakes[host]
+			if !ok {
+				flake = flags.flakePath
+			}
+			if _, ok := flakeHosts[flake]; !ok {
+				flakes = append(flakes, flake)
+			}
+			flakeHosts[flake] = append(flakeHosts[flake], host)
+		}
+		for _, flake := range flakes {
+			results = append(results, t.buildToplevels(ctx, path, flake, flakeHosts[flake])...)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	out.Println("")
+	out.Println("PR #%d (%s): %s", number, rev, result.PR.Title)
+	return reportBuilds(out, results)
+}
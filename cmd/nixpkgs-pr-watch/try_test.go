@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// testGit runs git in dir, with an identity to commit
+func testGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=master"}, args...)
+	out, err := runCommand(context.Background(), dir, "git", args...)
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// testNixpkgsRemote returns a bare repository with the head of PR #42, and
+// a clone of it
+func testNixpkgsRemote(t *testing.T) (remote, clone string) {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	remote = filepath.Join(dir, "remote.git")
+	clone = filepath.Join(dir, "nixpkgs")
+
+	testGit(t, dir, "init", "-q", src)
+	if err := os.WriteFile(filepath.Join(src, "default.nix"), []byte("{ }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, src, "add", ".")
+	testGit(t, src, "commit", "-q", "-m", "init")
+	testGit(t, dir, "clone", "-q", "--bare", src, remote)
+	testGit(t, dir, "clone", "-q", remote, clone)
+
+	if err := os.WriteFile(filepath.Join(src, "pr.nix"), []byte("{ }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, src, "add", ".")
+	testGit(t, src, "commit", "-q", "-m", "foo: 1.0 -> 1.1")
+	testGit(t, src, "push", "-q", remote, "HEAD:refs/pull/42/head")
+	return remote, clone
+}
+
+func TestTrial(t *testing.T) {
+	remote, clone := testNixpkgsRemote(t)
+
+	var stdout, stderr bytes.Buffer
+	out := output.NewWriter(&stdout, &stderr, false)
+	var builds [][]string
+	tr := &trial{
+		out:   out,
+		flags: tryFlags{nixpkgs: clone, remote: remote, worktreeDir: filepath.Join(t.TempDir(), "worktrees")},
+		run: func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+			if name != "nix" {
+				return runCommand(ctx, dir, name, args...)
+			}
+			builds = append(builds, args)
+			if slices.Contains(args, "bar") {
+				return []byte("error: builder for bar failed\n"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+
+	ctx := context.Background()
+	path, rev, err := tr.worktree(ctx, 42)
+	if err != nil {
+		t.Fatalf("worktree() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "pr.nix")); err != nil {
+		t.Errorf("worktree isn't at the head of the PR: %v", err)
+	}
+	if rev == "" {
+		t.Error("no revision")
+	}
+
+	// A previous worktree of the PR is replaced
+	if _, _, err := tr.worktree(ctx, 42); err != nil {
+		t.Fatalf("worktree() again error = %v", err)
+	}
+
+	results := tr.buildAttributes(ctx, path, []string{"bar", "foo"})
+	results = append(results, tr.buildToplevels(ctx, path, "/src/flake", []string{"kyushu"})...)
+	want := [][]string{
+		{"build", "--no-link", "--file", path, "bar"},
+		{"build", "--no-link", "--file", path, "foo"},
+		{"build", "--no-link", "/src/flake#nixosConfigurations.kyushu.config.system.build.toplevel", "--override-input", "nixpkgs", "path:" + path},
+	}
+	if !slices.EqualFunc(builds, want, slices.Equal) {
+		t.Errorf("builds = %q, want %q", builds, want)
+	}
+
+	err = reportBuilds(out, results)
+	if err == nil || err.Error() != "1 of 3 builds failed" {
+		t.Errorf("reportBuilds() error = %v", err)
+	}
+	if got := stdout.String(); !strings.Contains(got, "foo built in") || !strings.Contains(got, "kyushu (toplevel) built in") || !strings.Contains(got, "    error: builder for bar failed") {
+		t.Errorf("stdout:\n%s", got)
+	}
+	if got := stderr.String(); !strings.Contains(got, "bar failed to build") {
+		t.Errorf("stderr:\n%s", got)
+	}
+
+	if err := tr.removeWorktree(ctx, path); err != nil {
+		t.Fatalf("removeWorktree() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("worktree not removed: %v", err)
+	}
+}
+
+func TestMatchedAttributes(t *testing.T) {
+	result := pr.MatchResult{Matches: []pr.Match{
+		{Type: "package", Dependency: "git"},
+		{Type: "title", Dependency: "curl"},
+		{Type: "service", Dependency: "nginx"},
+		{Type: "module", Dependency: "docker"},
+		{Type: "package", Dependency: "git-lfs"},
+		{Type: "package", Dependency: "git"},
+		{Type: "package", Dependency: "python3"},
+	}}
+	hostDeps := map[string]*deps.Dependencies{
+		"kyushu": {Packages: []deps.Package{
+			{Name: "git", Attribute: "gitFull"},
+			{Name: "git-lfs"},
+		}},
+		"sakhalin": {Packages: []deps.Package{
+			{Name: "python3", Attribute: "python312"},
+		}},
+	}
+
+	// Attributes are those of the packages on the hosts the PR is relevant to
+	// and packages without an extracted attribute are skipped rather than
+	// built by name
+	if got, skipped := matchedAttributes(result, hostDeps, []string{"kyushu"}); !slices.Equal(got, []string{"gitFull"}) || !slices.Equal(skipped, []string{"git-lfs", "python3"}) {
+		t.Errorf("matchedAttributes() = %v, skipped %v", got, skipped)
+	}
+	if got, skipped := matchedAttributes(result, hostDeps, []string{"kyushu", "sakhalin"}); !slices.Equal(got, []string{"gitFull", "python312"}) || !slices.Equal(skipped, []string{"git-lfs"}) {
+		t.Errorf("matchedAttributes() = %v, skipped %v", got, skipped)
+	}
+}
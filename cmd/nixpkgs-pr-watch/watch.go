@@ -1,10 +1,11 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -16,207 +17,244 @@ import (
 	"go.sbr.pm/x/internal/pr"
 )
 
-func runWatch(out *output.Writer, flags watchFlags) error {
-	// Initialize cache
-	depsCache, err := cache.New(24*time.Hour, "nixpkgs-pr-watch")
+func runWatch(ctx context.Context, out *output.Writer, flags watchFlags) error {
+	// Initialize cache, shared by PRs and dependencies with per-entry TTLs
+	c, err := openCache()
 	if err != nil {
 		return fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
-	prCache, err := cache.New(6*time.Hour, "nixpkgs-pr-watch")
+	// Parse notification targets before the long fetch, to fail early
+	n, err := newNotifier(out, flags.notify, flags.profile)
 	if err != nil {
-		return fmt.Errorf("failed to initialize cache: %w", err)
+		return err
+	}
+
+	// The terminal report shows stale cached PRs at once, then again once
+	// they are refreshed. Other outputs are single documents, they wait for
+	// the refresh.
+	terminal := !flags.interactive && flags.feedFile == "" && flags.format.Format == "terminal" && flags.format.Template == ""
+	var opts prFetchOptions
+	if terminal {
+		opts.background = &staleRefresh{}
 	}
 
-	// Determine which hosts to analyze
-	cfg, err := config.New(flags.flakePath)
+	run, snoozed, err := triagedMatches(ctx, out, c, flags, opts)
 	if err != nil {
-		return fmt.Errorf("failed to load flake configuration: %w", err)
+		return err
 	}
 
-	var hostsToAnalyze []string
-	if flags.allHosts {
-		hostsToAnalyze, err = cfg.AllHosts()
-		if err != nil {
-			return fmt.Errorf("failed to get all hosts: %w", err)
+	// Output results
+	if flags.interactive {
+		err = runInteractive(run, snoozed)
+	} else if flags.feedFile != "" {
+		err = writeFeedFile(flags.feedFile, flags.format.Format, newFeed(run.report()))
+		if err == nil {
+			out.Success("Updated %s feed %s", flags.format.Format, flags.feedFile)
 		}
-	} else {
-		hostname := flags.host
-		if hostname == "" {
-			hostname, err = cfg.CurrentHost()
-			if err != nil {
-				return fmt.Errorf("failed to determine current host: %w", err)
+	} else if terminal {
+		err = outputTerminal(out, run.results, run.deps, run.hosts, run.marks, flags)
+		if err == nil && opts.background.wait() {
+			out.Info("Refreshed stale PRs, matching them again...")
+			run, _, err = triagedMatches(ctx, out, c, flags, prFetchOptions{})
+			if err == nil {
+				err = outputTerminal(out, run.results, run.deps, run.hosts, run.marks, flags)
 			}
 		}
-		hostsToAnalyze = []string{hostname}
+	} else {
+		rep := run.report()
+		if flags.groupBy == groupByPackage {
+			rep.Packages = groupByPackages(run.results)
+		}
+		err = output.Render(os.Stdout, flags.format, rep)
 	}
+	if err != nil || n == nil {
+		return err
+	}
+
+	// Announce matches not announced yet
+	return n.send(ctx, matchNotifications(run.results))
+}
 
-	out.Info("Analyzing hosts: %v", hostsToAnalyze)
+// triagedMatches matches PRs, hides those snoozed from the interactive list
+// when browsing it, marks those new or changed since acknowledged, and sorts
+// them
+func triagedMatches(ctx context.Context, out *output.Writer, c *cache.Cache, flags watchFlags, opts prFetchOptions) (*matchRun, *snoozes, error) {
+	run, err := matchPRs(ctx, out, c, flags, opts)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Extract dependencies for each host
-	allDeps := make(map[string]*deps.Dependencies)
-	totalPackages := 0
-	totalModules := 0
+	// Hide PRs snoozed from the interactive list from it, until they are
+	// updated. Reports and feeds are shared or scripted, they show them all.
+	snoozesPath, err := defaultSnoozesPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	snoozed, err := loadSnoozes(snoozesPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if flags.interactive && !flags.includeSnoozed {
+		var hidden int
+		run.results, hidden = snoozed.filter(run.results, time.Now())
+		if hidden > 0 {
+			out.Info("Hiding %d snoozed PRs (use --include-snoozed to show them)", hidden)
+		}
+	}
 
-	for _, hostname := range hostsToAnalyze {
-		cacheKey := fmt.Sprintf("%s-deps", hostname)
-		var hostDeps deps.Dependencies
+	// Mark matches new or changed since acknowledged with mark-seen
+	seenPath, err := seenStatePath(flags.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	seen, seenExists, err := loadWatchState(seenPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	run.marks = triageMarks(seen, run.results)
+	if !seenExists {
+		out.Verbose("No PRs marked as seen yet, all matches are new (see mark-seen)")
+	}
+	if flags.onlyNew || flags.onlyChanged {
+		run.results = filterByMarks(run.results, run.marks, flags.onlyNew, flags.onlyChanged)
+	}
 
-		// Try to load from cache
-		if !flags.refreshDeps {
-			if err := depsCache.Get(cacheKey, &hostDeps); err == nil && len(hostDeps.Packages) > 0 {
-				out.Info("  %s: loaded from cache (%d packages, %d modules)", hostname, len(hostDeps.Packages), len(hostDeps.Modules))
-				allDeps[hostname] = &hostDeps
-				totalPackages += len(hostDeps.Packages)
-				totalModules += len(hostDeps.Modules)
-				continue
+	// Sort results
+	sortResults(run.results, flags.sortBy)
+	return run, snoozed, nil
+}
+
+// matchRun is the outcome of matching PRs against the analyzed hosts
+type matchRun struct {
+	results  []pr.MatchResult
+	all      []pr.MatchResult // Matches before filtering by confidence
+	deps     *deps.Dependencies
+	hostDeps map[string]*deps.Dependencies
+	flakes   map[string]string // Flake of each analyzed host, if any
+	hosts    []string          // Analyzed hosts
+	prHosts  map[int][]string  // Hosts each PR of all is relevant to
+	marks    map[int]string    // Triage marks of results, see triageMarks
+}
+
+// report builds the report of the run
+func (r *matchRun) report() report {
+	rep := newReport(r.results, r.deps, r.hosts)
+	rep.PRHosts = make(map[int][]string, len(r.results))
+	for _, result := range r.results {
+		rep.PRHosts[result.PR.Number] = r.prHosts[result.PR.Number]
+		if result.PR.BaseRef != "" {
+			if rep.PRChannels == nil {
+				rep.PRChannels = make(map[int][]string)
 			}
+			rep.PRChannels[result.PR.Number] = config.ChannelPath(result.PR.BaseRef)
 		}
-
-		// Extract dependencies
-		out.Info("  %s: extracting dependencies...", hostname)
-		extractor := deps.NewExtractor(flags.flakePath, hostname)
-		hostDeps, err = extractor.Extract()
-		if err != nil {
-			out.Warning("  %s: failed to extract dependencies: %v", hostname, err)
-			continue
+		if mark, ok := r.marks[result.PR.Number]; ok {
+			if rep.Marks == nil {
+				rep.Marks = make(map[int]string)
+			}
+			rep.Marks[result.PR.Number] = mark
 		}
+	}
+	return rep
+}
 
-		// Cache the results
-		if err := depsCache.Set(cacheKey, hostDeps); err != nil {
-			out.Warning("  %s: failed to cache dependencies: %v", hostname, err)
-		}
+// matchPRs extracts the dependencies of the hosts to analyze, fetches open
+// PRs and returns those matching with at least the minimum confidence,
+// along with the merged dependencies, the analyzed hosts and the hosts each
+// PR is relevant to. opts controls how cached PRs are used.
+func matchPRs(ctx context.Context, out *output.Writer, c *cache.Cache, flags watchFlags, opts prFetchOptions) (*matchRun, error) {
+	if err := validateLabelGlobs(slices.Concat(flags.labels, flags.excludeLabels)); err != nil {
+		return nil, err
+	}
 
-		out.Info("  %s: found %d packages, %d modules", hostname, len(hostDeps.Packages), len(hostDeps.Modules))
-		allDeps[hostname] = &hostDeps
-		totalPackages += len(hostDeps.Packages)
-		totalModules += len(hostDeps.Modules)
+	hostsToAnalyze, allDeps, flakes, err := loadDependencies(out, c, flags)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(allDeps) == 0 {
-		return fmt.Errorf("no dependencies extracted from any host")
+		return nil, fmt.Errorf("no dependencies extracted from any host")
 	}
+	allDeps = ignoreDependencies(allDeps, flags.ignore)
 
 	// Merge dependencies from all hosts
 	merged := deps.Merge(allDeps)
-	out.Info("Total unique: %d packages, %d modules", len(merged.Packages), len(merged.Modules))
-
-	// Fetch PRs using incremental cache with smart merging
-	out.Info("Fetching nixpkgs PRs (limit: %d)...", flags.limit)
-	var prs []pr.PullRequest
-
-	// Check cache metadata to see if we have cached PRs
-	type prCacheMetadata struct {
-		MaxLimit  int       `json:"max_limit"`
-		FetchedAt time.Time `json:"fetched_at"`
-		Cursor    string    `json:"cursor"` // GraphQL cursor for pagination
-	}
-
-	var metadata prCacheMetadata
-	var cachedPRs []pr.PullRequest
-	metadataKey := "nixpkgs-prs-metadata"
-	prsKey := "nixpkgs-prs-data"
-
-	// Load existing cache
-	hasCachedPRs := false
-	if !flags.refreshPRs {
-		if err := prCache.Get(metadataKey, &metadata); err == nil {
-			if err := prCache.Get(prsKey, &cachedPRs); err == nil && len(cachedPRs) > 0 {
-				hasCachedPRs = true
-			}
-		}
-	}
+	out.Info("Total unique: %d packages, %d modules, %d services", len(merged.Packages), len(merged.Modules), len(merged.Services))
 
-	// Decide what to fetch
-	if hasCachedPRs && metadata.MaxLimit >= flags.limit {
-		// Cache has enough PRs, use them
-		prs = cachedPRs[:flags.limit]
-		out.Info("Loaded %d PRs from cache (cached: %d, age: %v)",
-			len(prs), len(cachedPRs), time.Since(metadata.FetchedAt).Round(time.Minute))
-	} else if hasCachedPRs && metadata.MaxLimit < flags.limit {
-		// Cache has some PRs but not enough - fetch additional PRs using cursor
-		deltaNeeded := flags.limit - metadata.MaxLimit
-		out.Info("Cache has %d PRs, fetching %d more using cursor...", metadata.MaxLimit, deltaNeeded)
-
-		fetcher := pr.NewFetcher()
-		newPRs, newCursor, err := fetcher.FetchNixpkgsPRsWithCursor(deltaNeeded, metadata.Cursor, flags.baseBranch)
-
-		// Merge cached PRs with any new PRs we got (even if there was an error)
-		prs = append(cachedPRs, newPRs...)
-
-		// Update cache with combined results if we got new data
-		if len(newPRs) > 0 {
-			metadata = prCacheMetadata{
-				MaxLimit:  len(prs),
-				FetchedAt: time.Now(),
-				Cursor:    newCursor,
-			}
-			if cacheErr := prCache.Set(prsKey, prs); cacheErr != nil {
-				out.Warning("Failed to cache PRs: %v", cacheErr)
-			}
-			if cacheErr := prCache.Set(metadataKey, metadata); cacheErr != nil {
-				out.Warning("Failed to cache metadata: %v", cacheErr)
+	// Fetch and match PRs per base branch, so hosts following a release
+	// channel are matched against backports to their release branch
+	branchHosts := groupHostsByBranch(detectBaseBranches(out, flags, hostsToAnalyze, flakes))
+
+	branches := sortedBranches(branchHosts)
+	branchPRs, err := fetchBranches(ctx, out, c, flags, opts, branches)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []pr.MatchResult
+	prHosts := make(map[int][]string)
+	for i, branch := range branches {
+		hosts := branchHosts[branch]
+		prs := branchPRs[i]
+
+		// Filter PRs by user if requested
+		if flags.user != "" {
+			var filteredPRs []pr.PullRequest
+			for _, p := range prs {
+				if p.Author == flags.user {
+					filteredPRs = append(filteredPRs, p)
+				}
 			}
+			out.Info("Filtered to %d PRs by user @%s", len(filteredPRs), flags.user)
+			prs = filteredPRs
+		}
+		if len(flags.ignorePRs) > 0 {
+			prs = slices.DeleteFunc(slices.Clone(prs), func(p pr.PullRequest) bool {
+				return slices.Contains(flags.ignorePRs, p.Number)
+			})
+		}
+		if len(flags.labels) > 0 || len(flags.excludeLabels) > 0 {
+			prs = filterByLabels(prs, flags.labels, flags.excludeLabels)
+			out.Info("Filtered to %d PRs by labels", len(prs))
 		}
 
-		if err != nil {
-			out.Warning("Failed to fetch additional PRs: %v", err)
-			if len(newPRs) > 0 {
-				out.Info("Cached partial results: %d previous + %d new = %d total PRs", len(cachedPRs), len(newPRs), len(prs))
-			} else {
-				out.Info("Using cached %d PRs instead", len(cachedPRs))
+		// Match PRs to the dependencies of the hosts following this branch
+		branchDeps := merged
+		if len(branchHosts) > 1 {
+			subset := make(map[string]*deps.Dependencies)
+			for _, host := range hosts {
+				if d, ok := allDeps[host]; ok {
+					subset[host] = d
+				}
 			}
-		} else {
-			out.Info("Fetched %d additional PRs, total: %d", len(newPRs), len(prs))
+			branchDeps = deps.Merge(subset)
 		}
-	} else {
-		// No cache or refresh requested - fetch fresh data using cursor-based API
-		fetcher := pr.NewFetcher()
-		var cursor string
-		prs, cursor, err = fetcher.FetchNixpkgsPRsWithCursor(flags.limit, "", flags.baseBranch)
-
-		// Cache partial results even if there was an error
-		if len(prs) > 0 {
-			metadata = prCacheMetadata{
-				MaxLimit:  len(prs),
-				FetchedAt: time.Now(),
-				Cursor:    cursor,
-			}
-			if cacheErr := prCache.Set(prsKey, prs); cacheErr != nil {
-				out.Warning("Failed to cache PRs: %v", cacheErr)
-			}
-			if cacheErr := prCache.Set(metadataKey, metadata); cacheErr != nil {
-				out.Warning("Failed to cache metadata: %v", cacheErr)
-			}
 
-			if err != nil {
-				out.Warning("Fetch incomplete due to error: %v", err)
-				out.Info("Using %d PRs fetched before error", len(prs))
-			} else {
-				out.Info("Fetched %d PRs", len(prs))
+		out.Info("Matching PRs to dependencies...")
+		matcher := pr.NewMatcher(branchDeps)
+		branchResults := matcher.MatchAll(prs)
+		results = append(results, branchResults...)
+
+		// Attribute matches to hosts, matching again per host only when
+		// several hosts follow this branch
+		if len(hosts) == 1 {
+			for _, r := range branchResults {
+				prHosts[r.PR.Number] = append(prHosts[r.PR.Number], hosts[0])
 			}
-		} else if err != nil {
-			return fmt.Errorf("failed to fetch PRs: %w", err)
+			continue
 		}
-	}
-
-	// Filter PRs by user if requested
-	if flags.user != "" {
-		var filteredPRs []pr.PullRequest
-		for _, p := range prs {
-			if p.Author == flags.user {
-				filteredPRs = append(filteredPRs, p)
+		for _, host := range hosts {
+			d, ok := allDeps[host]
+			if !ok {
+				continue
+			}
+			for _, r := range pr.NewMatcher(d).MatchAll(prs) {
+				prHosts[r.PR.Number] = append(prHosts[r.PR.Number], host)
 			}
 		}
-		out.Info("Filtered to %d PRs by user @%s", len(filteredPRs), flags.user)
-		prs = filteredPRs
 	}
 
-	// Match PRs to dependencies
-	out.Info("Matching PRs to dependencies...")
-	matcher := pr.NewMatcher(merged)
-	results := matcher.MatchAll(prs)
-
 	// Filter by confidence
 	var filtered []pr.MatchResult
 	for _, result := range results {
@@ -227,18 +265,15 @@ func runWatch(out *output.Writer, flags watchFlags) error {
 
 	out.Info("Found %d matching PRs", len(filtered))
 
-	// Sort results
-	sortResults(filtered, flags.sortBy)
-
-	// Output results
-	switch flags.outputFormat {
-	case "json":
-		return outputJSON(filtered, merged, hostsToAnalyze)
-	case "urls":
-		return outputURLs(os.Stdout, filtered)
-	default:
-		return outputTerminal(out, filtered, merged, hostsToAnalyze, flags)
-	}
+	return &matchRun{
+		results:  filtered,
+		all:      results,
+		deps:     merged,
+		hostDeps: allDeps,
+		flakes:   flakes,
+		hosts:    hostsToAnalyze,
+		prHosts:  prHosts,
+	}, nil
 }
 
 func shouldIncludeByConfidence(result pr.MatchResult, minConfidence string) bool {
@@ -255,27 +290,6 @@ func shouldIncludeByConfidence(result pr.MatchResult, minConfidence string) bool
 	}
 }
 
-func outputJSON(results []pr.MatchResult, deps *deps.Dependencies, hosts []string) error {
-	output := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"timestamp":          time.Now().Format(time.RFC3339),
-			"hosts_analyzed":     hosts,
-			"total_dependencies": len(deps.Packages),
-			"total_modules":      len(deps.Modules),
-			"total_prs_matched":  len(results),
-		},
-		"dependencies": map[string]interface{}{
-			"packages": deps.Packages,
-			"modules":  deps.Modules,
-		},
-		"matches": results,
-	}
-
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(output)
-}
-
 // outputURLs writes PR URLs one per line to the given writer.
 // This format is designed for piping to other tools like lazypr.
 func outputURLs(w io.Writer, results []pr.MatchResult) error {
@@ -285,98 +299,6 @@ func outputURLs(w io.Writer, results []pr.MatchResult) error {
 	return nil
 }
 
-func outputTerminal(out *output.Writer, results []pr.MatchResult, deps *deps.Dependencies, hosts []string, flags watchFlags) error {
-	out.Println("")
-	out.Println("┌─────────────────────────────────────────────────────────────────────────────┐")
-	out.Println("│ NixOS/nixpkgs PRs matching your configuration                              │")
-	out.Println("│ Analyzed: %s (%d packages, %d modules)%s│",
-		formatHosts(hosts),
-		len(deps.Packages),
-		len(deps.Modules),
-		pad(42-len(formatHosts(hosts))-countDigits(len(deps.Packages))-countDigits(len(deps.Modules))))
-	out.Println("│ Found: %d relevant PRs%s│", len(results), pad(57-countDigits(len(results))))
-	out.Println("└─────────────────────────────────────────────────────────────────────────────┘")
-	out.Println("")
-
-	// Group by confidence
-	highConf := []pr.MatchResult{}
-	medConf := []pr.MatchResult{}
-	lowConf := []pr.MatchResult{}
-
-	for _, r := range results {
-		switch r.HighestConfidence() {
-		case "high":
-			highConf = append(highConf, r)
-		case "medium":
-			medConf = append(medConf, r)
-		case "low":
-			lowConf = append(lowConf, r)
-		}
-	}
-
-	if len(highConf) > 0 {
-		out.Success("HIGH CONFIDENCE MATCHES (%d)", len(highConf))
-		out.Println("════════════════════════════════════════════════════════════════════════════════")
-		out.Println("")
-		for _, r := range highConf {
-			printMatch(out, r, flags.compact)
-		}
-	}
-
-	if len(medConf) > 0 {
-		out.Info("MEDIUM CONFIDENCE MATCHES (%d)", len(medConf))
-		out.Println("════════════════════════════════════════════════════════════════════════════════")
-		out.Println("")
-		for _, r := range medConf {
-			printMatch(out, r, flags.compact)
-		}
-	}
-
-	if len(lowConf) > 0 {
-		out.Warning("LOW CONFIDENCE MATCHES (%d)", len(lowConf))
-		out.Println("════════════════════════════════════════════════════════════════════════════════")
-		out.Println("")
-		for _, r := range lowConf {
-			printMatch(out, r, flags.compact)
-		}
-	}
-
-	return nil
-}
-
-func printMatch(out *output.Writer, r pr.MatchResult, compact bool) {
-	// Build status indicators
-	statusIndicators := formatStatusIndicators(r.PR)
-
-	if compact {
-		// Compact mode: 2 lines
-		titleLine := fmt.Sprintf("[#%d] %s (created: %s)", r.PR.Number, r.PR.Title, formatDate(r.PR.CreatedAt))
-		if statusIndicators != "" {
-			titleLine = fmt.Sprintf("%s %s", titleLine, statusIndicators)
-		}
-		out.Success("%s", titleLine)
-		out.Println("  %s by @%s - %s", formatMatches(r.Matches), r.PR.Author, r.PR.URL)
-	} else {
-		// Full mode: include date and all details
-		titleLine := fmt.Sprintf("[#%d] %s", r.PR.Number, r.PR.Title)
-		if statusIndicators != "" {
-			titleLine = fmt.Sprintf("%s %s", titleLine, statusIndicators)
-		}
-		out.Success("%s", titleLine)
-		out.Println("  → Matches: %s", formatMatches(r.Matches))
-		if len(r.PR.Files) > 0 {
-			out.Println("  │ Files: %s", formatFiles(r.PR.Files))
-		}
-		if len(r.PR.Labels) > 0 {
-			out.Println("  │ Labels: %s", formatLabels(r.PR.Labels))
-		}
-		out.Println("  │ Created: %s | Updated: %s", formatDate(r.PR.CreatedAt), formatDate(r.PR.UpdatedAt))
-		out.Println("  │ Author: @%s", r.PR.Author)
-		out.Println("  └ %s", r.PR.URL)
-	}
-	out.Println("")
-}
-
 func formatStatusIndicators(pr pr.PullRequest) string {
 	var indicators []string
 
@@ -408,7 +330,7 @@ func formatMatches(matches []pr.Match) string {
 	}
 	if len(matches) == 1 {
 		icon := "📦"
-		if matches[0].Type == "module" {
+		if matches[0].Type == "module" || matches[0].Type == "service" {
 			icon = "⚙️ "
 		}
 		return fmt.Sprintf("%s %s (%s)", icon, matches[0].Dependency, matches[0].Type)
@@ -417,11 +339,15 @@ func formatMatches(matches []pr.Match) string {
 	// Group by type
 	pkgs := 0
 	mods := 0
+	svcs := 0
 	for _, m := range matches {
-		if m.Type == "module" {
+		switch m.Type {
+		case "module":
 			mods++
-		} else if m.Type == "package" {
+		case "package":
 			pkgs++
+		case "service":
+			svcs++
 		}
 	}
 
@@ -432,6 +358,9 @@ func formatMatches(matches []pr.Match) string {
 	if mods > 0 {
 		parts = append(parts, fmt.Sprintf("%d mod%s", mods, pluralize(mods)))
 	}
+	if svcs > 0 {
+		parts = append(parts, fmt.Sprintf("%d svc%s", svcs, pluralize(svcs)))
+	}
 
 	return fmt.Sprintf("%s (%s)", matches[0].Dependency, strings.Join(parts, ", "))
 }
@@ -493,33 +422,6 @@ func formatDate(t time.Time) string {
 	return t.Format("2006-01-02")
 }
 
-func pad(n int) string {
-	if n <= 0 {
-		return ""
-	}
-	s := ""
-	for i := 0; i < n; i++ {
-		s += " "
-	}
-	return s
-}
-
-func countDigits(n int) int {
-	if n == 0 {
-		return 1
-	}
-	count := 0
-	if n < 0 {
-		count = 1
-		n = -n
-	}
-	for n > 0 {
-		n /= 10
-		count++
-	}
-	return count
-}
-
 // sortByCreatedDesc sorts PRs by creation date descending (newest first)
 func sortByCreatedDesc(prs []pr.PullRequest) {
 	sort.Slice(prs, func(i, j int) bool {
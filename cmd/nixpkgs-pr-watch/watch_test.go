@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"go.sbr.pm/x/internal/deps"
+	"go.sbr.pm/x/internal/output"
 	"go.sbr.pm/x/internal/pr"
 )
 
@@ -91,3 +93,57 @@ func TestOutputURLs_SingleResult(t *testing.T) {
 		t.Errorf("outputURLs() = %q, want %q", got, want)
 	}
 }
+
+func TestReport_Formats(t *testing.T) {
+	results := []pr.MatchResult{
+		{
+			PR: pr.PullRequest{
+				Number: 123,
+				Title:  "foo: 1.0 -> 1.1",
+				Author: "r-ryantm",
+				URL:    "https://github.com/NixOS/nixpkgs/pull/123",
+			},
+			Score: 90,
+			Matches: []pr.Match{
+				{Type: "package", Dependency: "foo", Confidence: "high"},
+				{Type: "title", Dependency: "foo", Confidence: "medium"},
+			},
+		},
+	}
+	r := newReport(results, &deps.Dependencies{}, []string{"host"})
+
+	tests := []struct {
+		name string
+		opts output.FormatOptions
+		want string
+	}{
+		{
+			name: "template",
+			opts: output.FormatOptions{Template: "{{.PR.Number}} {{.PR.Title}}"},
+			want: "123 foo: 1.0 -> 1.1\n",
+		},
+		{
+			name: "urls",
+			opts: output.FormatOptions{Format: "urls"},
+			want: "https://github.com/NixOS/nixpkgs/pull/123\n",
+		},
+		{
+			name: "csv",
+			opts: output.FormatOptions{Format: output.FormatCSV},
+			want: "number,title,author,base,confidence,score,matches,url\n" +
+				"123,foo: 1.0 -> 1.1,r-ryantm,,high,90,foo,https://github.com/NixOS/nixpkgs/pull/123\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := output.Render(&buf, tt.opts, r); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Render() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
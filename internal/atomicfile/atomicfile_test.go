@@ -0,0 +1,50 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	if err := WriteFile(path, []byte("first")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// A failed write keeps the previous content
+	failure := errors.New("failed")
+	err := Write(path, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		// The temporary file is recognized as such
+		if name := filepath.Base(w.(*os.File).Name()); !IsTemp(name) {
+			t.Errorf("IsTemp(%q) = false", name)
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Write() error = %v, want %v", err, failure)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "first" {
+		t.Errorf("content = %q, %v, want %q", data, err, "first")
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, %v, want 0644", info.Mode().Perm(), err)
+	}
+
+	// No temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("files = %v, want only %s", entries, filepath.Base(path))
+	}
+}
@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.sbr.pm/x/internal/atomicfile"
+)
+
+// staleTempAge is the age after which a temporary file is considered left
+// behind by an interrupted write
+const staleTempAge = time.Hour
+
+// Budget bounds the disk usage of a cache.
+// Zero values disable the corresponding limit.
+type Budget struct {
+	// MaxSize is the maximum total size of entries, in bytes. Least recently
+	// used entries are evicted first when it is exceeded.
+	MaxSize int64
+
+	// MaxAge is the maximum time since an entry was last used
+	MaxAge time.Duration
+}
+
+// PruneResult reports what Prune removed
+type PruneResult struct {
+	Removed int
+	Freed   int64
+}
+
+// SetBudget sets the cache budget, enforced after every write and by Prune
+func (c *Cache) SetBudget(b Budget) {
+	c.budget = b
+}
+
+// Budget returns the cache budget
+func (c *Cache) Budget() Budget {
+	return c.budget
+}
+
+// Prune removes expired and corrupted entries, then enforces the budget.
+// Lock files of keys without an entry are removed too.
+func (c *Cache) Prune() (*PruneResult, error) {
+	return c.PruneWithBudget(c.budget)
+}
+
+// PruneWithBudget is like Prune with a budget other than the cache's own
+func (c *Cache) PruneWithBudget(b Budget) (*PruneResult, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PruneResult{}
+	var live []EntryInfo
+	for _, entry := range entries {
+		if !entry.Expired() {
+			live = append(live, entry)
+			continue
+		}
+		removed, err := c.removeInvalid(entry.Key)
+		if err != nil {
+			return result, err
+		}
+		if removed {
+			result.Removed++
+			result.Freed += entry.Size
+		}
+	}
+
+	evicted, err := c.evict(live, b, "")
+	result.Removed += evicted.Removed
+	result.Freed += evicted.Freed
+	if err != nil {
+		return result, err
+	}
+
+	if err := c.removeOrphanLocks(); err != nil {
+		return result, err
+	}
+
+	stale, err := c.removeStaleTemps()
+	result.Removed += stale.Removed
+	result.Freed += stale.Freed
+	return result, err
+}
+
+// removeStaleTemps removes temporary files left behind by writes that were
+// interrupted, old enough not to belong to a write still in flight
+func (c *Cache) removeStaleTemps() (*PruneResult, error) {
+	result := &PruneResult{}
+	files, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		return result, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !atomicfile.IsTemp(file.Name()) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil || time.Since(info.ModTime()) < staleTempAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.baseDir, file.Name())); err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+		result.Removed++
+		result.Freed += info.Size()
+	}
+	return result, nil
+}
+
+// enforceBudget evicts entries exceeding the cache budget, never evicting
+// keep (the entry that was just written). It only looks at file metadata,
+// so it is cheap enough to run after every write.
+func (c *Cache) enforceBudget(keep string) error {
+	if c.budget.MaxSize <= 0 && c.budget.MaxAge <= 0 {
+		return nil
+	}
+
+	files, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		return err
+	}
+
+	var entries []EntryInfo
+	for _, file := range files {
+		if !isEntryFile(file) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, EntryInfo{
+			Key:        keyOf(file.Name()),
+			Size:       info.Size(),
+			AccessedAt: info.ModTime(),
+		})
+	}
+
+	_, err = c.evict(entries, c.budget, keep)
+	return err
+}
+
+// evict removes entries unused for longer than the budget's MaxAge, then
+// the least recently used entries until the total size fits in MaxSize
+func (c *Cache) evict(entries []EntryInfo, b Budget, keep string) (*PruneResult, error) {
+	result := &PruneResult{}
+
+	// Least recently used first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AccessedAt.Before(entries[j].AccessedAt)
+	})
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	for _, entry := range entries {
+		if entry.Key == keep {
+			continue
+		}
+		tooOld := b.MaxAge > 0 && time.Since(entry.AccessedAt) > b.MaxAge
+		tooBig := b.MaxSize > 0 && total > b.MaxSize
+		if !tooOld && !tooBig {
+			continue
+		}
+
+		if err := c.Delete(entry.Key); err != nil {
+			return result, err
+		}
+		total -= entry.Size
+		result.Removed++
+		result.Freed += entry.Size
+	}
+
+	return result, nil
+}
+
+// touch marks the entry for key as recently used, for LRU eviction
+func (c *Cache) touch(key string) {
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+}
+
+// keyOf returns the key of a cache entry file name
+func keyOf(name string) string {
+	return strings.TrimSuffix(name, entryExt)
+}
@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCache creates a cache in a temporary directory
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	c, err := New(1*time.Hour, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// setAccessed sets the last access time of an entry
+func setAccessed(t *testing.T, c *Cache, key string, at time.Time) {
+	t.Helper()
+	if err := os.Chtimes(c.path(key), at, at); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+}
+
+func keys(t *testing.T, c *Cache) []string {
+	t.Helper()
+	entries, err := c.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var keys []string
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+// randomValue returns an incompressible value, so entry sizes are
+// predictable
+func randomValue(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 1000)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("rand.Read() error = %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func TestCache_BudgetMaxSize(t *testing.T) {
+	c := newTestCache(t)
+	value := randomValue(t)
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, value); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	now := time.Now()
+	setAccessed(t, c, "a", now.Add(-3*time.Hour))
+	setAccessed(t, c, "b", now.Add(-2*time.Hour))
+	setAccessed(t, c, "c", now.Add(-1*time.Hour))
+
+	// Reading "a" makes "b" the least recently used entry
+	var got string
+	if err := c.Get("a", &got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	// Room for three entries, with some slack for small size differences
+	entries, _ := c.List()
+	c.SetBudget(Budget{MaxSize: 3*entries[0].Size + entries[0].Size/2})
+
+	if err := c.Set("d", value); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if got, want := keys(t, c), []string{"a", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys after eviction = %v, want %v", got, want)
+	}
+}
+
+func TestCache_BudgetKeepsNewEntry(t *testing.T) {
+	c := newTestCache(t)
+	c.SetBudget(Budget{MaxSize: 10})
+
+	if err := c.Set("big", strings.Repeat("x", 1000)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if got, want := keys(t, c), []string{"big"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	c := newTestCache(t)
+
+	if err := c.Set("fresh", "value"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set("unused", "value"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.SetWithTTL("expired", "value", time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTL() error = %v", err)
+	}
+	if err := c.SetWithTTL("forever", "value", NoExpiry); err != nil {
+		t.Fatalf("SetWithTTL() error = %v", err)
+	}
+	if err := os.WriteFile(c.path("corrupted"), []byte("{"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	setAccessed(t, c, "unused", time.Now().Add(-48*time.Hour))
+	time.Sleep(10 * time.Millisecond)
+
+	c.SetBudget(Budget{MaxAge: 24 * time.Hour})
+	result, err := c.Prune()
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.Removed != 3 {
+		t.Errorf("Prune() removed %d entries, want 3", result.Removed)
+	}
+	if result.Freed <= 0 {
+		t.Errorf("Prune() freed %d bytes, want > 0", result.Freed)
+	}
+
+	if got, want := keys(t, c), []string{"forever", "fresh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys after Prune() = %v, want %v", got, want)
+	}
+}
+
+func TestCache_PruneStaleTemps(t *testing.T) {
+	c := newTestCache(t)
+
+	stale := filepath.Join(c.baseDir, ".deps-kyushu.json.gz.123.tmp")
+	inFlight := filepath.Join(c.baseDir, ".deps-kyushu.json.gz.456.tmp")
+	for _, path := range []string{stale, inFlight} {
+		if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * staleTempAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	result, err := c.Prune()
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.Removed != 1 || result.Freed != int64(len("partial")) {
+		t.Errorf("Prune() = %+v, want 1 removed file", result)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale temporary file not removed: %v", err)
+	}
+	if _, err := os.Stat(inFlight); err != nil {
+		t.Errorf("recent temporary file removed: %v", err)
+	}
+}
+
+func TestCache_InfoPrefixes(t *testing.T) {
+	c := newTestCache(t)
+
+	for _, key := range []string{"deps-host1-abc", "deps-host2-abc", "prs-master-data", "templates"} {
+		if err := c.Set(key, "value"); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if err := c.SetWithTTL("prs-staging-data", "value", time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTL() error = %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	info, err := c.Info()
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if info.EntryCount != 5 || info.ExpiredCount != 1 {
+		t.Errorf("Info() = %d entries, %d expired, want 5, 1", info.EntryCount, info.ExpiredCount)
+	}
+
+	var got []PrefixInfo
+	for _, p := range info.Prefixes {
+		p.TotalSize = 0
+		got = append(got, p)
+	}
+	want := []PrefixInfo{
+		{Prefix: "deps", EntryCount: 2},
+		{Prefix: "prs", EntryCount: 2, ExpiredCount: 1},
+		{Prefix: "templates", EntryCount: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Info().Prefixes = %+v, want %+v", got, want)
+	}
+}
+
+func TestCache_RemoveMatching(t *testing.T) {
+	c := newTestCache(t)
+
+	for _, key := range []string{"deps-host1-abc", "deps-host2-abc", "prs-master-data"} {
+		if err := c.Set(key, "value"); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+
+	removed, err := c.RemoveMatching("deps-*")
+	if err != nil {
+		t.Fatalf("RemoveMatching() error = %v", err)
+	}
+	if want := []string{"deps-host1-abc", "deps-host2-abc"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("RemoveMatching() = %v, want %v", removed, want)
+	}
+	if got, want := keys(t, c), []string{"prs-master-data"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys after RemoveMatching() = %v, want %v", got, want)
+	}
+
+	if _, err := c.RemoveMatching("["); err == nil {
+		t.Error("RemoveMatching() with invalid pattern should fail")
+	}
+}
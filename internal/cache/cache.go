@@ -1,45 +1,113 @@
 package cache
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"go.sbr.pm/x/internal/atomicfile"
+	"go.sbr.pm/x/internal/paths"
 )
 
 const (
 	// DefaultTTL is the default time-to-live for cache entries (24 hours)
 	DefaultTTL = 24 * time.Hour
+
+	// NoExpiry can be passed as TTL for entries that never expire.
+	// Use it for data keyed by a content hash, where a changed input
+	// produces a different key instead of a stale entry.
+	NoExpiry time.Duration = -1
+
+	// entryExt is the file extension of cache entries
+	entryExt = ".json.gz"
+
+	// legacyExt is the file extension of uncompressed entries written by
+	// earlier versions, removed when the cache is opened
+	legacyExt = ".json"
+
+	// locksDir is the subdirectory holding per-key lock files
+	locksDir = ".locks"
+
+	// versionFile records the entry format of the cache directory, so
+	// entries of earlier formats are only looked for once
+	versionFile = ".version"
 )
 
-// Entry represents a cached item with expiration
-type Entry struct {
-	Data      interface{} `json:"data"`
-	ExpiresAt time.Time   `json:"expires_at"`
-}
+// ErrMiss is returned by Lookup and Get when no entry exists for a key
+var ErrMiss = errors.New("cache miss")
 
-// Cache handles caching of data with TTL support
+// Cache handles caching of data with TTL support.
+//
+// Each entry carries its own expiration: Set uses the cache's default TTL
+// and SetWithTTL overrides it, so data with different lifetimes can share a
+// cache. Expired entries are misses for Get, but Lookup still returns them
+// flagged as stale, so callers can show them while refreshing.
+//
+// An optional Budget bounds the cache size and the age of unused entries,
+// evicting the least recently used entries first.
+//
+// Entries are written to a temporary file and renamed into place, and each
+// key is guarded by an advisory file lock, so several processes can share
+// the same cache directory. Corrupted entries are treated as misses and
+// removed. Entries stored with a different type than the one they are read
+// into (see Entry) are misses too, but are kept for readers of their type.
 type Cache struct {
 	baseDir string
 	ttl     time.Duration
+	budget  Budget
 }
 
 // Info contains cache statistics
 type Info struct {
-	Directory  string
-	EntryCount int
-	TotalSize  int64
+	Directory    string
+	EntryCount   int
+	ExpiredCount int
+	TotalSize    int64
+	Prefixes     []PrefixInfo
+}
+
+// PrefixInfo contains statistics for entries sharing a key prefix, the
+// part of the key before the first "-" (e.g. "deps" for "deps-host-abc")
+type PrefixInfo struct {
+	Prefix       string
+	EntryCount   int
+	ExpiredCount int
+	TotalSize    int64
+}
+
+// EntryInfo describes a cache entry.
+// Corrupted entries are reported as expired.
+type EntryInfo struct {
+	Key        string
+	Size       int64
+	StoredAt   time.Time
+	ExpiresAt  time.Time
+	AccessedAt time.Time // Last read or write, used for LRU eviction
+	corrupted  bool
+}
+
+// Expired reports whether the entry is expired or corrupted
+func (e EntryInfo) Expired() bool {
+	return e.corrupted || (!e.ExpiresAt.IsZero() && time.Now().After(e.ExpiresAt))
 }
 
-// New creates a new Cache instance
+// New creates a new Cache instance in the cache directory of the named tool
+// (see paths.CacheDir)
 func New(ttl time.Duration, name string) (*Cache, error) {
-	homeDir, err := os.UserHomeDir()
+	baseDir, err := paths.CacheDir(name)
 	if err != nil {
 		return nil, err
 	}
 
-	baseDir := filepath.Join(homeDir, ".cache", name)
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(baseDir, locksDir), 0755); err != nil {
 		return nil, err
 	}
 
@@ -47,70 +115,222 @@ func New(ttl time.Duration, name string) (*Cache, error) {
 		ttl = DefaultTTL
 	}
 
-	return &Cache{
+	c := &Cache{
 		baseDir: baseDir,
 		ttl:     ttl,
-	}, nil
+	}
+	c.removeLegacy()
+
+	return c, nil
+}
+
+// removeLegacy removes entries in the uncompressed format of earlier
+// versions, which are never read again. It only runs once per cache
+// directory, recorded in its version file.
+func (c *Cache) removeLegacy() {
+	versionPath := filepath.Join(c.baseDir, versionFile)
+	version := strconv.Itoa(formatVersion)
+	if data, err := os.ReadFile(versionPath); err == nil && strings.TrimSpace(string(data)) == version {
+		return
+	}
+
+	files, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, legacyExt) {
+			continue
+		}
+		_ = os.Remove(filepath.Join(c.baseDir, name))
+	}
+
+	_ = atomicfile.Write(versionPath, func(w io.Writer) error {
+		_, err := io.WriteString(w, version+"\n")
+		return err
+	})
+}
+
+// path returns the file path of the entry for key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.baseDir, key+entryExt)
 }
 
 // Get retrieves a value from cache
 // Returns nil if not found or expired
 func (c *Cache) Get(key string, dest interface{}) error {
-	filePath := filepath.Join(c.baseDir, key+".json")
+	_, err := c.read(key, dest, false)
+	if errors.Is(err, ErrMiss) {
+		return nil
+	}
+	return err
+}
 
-	data, err := os.ReadFile(filePath)
+// Lookup retrieves a value from cache even if it has expired.
+// It returns the age of the entry and whether it is stale (expired), or
+// ErrMiss if there is no usable entry for key.
+func Lookup[T any](c *Cache, key string) (value T, age time.Duration, stale bool, err error) {
+	entry, err := c.lookup(key, &value)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
+		return value, 0, false, err
+	}
+	return value, time.Since(entry.StoredAt), entry.expired(), nil
+}
+
+// Get retrieves a fresh value from cache.
+// It returns ErrMiss if there is no entry for key or if it has expired.
+func Get[T any](c *Cache, key string) (T, error) {
+	value, _, stale, err := Lookup[T](c, key)
+	if err != nil {
+		return value, err
+	}
+	if stale {
+		var zero T
+		return zero, ErrMiss
+	}
+	return value, nil
+}
+
+// lookup decodes the entry for key into dest, even if it has expired
+func (c *Cache) lookup(key string, dest interface{}) (*Entry, error) {
+	return c.read(key, dest, true)
+}
+
+// readStatus is the outcome of reading an entry
+type readStatus int
+
+const (
+	readOK       readStatus = iota
+	readInvalid             // Corrupted, or expired when stale entries aren't wanted
+	readMismatch            // Stored with another schema than the destination's
+)
+
+// read decodes the entry for key into dest under a shared lock. Expired
+// entries are returned only if stale is true, and are otherwise removed
+// like corrupted entries. Entries stored with another schema are misses,
+// but are kept. dest is only modified when the entry is returned.
+func (c *Cache) read(key string, dest interface{}, stale bool) (*Entry, error) {
+	entry, status, err := c.readLocked(key, dest, stale)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case readInvalid:
+		if _, err := c.removeInvalid(key); err != nil {
+			return nil, err
 		}
-		return err
+		return nil, ErrMiss
+	case readMismatch:
+		return nil, ErrMiss
 	}
 
-	var entry Entry
-	if err := json.Unmarshal(data, &entry); err != nil {
-		return err
+	c.touch(key)
+	return entry, nil
+}
+
+// readLocked does the actual read for read, reporting the status of the
+// entry
+func (c *Cache) readLocked(key string, dest interface{}, stale bool) (*Entry, readStatus, error) {
+	unlock, err := c.lock(key, false)
+	if err != nil {
+		return nil, readInvalid, err
 	}
+	defer unlock()
 
-	// Check if expired
-	if time.Now().After(entry.ExpiresAt) {
-		// Clean up expired entry
-		_ = os.Remove(filePath)
-		return nil
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, readInvalid, ErrMiss
+		}
+		return nil, readInvalid, err
 	}
+	defer f.Close()
 
-	// Unmarshal the data into the destination
-	dataBytes, err := json.Marshal(entry.Data)
+	er, err := readEntry(f)
 	if err != nil {
-		return err
+		return nil, readInvalid, nil
+	}
+	defer er.Close()
+
+	if !stale && er.expired() {
+		return nil, readInvalid, nil
 	}
 
-	return json.Unmarshal(dataBytes, dest)
+	// Decode into a new value, so a failed decode leaves dest untouched
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Pointer || destValue.IsNil() {
+		return nil, readInvalid, fmt.Errorf("cache destination must be a non-nil pointer, got %T", dest)
+	}
+	tmp := reflect.New(destValue.Type().Elem())
+	if err := er.decode(tmp.Interface()); err != nil {
+		if errors.Is(err, errSchemaMismatch) {
+			return nil, readMismatch, nil
+		}
+		return nil, readInvalid, nil
+	}
+	destValue.Elem().Set(tmp.Elem())
+	return &er.Entry, readOK, nil
 }
 
 // Set stores a value in cache with the configured TTL
 func (c *Cache) Set(key string, value interface{}) error {
+	return c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL stores a value in cache with its own TTL.
+// A zero TTL uses the cache's default, and NoExpiry stores an entry that
+// never expires.
+func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+
+	now := time.Now()
 	entry := Entry{
-		Data:      value,
-		ExpiresAt: time.Now().Add(c.ttl),
+		Format:   formatVersion,
+		Schema:   schemaOf(value),
+		StoredAt: now,
 	}
+	if ttl > 0 {
+		entry.ExpiresAt = now.Add(ttl)
+	}
+
+	if err := c.write(key, entry, value); err != nil {
+		return err
+	}
+
+	// Eviction is best-effort, the entry itself was stored
+	_ = c.enforceBudget(key)
+	return nil
+}
 
-	data, err := json.Marshal(entry)
+// write stores an entry for key under an exclusive lock
+func (c *Cache) write(key string, entry Entry, value interface{}) error {
+	unlock, err := c.lock(key, true)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	filePath := filepath.Join(c.baseDir, key+".json")
-	return os.WriteFile(filePath, data, 0644)
+	return atomicfile.Write(c.path(key), func(w io.Writer) error {
+		return encodeEntry(w, entry, value)
+	})
 }
 
 // Delete removes an entry from cache
 func (c *Cache) Delete(key string) error {
-	filePath := filepath.Join(c.baseDir, key+".json")
-	err := os.Remove(filePath)
-	if os.IsNotExist(err) {
-		return nil
+	unlock, err := c.lock(key, true)
+	if err != nil {
+		return err
 	}
-	return err
+	defer unlock()
+
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	c.removeLock(key)
+	return nil
 }
 
 // Clear removes all cache entries
@@ -123,35 +343,282 @@ func (c *Cache) Clear() error {
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			filePath := filepath.Join(c.baseDir, entry.Name())
-			if err := os.Remove(filePath); err != nil {
+			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 				return err
 			}
 		}
 	}
 
-	return nil
+	return c.removeOrphanLocks()
 }
 
 // Info returns cache statistics
 func (c *Cache) Info() (*Info, error) {
-	entries, err := os.ReadDir(c.baseDir)
+	entries, err := c.List()
 	if err != nil {
 		return nil, err
 	}
 
-	var totalSize int64
+	info := &Info{Directory: c.baseDir}
+	prefixes := make(map[string]*PrefixInfo)
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			info, err := entry.Info()
-			if err == nil {
-				totalSize += info.Size()
+		prefix := keyPrefix(entry.Key)
+		p, ok := prefixes[prefix]
+		if !ok {
+			p = &PrefixInfo{Prefix: prefix}
+			prefixes[prefix] = p
+		}
+
+		info.EntryCount++
+		info.TotalSize += entry.Size
+		p.EntryCount++
+		p.TotalSize += entry.Size
+		if entry.Expired() {
+			info.ExpiredCount++
+			p.ExpiredCount++
+		}
+	}
+
+	for _, p := range prefixes {
+		info.Prefixes = append(info.Prefixes, *p)
+	}
+	sort.Slice(info.Prefixes, func(i, j int) bool {
+		return info.Prefixes[i].Prefix < info.Prefixes[j].Prefix
+	})
+
+	return info, nil
+}
+
+// List returns all cache entries, sorted by key
+func (c *Cache) List() ([]EntryInfo, error) {
+	files, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []EntryInfo
+	for _, file := range files {
+		if !isEntryFile(file) {
+			continue
+		}
+		stat, err := file.Info()
+		if err != nil {
+			// Removed in the meantime
+			continue
+		}
+
+		key := keyOf(file.Name())
+		info := EntryInfo{
+			Key:        key,
+			Size:       stat.Size(),
+			AccessedAt: stat.ModTime(),
+		}
+
+		if entry, err := c.readHeader(key); err != nil {
+			if os.IsNotExist(err) {
+				continue
 			}
+			info.corrupted = true
+		} else {
+			info.StoredAt = entry.StoredAt
+			info.ExpiresAt = entry.ExpiresAt
 		}
+
+		entries = append(entries, info)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries, nil
+}
+
+// RemoveMatching removes entries whose key matches the glob pattern (see
+// path.Match) and returns the removed keys
+func (c *Cache) RemoveMatching(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
 	}
 
-	return &Info{
-		Directory:  c.baseDir,
-		EntryCount: len(entries),
-		TotalSize:  totalSize,
-	}, nil
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		if ok, _ := path.Match(pattern, entry.Key); !ok {
+			continue
+		}
+		if err := c.Delete(entry.Key); err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry.Key)
+	}
+	return removed, nil
+}
+
+// keyPrefix returns the part of key before the first "-"
+func keyPrefix(key string) string {
+	prefix, _, _ := strings.Cut(key, "-")
+	return prefix
+}
+
+// isEntryFile reports whether a directory entry is a cache entry, as
+// opposed to the locks directory or an in-flight temporary file
+func isEntryFile(entry os.DirEntry) bool {
+	name := entry.Name()
+	return !entry.IsDir() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, entryExt)
+}
+
+// readHeader reads the header of the entry for key under a shared lock,
+// without decoding its data
+func (c *Cache) readHeader(key string) (*Entry, error) {
+	unlock, err := c.lock(key, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	er, err := readEntry(f)
+	if err != nil {
+		return nil, err
+	}
+	defer er.Close()
+	return &er.Entry, nil
+}
+
+// removeInvalid removes the entry for key if it is still corrupted or
+// expired once the exclusive lock is held, reporting whether it did.
+// Another process may have replaced it with a fresh entry in the meantime.
+func (c *Cache) removeInvalid(key string) (bool, error) {
+	unlock, err := c.lock(key, true)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	valid := entryValid(f)
+	f.Close()
+	if valid {
+		return false, nil
+	}
+
+	if err := os.Remove(c.path(key)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	c.removeLock(key)
+	return true, nil
+}
+
+// entryValid reports whether the entry read from r is well-formed and not
+// expired
+func entryValid(r io.Reader) bool {
+	er, err := readEntry(r)
+	if err != nil {
+		return false
+	}
+	defer er.Close()
+
+	if er.expired() {
+		return false
+	}
+	return er.verify() == nil
+}
+
+// lockPath returns the path of the lock file of key
+func (c *Cache) lockPath(key string) string {
+	return filepath.Join(c.baseDir, locksDir, key+".lock")
+}
+
+// lock takes an advisory lock on key and returns a function releasing it.
+//
+// Lock files are removed together with their entry, while holding the
+// exclusive lock. A process that was waiting on a removed lock file would
+// then hold a lock nobody else sees, so it retries until the file it locked
+// is still the one in place.
+func (c *Cache) lock(key string, exclusive bool) (func(), error) {
+	lockPath := c.lockPath(key)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := flock(f, exclusive); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		locked, err := f.Stat()
+		if err != nil {
+			_ = funlock(f)
+			f.Close()
+			return nil, err
+		}
+		current, err := os.Stat(lockPath)
+		if err == nil && os.SameFile(locked, current) {
+			return func() {
+				_ = funlock(f)
+				f.Close()
+			}, nil
+		}
+
+		_ = funlock(f)
+		f.Close()
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+}
+
+// removeLock removes the lock file of key. It must be called while holding
+// the exclusive lock, once the entry is gone.
+func (c *Cache) removeLock(key string) {
+	_ = os.Remove(c.lockPath(key))
+}
+
+// removeOrphanLocks removes the lock files of keys without an entry, left
+// behind by entries removed without going through Delete (e.g. by Clear)
+func (c *Cache) removeOrphanLocks() error {
+	files, err := os.ReadDir(filepath.Join(c.baseDir, locksDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range files {
+		key, ok := strings.CutSuffix(file.Name(), ".lock")
+		if !ok || file.IsDir() {
+			continue
+		}
+
+		unlock, err := c.lock(key, true)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(c.path(key)); os.IsNotExist(err) {
+			c.removeLock(key)
+		}
+		unlock()
+	}
+	return nil
 }
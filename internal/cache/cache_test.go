@@ -2,7 +2,6 @@ package cache
 
 import (
 	"os"
-	"path/filepath"
 	"testing"
 	"time"
 )
@@ -10,9 +9,7 @@ import (
 func TestCache_SetAndGet(t *testing.T) {
 	// Create temp directory for test cache
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	c, err := New(1*time.Hour, "test-cache")
 	if err != nil {
@@ -74,9 +71,7 @@ func TestCache_SetAndGet(t *testing.T) {
 
 func TestCache_GetNonExistent(t *testing.T) {
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	c, err := New(1*time.Hour, "test-cache")
 	if err != nil {
@@ -98,9 +93,7 @@ func TestCache_GetNonExistent(t *testing.T) {
 
 func TestCache_Expiration(t *testing.T) {
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	// Create cache with very short TTL
 	c, err := New(50*time.Millisecond, "test-cache")
@@ -138,7 +131,7 @@ func TestCache_Expiration(t *testing.T) {
 	}
 
 	// Verify cache file was cleaned up
-	cacheFile := filepath.Join(c.baseDir, "expire-test.json")
+	cacheFile := c.path("expire-test")
 	if _, err := os.Stat(cacheFile); !os.IsNotExist(err) {
 		t.Errorf("Expired cache file still exists")
 	}
@@ -146,9 +139,7 @@ func TestCache_Expiration(t *testing.T) {
 
 func TestCache_Delete(t *testing.T) {
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	c, err := New(1*time.Hour, "test-cache")
 	if err != nil {
@@ -186,9 +177,7 @@ func TestCache_Delete(t *testing.T) {
 
 func TestCache_Clear(t *testing.T) {
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	c, err := New(1*time.Hour, "test-cache")
 	if err != nil {
@@ -225,9 +214,7 @@ func TestCache_Clear(t *testing.T) {
 
 func TestCache_Info(t *testing.T) {
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	c, err := New(1*time.Hour, "test-cache")
 	if err != nil {
@@ -271,9 +258,7 @@ func TestCache_Info(t *testing.T) {
 
 func TestNew_DefaultTTL(t *testing.T) {
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	// Create with zero TTL should use default
 	c, err := New(0, "test-cache")
@@ -288,9 +273,7 @@ func TestNew_DefaultTTL(t *testing.T) {
 
 func TestCache_ConcurrentAccess(t *testing.T) {
 	tmpDir := t.TempDir()
-	oldHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", oldHome)
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
 
 	c, err := New(1*time.Hour, "test-cache")
 	if err != nil {
@@ -318,3 +301,155 @@ func TestCache_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Get() after concurrent writes error = %v", err)
 	}
 }
+
+func TestCache_NoExpiry(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	c, err := New(NoExpiry, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := c.Set("pinned", "value"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	entry, err := c.readHeader("pinned")
+	if err != nil {
+		t.Fatalf("readHeader() error = %v", err)
+	}
+	if !entry.ExpiresAt.IsZero() {
+		t.Errorf("entry without expiry should not store expires_at, got %v", entry.ExpiresAt)
+	}
+
+	var got string
+	if err := c.Get("pinned", &got); err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+	if got != "value" {
+		t.Errorf("Get() = %q, want %q", got, "value")
+	}
+}
+
+func TestCache_SetWithTTL(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	c, err := New(1*time.Hour, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := c.SetWithTTL("short", "value", 50*time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTL() error = %v", err)
+	}
+	if err := c.Set("default", "value"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	var got string
+	if err := c.Get("short", &got); err != nil || got != "" {
+		t.Errorf("Get() on expired per-entry TTL = %q, %v, want miss", got, err)
+	}
+	if err := c.Get("default", &got); err != nil || got != "value" {
+		t.Errorf("Get() on default TTL = %q, %v, want %q", got, err, "value")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	c, err := New(1*time.Hour, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	type testData struct {
+		Name  string
+		Value int
+	}
+
+	// Miss
+	if _, _, _, err := Lookup[testData](c, "missing"); err != ErrMiss {
+		t.Errorf("Lookup() on missing key error = %v, want ErrMiss", err)
+	}
+
+	// Fresh hit
+	want := testData{Name: "test", Value: 42}
+	if err := c.Set("fresh", want); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, age, stale, err := Lookup[testData](c, "fresh")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Lookup() = %+v, want %+v", got, want)
+	}
+	if stale {
+		t.Error("Lookup() on fresh entry reported stale")
+	}
+	if age < 0 || age > time.Minute {
+		t.Errorf("Lookup() age = %v, want small positive duration", age)
+	}
+
+	// Stale hit is returned and kept on disk
+	if err := c.SetWithTTL("stale", want, 10*time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTL() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	got, age, stale, err = Lookup[testData](c, "stale")
+	if err != nil {
+		t.Fatalf("Lookup() on stale entry error = %v", err)
+	}
+	if !stale {
+		t.Error("Lookup() on expired entry should report stale")
+	}
+	if got != want {
+		t.Errorf("Lookup() on stale entry = %+v, want %+v", got, want)
+	}
+	if age < 50*time.Millisecond {
+		t.Errorf("Lookup() age = %v, want >= 50ms", age)
+	}
+	if _, err := os.Stat(c.path("stale")); err != nil {
+		t.Errorf("Lookup() should not remove stale entries: %v", err)
+	}
+}
+
+func TestGet_Generic(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	c, err := New(1*time.Hour, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := Get[[]string](c, "missing"); err != ErrMiss {
+		t.Errorf("Get() on missing key error = %v, want ErrMiss", err)
+	}
+
+	if err := c.Set("list", []string{"a", "b"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, err := Get[[]string](c, "list")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Get() = %v, want [a b]", got)
+	}
+
+	if err := c.SetWithTTL("expired", []string{"old"}, 10*time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTL() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, err := Get[[]string](c, "expired"); err != ErrMiss || got != nil {
+		t.Errorf("Get() on expired entry = %v, %v, want nil, ErrMiss", got, err)
+	}
+}
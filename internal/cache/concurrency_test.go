@@ -0,0 +1,260 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// payload is large enough that a torn write would be noticed
+type payload struct {
+	Writer int    `json:"writer"`
+	Data   string `json:"data"`
+	Length int    `json:"length"`
+}
+
+func newPayload(writer, i int) payload {
+	data := strings.Repeat(fmt.Sprintf("%d-%d;", writer, i), 2000)
+	return payload{Writer: writer, Data: data, Length: len(data)}
+}
+
+// hammer writes and reads the same key repeatedly, failing on any error or
+// inconsistent value
+func hammer(c *Cache, writer, iterations int) error {
+	for i := 0; i < iterations; i++ {
+		if err := c.Set("shared", newPayload(writer, i)); err != nil {
+			return fmt.Errorf("Set() error = %w", err)
+		}
+
+		var got payload
+		if err := c.Get("shared", &got); err != nil {
+			return fmt.Errorf("Get() error = %w", err)
+		}
+		if got.Length != len(got.Data) {
+			return fmt.Errorf("Get() returned torn entry: length %d, data %d bytes", got.Length, len(got.Data))
+		}
+	}
+	return nil
+}
+
+func TestCache_ConcurrentGoroutines(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	c, err := New(1*time.Hour, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			if err := hammer(c, writer, 50); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	// Expired-entry cleanup racing with writers must not remove fresh data
+	short, err := New(time.Nanosecond, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			var got payload
+			if err := short.Get("shared", &got); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	assertNoTempFiles(t, c.baseDir)
+}
+
+func TestCache_ConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping multi-process test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	var cmds []*exec.Cmd
+	for i := 0; i < 4; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCacheHelperProcess$")
+		cmd.Env = append(os.Environ(),
+			"CACHE_HELPER_PROCESS=1",
+			fmt.Sprintf("CACHE_HELPER_WRITER=%d", i),
+			"TEST_CACHE_CACHE_DIR="+filepath.Join(tmpDir, "test-cache"),
+		)
+		var output strings.Builder
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("failed to start helper process: %v", err)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("helper process %d failed: %v: %s", i, err, cmd.Stdout)
+		}
+	}
+
+	assertNoTempFiles(t, filepath.Join(tmpDir, "test-cache"))
+}
+
+// TestCacheHelperProcess is run as a subprocess by TestCache_ConcurrentProcesses
+func TestCacheHelperProcess(t *testing.T) {
+	if os.Getenv("CACHE_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	var writer int
+	fmt.Sscanf(os.Getenv("CACHE_HELPER_WRITER"), "%d", &writer)
+
+	c, err := New(1*time.Hour, "test-cache")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "New() error = %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := hammer(c, writer, 100); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestCache_CorruptedEntry(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	c, err := New(1*time.Hour, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	cacheFile := c.path("corrupted")
+	if err := os.WriteFile(cacheFile, []byte(`{"data": {"name": "trunc`), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	got := "unchanged"
+	if err := c.Get("corrupted", &got); err != nil {
+		t.Errorf("Get() on corrupted entry error = %v, want nil", err)
+	}
+	if got != "unchanged" {
+		t.Errorf("Get() on corrupted entry modified destination: %q", got)
+	}
+	if _, err := os.Stat(cacheFile); !os.IsNotExist(err) {
+		t.Errorf("Corrupted cache file still exists")
+	}
+
+	// The key is usable again afterwards
+	if err := c.Set("corrupted", "fresh"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Get("corrupted", &got); err != nil || got != "fresh" {
+		t.Errorf("Get() after repair = %q, %v, want %q", got, err, "fresh")
+	}
+}
+
+func TestCache_RemovesLockFiles(t *testing.T) {
+	c := newTestCache(t)
+
+	for _, key := range []string{"deleted", "matched-1", "matched-2", "cleared"} {
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+
+	if err := c.Delete("deleted"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := c.RemoveMatching("matched-*"); err != nil {
+		t.Fatalf("RemoveMatching() error = %v", err)
+	}
+	assertLocks(t, c, "cleared")
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	assertLocks(t, c)
+
+	// Deleting while other goroutines use the key must not break locking
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(writer int) {
+			defer wg.Done()
+			if err := hammer(c, writer, 50); err != nil {
+				errs <- err
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := c.Delete("shared"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// assertLocks checks that the cache has lock files for keys only
+func assertLocks(t *testing.T, c *Cache, keys ...string) {
+	t.Helper()
+
+	files, err := os.ReadDir(filepath.Join(c.baseDir, locksDir))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var got []string
+	for _, file := range files {
+		got = append(got, strings.TrimSuffix(file.Name(), ".lock"))
+	}
+	if strings.Join(got, ",") != strings.Join(keys, ",") {
+		t.Errorf("lock files = %v, want %v", got, keys)
+	}
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temporary file left behind: %s", entry.Name())
+		}
+	}
+}
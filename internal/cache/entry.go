@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// formatVersion is the version of the on-disk entry format. Entries with
+// another version are treated as corrupted.
+const formatVersion = 2
+
+// errSchemaMismatch is returned when an entry was stored with a different
+// type than the one it is decoded into
+var errSchemaMismatch = errors.New("cache entry schema mismatch")
+
+// Entry is the header of a cached item.
+// A zero ExpiresAt means the entry never expires.
+//
+// On disk, an entry is a gzip stream holding the JSON-encoded header
+// followed by the JSON-encoded data, so the header can be read without
+// decoding the data, and the data decoded straight into its destination.
+type Entry struct {
+	Format    int       `json:"format"`
+	Schema    string    `json:"schema"`
+	StoredAt  time.Time `json:"stored_at,omitzero"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// expired reports whether the entry is past its expiration time
+func (e *Entry) expired() bool {
+	return !e.ExpiresAt.IsZero() && time.Now().After(e.ExpiresAt)
+}
+
+// encodeEntry writes entry and value to w
+func encodeEntry(w io.Writer, entry Entry, value interface{}) error {
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	if err := enc.Encode(entry); err != nil {
+		return err
+	}
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// entryReader reads an entry from disk: the header first, then the data
+type entryReader struct {
+	Entry
+	zr  *gzip.Reader
+	dec *json.Decoder
+}
+
+// readEntry reads the entry header from r, failing on corrupted data or an
+// unsupported format
+func readEntry(r io.Reader) (*entryReader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	er := &entryReader{zr: zr, dec: json.NewDecoder(zr)}
+	if err := er.dec.Decode(&er.Entry); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	if er.Format != formatVersion {
+		zr.Close()
+		return nil, fmt.Errorf("unsupported cache entry format %d", er.Format)
+	}
+	return er, nil
+}
+
+// decode streams the entry data into dest. It fails with errSchemaMismatch
+// if dest's type doesn't match the stored schema.
+func (er *entryReader) decode(dest interface{}) error {
+	if schema := schemaOfDest(dest); schema != "" && schema != er.Schema {
+		return errSchemaMismatch
+	}
+	return er.dec.Decode(dest)
+}
+
+// verify checks that the entry data is well-formed
+func (er *entryReader) verify() error {
+	var raw json.RawMessage
+	return er.dec.Decode(&raw)
+}
+
+// Close releases the decompressor
+func (er *entryReader) Close() error {
+	return er.zr.Close()
+}
+
+// schemaOf returns a fingerprint of the type of value, which changes when
+// the structure of the type (fields, JSON names, element types) changes
+func schemaOf(value interface{}) string {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return typeFingerprint(t)
+}
+
+// schemaOfDest returns the schema of the type a destination pointer points
+// to, or "" for untyped destinations (e.g. *interface{}) which accept any
+// schema
+func schemaOfDest(dest interface{}) string {
+	t := reflect.TypeOf(dest)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface {
+		return ""
+	}
+	return typeFingerprint(t)
+}
+
+// typeFingerprint hashes the description of t
+func typeFingerprint(t reflect.Type) string {
+	var b strings.Builder
+	describeType(&b, t, make(map[reflect.Type]bool))
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*interface{ MarshalText() ([]byte, error) })(nil)).Elem()
+)
+
+// describeType writes a description of the JSON structure of t
+func describeType(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	if t == nil {
+		b.WriteString("any")
+		return
+	}
+
+	// Types with custom encoding (e.g. time.Time) are opaque
+	if t.Kind() != reflect.Interface && (t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)) {
+		b.WriteString(t.String())
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		describeType(b, t.Elem(), seen)
+	case reflect.Slice, reflect.Array:
+		b.WriteString("[]")
+		describeType(b, t.Elem(), seen)
+	case reflect.Map:
+		b.WriteString("map[")
+		describeType(b, t.Key(), seen)
+		b.WriteString("]")
+		describeType(b, t.Elem(), seen)
+	case reflect.Interface:
+		b.WriteString("any")
+	case reflect.Struct:
+		if seen[t] {
+			// Recursive type, already described
+			b.WriteString(t.String())
+			return
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		var fields []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			var fb strings.Builder
+			fb.WriteString(field.Name)
+			if tag != "" {
+				fb.WriteString(" " + tag)
+			}
+			fb.WriteString(" ")
+			describeType(&fb, field.Type, seen)
+			fields = append(fields, fb.String())
+		}
+		sort.Strings(fields)
+		b.WriteString("struct{" + strings.Join(fields, ";") + "}")
+	default:
+		b.WriteString(t.Kind().String())
+	}
+}
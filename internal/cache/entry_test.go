@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache_Compressed(t *testing.T) {
+	c := newTestCache(t)
+
+	if err := c.Set("compressed", []string{"a", "b"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	data, err := os.ReadFile(c.path("compressed"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Errorf("entry is not gzip-compressed: % x", data[:2])
+	}
+}
+
+func TestCache_SchemaMismatch(t *testing.T) {
+	c := newTestCache(t)
+
+	type v1 struct {
+		Name string
+	}
+	type v2 struct {
+		Name  string
+		Files []string
+	}
+
+	if err := c.Set("versioned", v1{Name: "old"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Reading into a changed type is a miss, and leaves the destination
+	// and the entry untouched
+	dest := v2{Name: "unchanged"}
+	if err := c.Get("versioned", &dest); err != nil {
+		t.Errorf("Get() with changed type error = %v", err)
+	}
+	if dest.Name != "unchanged" {
+		t.Errorf("Get() with changed type modified destination: %+v", dest)
+	}
+	if _, err := Get[v2](c, "versioned"); err != ErrMiss {
+		t.Errorf("Get() with changed type error = %v, want ErrMiss", err)
+	}
+	if got, err := Get[v1](c, "versioned"); err != nil || got.Name != "old" {
+		t.Errorf("Get() with stored type = %+v, %v, want entry kept", got, err)
+	}
+
+	// Untyped destinations accept any schema
+	var got interface{}
+	if err := c.Get("versioned", &got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if m, ok := got.(map[string]interface{}); !ok || m["Name"] != "old" {
+		t.Errorf("Get() into interface{} = %v", got)
+	}
+}
+
+func TestNew_RemovesLegacyEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TEST_CACHE_CACHE_DIR", tmpDir)
+
+	legacy := filepath.Join(tmpDir, "old-entry.json")
+	if err := os.WriteFile(legacy, []byte(`{"data": "value"}`), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	c, err := New(time.Hour, "test-cache")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("legacy entry should be removed")
+	}
+	if err := c.Set("current", "value"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Legacy entries are only looked for once per cache directory
+	other := filepath.Join(tmpDir, "other.json")
+	if err := os.WriteFile(other, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := New(time.Hour, "test-cache"); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("legacy entries should only be removed once: %v", err)
+	}
+	if _, err := os.Stat(c.path("current")); err != nil {
+		t.Errorf("current entry should be kept: %v", err)
+	}
+}
+
+type node struct {
+	Name     string  `json:"name"`
+	Children []*node `json:"children"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	type withTime struct {
+		At time.Time
+	}
+	type renamed struct {
+		Name string `json:"title"`
+	}
+	type plain struct {
+		Name string
+	}
+
+	tests := []struct {
+		name  string
+		a, b  interface{}
+		equal bool
+	}{
+		{name: "same type", a: plain{}, b: plain{Name: "x"}, equal: true},
+		{name: "pointer and value", a: &plain{}, b: plain{}, equal: true},
+		{name: "renamed json field", a: plain{}, b: renamed{}, equal: false},
+		{name: "slice and element", a: []plain{}, b: plain{}, equal: false},
+		{name: "recursive type", a: node{}, b: &node{}, equal: true},
+		{name: "opaque time", a: withTime{}, b: withTime{At: time.Now()}, equal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := schemaOf(tt.a), schemaOf(tt.b)
+			if (a == b) != tt.equal {
+				t.Errorf("schemaOf() = %q, %q, want equal = %v", a, b, tt.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package cache
+
+import "os"
+
+// flock is a no-op on platforms without flock(2); writes are still atomic
+// thanks to the rename, but concurrent removals are not serialized
+func flock(f *os.File, exclusive bool) error {
+	return nil
+}
+
+// funlock is a no-op on platforms without flock(2)
+func funlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,279 @@
+// Package cmdutil provides cobra commands shared by the tools in this
+// repository.
+package cmdutil
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/cache"
+	"go.sbr.pm/x/internal/output"
+)
+
+// CacheCmd returns a "cache" command with info, ls, prune, rm and clear
+// subcommands operating on the cache returned by open
+func CacheCmd(out *output.Writer, open func() (*cache.Cache, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage cache",
+	}
+
+	cmd.AddCommand(cacheInfoCmd(out, open))
+	cmd.AddCommand(cacheLsCmd(out, open))
+	cmd.AddCommand(cachePruneCmd(out, open))
+	cmd.AddCommand(cacheRmCmd(out, open))
+	cmd.AddCommand(cacheClearCmd(out, open))
+
+	return cmd
+}
+
+func openCache(open func() (*cache.Cache, error)) (*cache.Cache, error) {
+	c, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+	}
+	return c, nil
+}
+
+func cacheInfoCmd(out *output.Writer, open func() (*cache.Cache, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "info",
+		Short: "Show cache information",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := openCache(open)
+			if err != nil {
+				return err
+			}
+
+			info, err := c.Info()
+			if err != nil {
+				return fmt.Errorf("failed to get cache info: %w", err)
+			}
+
+			out.Info("Cache directory: %s", info.Directory)
+			out.Info("Total entries: %d (%d expired)", info.EntryCount, info.ExpiredCount)
+			out.Info("Total size: %s", FormatSize(info.TotalSize))
+			if budget := c.Budget(); budget.MaxSize > 0 || budget.MaxAge > 0 {
+				out.Info("Budget: %s", formatBudget(budget))
+			}
+
+			if len(info.Prefixes) == 0 {
+				return nil
+			}
+
+			var buf bytes.Buffer
+			w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PREFIX\tENTRIES\tEXPIRED\tSIZE")
+			for _, p := range info.Prefixes {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", p.Prefix, p.EntryCount, p.ExpiredCount, FormatSize(p.TotalSize))
+			}
+			w.Flush()
+			out.Print("%s", buf.String())
+
+			return nil
+		},
+	}
+}
+
+func cacheLsCmd(out *output.Writer, open func() (*cache.Cache, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls [KEY-GLOB]",
+		Short: "List cache entries",
+		Long: `List cache entries with their size, age and expiration.
+
+An optional glob pattern restricts the listing to matching keys.
+
+Examples:
+  cache ls
+  cache ls 'deps-*'`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pattern := "*"
+			if len(args) > 0 {
+				pattern = args[0]
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+
+			c, err := openCache(open)
+			if err != nil {
+				return err
+			}
+
+			entries, err := c.List()
+			if err != nil {
+				return fmt.Errorf("failed to list cache entries: %w", err)
+			}
+
+			var buf bytes.Buffer
+			w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tSIZE\tAGE\tLAST USED\tEXPIRES")
+			count := 0
+			for _, entry := range entries {
+				if ok, _ := path.Match(pattern, entry.Key); !ok {
+					continue
+				}
+				count++
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					entry.Key,
+					FormatSize(entry.Size),
+					formatAge(entry.StoredAt),
+					formatAge(entry.AccessedAt),
+					formatExpiry(entry))
+			}
+			w.Flush()
+
+			if count == 0 {
+				out.Info("No cache entries")
+				return nil
+			}
+			out.Print("%s", buf.String())
+			return nil
+		},
+	}
+}
+
+func cachePruneCmd(out *output.Writer, open func() (*cache.Cache, error)) *cobra.Command {
+	var (
+		maxSize string
+		maxAge  time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove expired entries and enforce the cache budget",
+		Long: `Remove expired and corrupted entries, then evict entries unused for
+longer than --max-age and the least recently used entries until the cache
+fits in --max-size. Both default to the tool's own budget.
+
+Examples:
+  cache prune
+  cache prune --max-size 50MB
+  cache prune --max-age 168h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := openCache(open)
+			if err != nil {
+				return err
+			}
+
+			budget := c.Budget()
+			if cmd.Flags().Changed("max-size") {
+				budget.MaxSize, err = ParseSize(maxSize)
+				if err != nil {
+					return err
+				}
+			}
+			if cmd.Flags().Changed("max-age") {
+				budget.MaxAge = maxAge
+			}
+
+			result, err := c.PruneWithBudget(budget)
+			if err != nil {
+				return fmt.Errorf("failed to prune cache: %w", err)
+			}
+
+			out.Success("Removed %d entries, freed %s", result.Removed, FormatSize(result.Freed))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Maximum total cache size (e.g. 100MB)")
+	cmd.Flags().DurationVar(&maxAge, "max-age", 0, "Remove entries unused for longer than this")
+
+	return cmd
+}
+
+func cacheRmCmd(out *output.Writer, open func() (*cache.Cache, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm KEY-GLOB...",
+		Short: "Remove cache entries matching glob patterns",
+		Long: `Remove cache entries whose key matches any of the glob patterns.
+
+Examples:
+  cache rm 'prs-*'
+  cache rm deps-myhost-abc123`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := openCache(open)
+			if err != nil {
+				return err
+			}
+
+			total := 0
+			for _, pattern := range args {
+				removed, err := c.RemoveMatching(pattern)
+				total += len(removed)
+				if err != nil {
+					return fmt.Errorf("failed to remove cache entries: %w", err)
+				}
+				if len(removed) == 0 {
+					out.Warning("No cache entries match %s", pattern)
+				}
+			}
+
+			out.Success("Removed %d entries", total)
+			return nil
+		},
+	}
+}
+
+func cacheClearCmd(out *output.Writer, open func() (*cache.Cache, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Clear all cache entries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := openCache(open)
+			if err != nil {
+				return err
+			}
+
+			if err := c.Clear(); err != nil {
+				return fmt.Errorf("failed to clear cache: %w", err)
+			}
+
+			out.Success("Cache cleared")
+			return nil
+		},
+	}
+}
+
+// formatBudget formats a cache budget for display
+func formatBudget(b cache.Budget) string {
+	size := "unlimited size"
+	if b.MaxSize > 0 {
+		size = "max " + FormatSize(b.MaxSize)
+	}
+	age := "no age limit"
+	if b.MaxAge > 0 {
+		age = "unused entries removed after " + FormatDuration(b.MaxAge)
+	}
+	return size + ", " + age
+}
+
+// formatAge formats the time elapsed since t
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return FormatDuration(time.Since(t))
+}
+
+// formatExpiry formats when an entry expires
+func formatExpiry(entry cache.EntryInfo) string {
+	switch {
+	case entry.Expired():
+		return "expired"
+	case entry.ExpiresAt.IsZero():
+		return "never"
+	default:
+		return "in " + FormatDuration(time.Until(entry.ExpiresAt))
+	}
+}
@@ -0,0 +1,29 @@
+package cmdutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/output"
+)
+
+// AddFormatFlags adds the -o/--output and --format flags selecting how the
+// results of cmd, of the same type as v, are rendered (see output.Render).
+// defaultFormat and extra are command-specific formats listed before those
+// available for v.
+func AddFormatFlags(cmd *cobra.Command, opts *output.FormatOptions, v any, defaultFormat string, extra ...string) {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, format := range append(append([]string{defaultFormat}, extra...), output.Formats(v)...) {
+		if !seen[format] {
+			seen[format] = true
+			formats = append(formats, format)
+		}
+	}
+
+	cmd.Flags().StringVarP(&opts.Format, "output", "o", defaultFormat,
+		fmt.Sprintf("Output format (%s)", strings.Join(formats, ", ")))
+	cmd.Flags().StringVar(&opts.Template, "format", "",
+		"Go template executed for each result, e.g. '{{.Title}}' (implies -o template)")
+}
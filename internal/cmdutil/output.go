@@ -0,0 +1,67 @@
+package cmdutil
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/paths"
+)
+
+// AddOutputFlags adds the --quiet, --verbose and --log-file flags shared by
+// all commands to root, and configures out from them before any command runs.
+// A bare --log-file name is created in the state directory of the tool.
+func AddOutputFlags(root *cobra.Command, out *output.Writer) {
+	var (
+		quiet   bool
+		verbose int
+		logFile string
+	)
+
+	flags := root.PersistentFlags()
+	flags.BoolVarP(&quiet, "quiet", "q", false, "Only print results, warnings and errors")
+	flags.CountVarP(&verbose, "verbose", "v", "Print more details (-vv for debug output)")
+	flags.StringVar(&logFile, "log-file", "", "Append all messages with timestamps to FILE (a bare name is created in the state directory)")
+	root.MarkFlagsMutuallyExclusive("quiet", "verbose")
+
+	preRun := root.PersistentPreRunE
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		switch {
+		case quiet:
+			out.SetLevel(output.LevelQuiet)
+		case verbose >= 2:
+			out.SetLevel(output.LevelDebug)
+		case verbose == 1:
+			out.SetLevel(output.LevelVerbose)
+		}
+
+		if logFile != "" {
+			if filepath.Base(logFile) == logFile {
+				dir, err := paths.Ensure(root.Name(), paths.State)
+				if err != nil {
+					return err
+				}
+				logFile = filepath.Join(dir, logFile)
+			}
+			if err := out.SetLogFile(logFile); err != nil {
+				return err
+			}
+			out.Debug("Logging to %s", logFile)
+		}
+
+		if preRun != nil {
+			return preRun(cmd, args)
+		}
+		return nil
+	}
+
+	postRun := root.PersistentPostRunE
+	root.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
+		if postRun != nil {
+			if err := postRun(cmd, args); err != nil {
+				return err
+			}
+		}
+		return out.Close()
+	}
+}
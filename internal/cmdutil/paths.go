@@ -0,0 +1,60 @@
+package cmdutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/paths"
+)
+
+// PathsCmd returns a "paths" command printing where tool stores its cache,
+// configuration, state and data, and which environment variables override
+// them
+func PathsCmd(out *output.Writer, tool string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "paths",
+		Short: "Show cache, config, state and data directories",
+		Long: fmt.Sprintf(`Show where %[1]s stores its files.
+
+Directories follow the XDG Base Directory specification (XDG_CACHE_HOME,
+XDG_CONFIG_HOME, XDG_STATE_HOME, XDG_DATA_HOME) and can be overridden with
+%[2]s, %[3]s, %[4]s and %[5]s.`,
+			tool,
+			paths.OverrideEnv(tool, paths.Cache),
+			paths.OverrideEnv(tool, paths.Config),
+			paths.OverrideEnv(tool, paths.State),
+			paths.OverrideEnv(tool, paths.Data)),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var buf bytes.Buffer
+			w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KIND\tPATH\tSOURCE")
+			for _, kind := range paths.Kinds {
+				dir, err := paths.Dir(tool, kind)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", kind, dir, pathSource(tool, kind))
+			}
+			w.Flush()
+
+			out.Print("%s", buf.String())
+			return nil
+		},
+	}
+}
+
+// pathSource describes where a directory comes from
+func pathSource(tool string, kind paths.Kind) string {
+	if env := paths.OverrideEnv(tool, kind); os.Getenv(env) != "" {
+		return env
+	}
+	if env := paths.XDGEnv(kind); os.Getenv(env) != "" {
+		return env
+	}
+	return "default"
+}
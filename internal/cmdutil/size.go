@@ -0,0 +1,66 @@
+package cmdutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits maps size suffixes to their multiplier, longest suffixes first
+var sizeUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size such as "512", "100KB", "50MB" or "1.5G".
+// Units are powers of 1024 and case-insensitive.
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	scale := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			scale = unit.scale
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(scale)), nil
+}
+
+// FormatSize formats a byte count using the largest fitting unit
+func FormatSize(size int64) string {
+	for _, unit := range sizeUnits[:3] {
+		if size >= unit.scale {
+			return fmt.Sprintf("%.1f %s", float64(size)/float64(unit.scale), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// FormatDuration formats a duration coarsely (e.g. "5m", "3h", "2d")
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
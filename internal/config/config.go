@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -32,6 +33,138 @@ func (c *Config) FlakePath() string {
 	return c.flakePath
 }
 
+// Fingerprint returns a short hash identifying the current contents of the
+// flake. It combines the hash of flake.lock with a hash of the flake sources,
+// so it changes when inputs are updated or any tracked file is edited.
+func (c *Config) Fingerprint() (string, error) {
+	h := sha256.New()
+
+	lock, err := os.ReadFile(filepath.Join(c.flakePath, "flake.lock"))
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read flake.lock: %w", err)
+	}
+	h.Write(lock)
+
+	source, err := c.sourceHash()
+	if err != nil {
+		return "", err
+	}
+	h.Write([]byte(source))
+
+	return fmt.Sprintf("%x", h.Sum(nil))[:16], nil
+}
+
+// sourceHash returns a hash of the flake sources.
+// It prefers the git tree hash (cheap and local) and falls back to the
+// narHash reported by nix for flakes that are not in a git repository.
+func (c *Config) sourceHash() (string, error) {
+	if hash, err := gitTreeHash(c.flakePath); err == nil {
+		return hash, nil
+	}
+
+	hash, err := narHash(c.flakePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash flake sources at %s: %w", c.flakePath, err)
+	}
+	return hash, nil
+}
+
+// gitTreeHash returns the git tree hash of dir at HEAD, combined with a hash
+// of uncommitted changes to tracked files (which nix also sees).
+func gitTreeHash(dir string) (string, error) {
+	tree, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD:./").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git tree hash: %w", err)
+	}
+
+	diff, err := exec.Command("git", "-C", dir, "diff", "HEAD", "--binary", "--", ".").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git diff: %w", err)
+	}
+
+	return fmt.Sprintf("%s-%x", strings.TrimSpace(string(tree)), sha256.Sum256(diff)), nil
+}
+
+// narHash returns the narHash of the flake source as reported by nix
+func narHash(flakePath string) (string, error) {
+	output, err := exec.Command("nix", "flake", "metadata", "--json", flakePath).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run nix flake metadata: %w", err)
+	}
+
+	var metadata struct {
+		Locked struct {
+			NarHash string `json:"narHash"`
+		} `json:"locked"`
+	}
+	if err := json.Unmarshal(output, &metadata); err != nil {
+		return "", fmt.Errorf("failed to parse flake metadata: %w", err)
+	}
+	if metadata.Locked.NarHash == "" {
+		return "", fmt.Errorf("flake metadata has no narHash")
+	}
+
+	return metadata.Locked.NarHash, nil
+}
+
+// maxConfigurationDepth bounds how deep ConfigurationFingerprint looks for
+// Nix files, so a configuration kept in e.g. $HOME doesn't hash all of it
+const maxConfigurationDepth = 3
+
+// ConfigurationFingerprint returns a short hash identifying the contents of
+// a channel-based configuration: configPath and the Nix files under its
+// directory (e.g. hardware-configuration.nix or imported modules), so it
+// changes when any of them is edited. Hidden directories, entries that
+// can't be read and files deeper than maxConfigurationDepth are skipped.
+func ConfigurationFingerprint(configPath string) (string, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read configuration: %w", err)
+	}
+	dir := configPath
+	h := sha256.New()
+	if !info.IsDir() {
+		dir = filepath.Dir(configPath)
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read configuration: %w", err)
+		}
+		fmt.Fprintf(h, "%s\x00%x\x00", filepath.Base(configPath), sha256.Sum256(data))
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable entries are skipped, unless it's the directory itself
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || strings.Count(rel, string(filepath.Separator)) >= maxConfigurationDepth-1) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path == configPath || !d.Type().IsRegular() || filepath.Ext(path) != ".nix" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%x\x00", rel, sha256.Sum256(data))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash configuration at %s: %w", dir, err)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))[:16], nil
+}
+
 // AllHosts returns all NixOS hosts defined in the flake
 func (c *Config) AllHosts() ([]string, error) {
 	// Use nix flake show to list all nixosConfigurations
@@ -64,8 +197,8 @@ func (c *Config) AllHosts() ([]string, error) {
 	return hosts, nil
 }
 
-// CurrentHost returns the current hostname
-func (c *Config) CurrentHost() (string, error) {
+// ShortHostname returns the hostname of the machine without its domain
+func ShortHostname() (string, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
 		return "", fmt.Errorf("failed to get hostname: %w", err)
@@ -76,6 +209,16 @@ func (c *Config) CurrentHost() (string, error) {
 		hostname = hostname[:idx]
 	}
 
+	return hostname, nil
+}
+
+// CurrentHost returns the current hostname
+func (c *Config) CurrentHost() (string, error) {
+	hostname, err := ShortHostname()
+	if err != nil {
+		return "", err
+	}
+
 	// Verify this host exists in the flake
 	hosts, err := c.AllHosts()
 	if err != nil {
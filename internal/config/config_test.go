@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initFlakeRepo creates a git repository containing a minimal flake
+func initFlakeRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "flake.nix"), "{ outputs = _: { }; }\n")
+	writeFile(t, filepath.Join(dir, "flake.lock"), `{"nodes": {"root": {}}, "root": "root", "version": 7}`)
+	writeFile(t, filepath.Join(dir, "configuration.nix"), "{ }\n")
+
+	git(t, dir, "init", "-q")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "init")
+
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile(%s) error = %v", path, err)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v error = %v: %s", args, err, output)
+	}
+}
+
+func TestNew_MissingFlake(t *testing.T) {
+	if _, err := New(t.TempDir()); err == nil {
+		t.Error("New() on directory without flake.nix should fail")
+	}
+}
+
+func TestConfig_Fingerprint(t *testing.T) {
+	dir := initFlakeRepo(t)
+
+	cfg, err := New(dir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	initial, err := cfg.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+
+	again, err := cfg.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	if again != initial {
+		t.Errorf("Fingerprint() not stable: %q != %q", again, initial)
+	}
+
+	// Uncommitted configuration change
+	writeFile(t, filepath.Join(dir, "configuration.nix"), "{ services.nginx.enable = true; }\n")
+	edited, err := cfg.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() after edit error = %v", err)
+	}
+	if edited == initial {
+		t.Error("Fingerprint() did not change after editing configuration.nix")
+	}
+
+	// Committing the same change keeps the content identical, but the
+	// tree hash now carries it instead of the diff
+	git(t, dir, "commit", "-q", "-am", "enable nginx")
+	committed, err := cfg.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() after commit error = %v", err)
+	}
+	if committed == initial {
+		t.Error("Fingerprint() after commit should differ from initial")
+	}
+
+	// Lock file update
+	writeFile(t, filepath.Join(dir, "flake.lock"), `{"nodes": {"root": {"inputs": {}}}, "root": "root", "version": 7}`)
+	git(t, dir, "commit", "-q", "-am", "update lock")
+	locked, err := cfg.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() after lock update error = %v", err)
+	}
+	if locked == committed {
+		t.Error("Fingerprint() did not change after updating flake.lock")
+	}
+}
+
+func TestConfigurationFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "configuration.nix")
+	writeFile(t, configPath, "{ imports = [ ./hardware-configuration.nix ]; }\n")
+	writeFile(t, filepath.Join(dir, "hardware-configuration.nix"), "{ }\n")
+
+	initial, err := ConfigurationFingerprint(configPath)
+	if err != nil {
+		t.Fatalf("ConfigurationFingerprint() error = %v", err)
+	}
+
+	// Other files don't affect the configuration
+	writeFile(t, filepath.Join(dir, "README"), "notes\n")
+	if got, _ := ConfigurationFingerprint(configPath); got != initial {
+		t.Errorf("ConfigurationFingerprint() changed after adding a non-Nix file")
+	}
+
+	// Editing an imported file does
+	writeFile(t, filepath.Join(dir, "hardware-configuration.nix"), "{ boot.loader.grub.enable = true; }\n")
+	edited, err := ConfigurationFingerprint(configPath)
+	if err != nil {
+		t.Fatalf("ConfigurationFingerprint() after edit error = %v", err)
+	}
+	if edited == initial {
+		t.Error("ConfigurationFingerprint() did not change after editing hardware-configuration.nix")
+	}
+
+	// Nix files too deep are skipped
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b", "c"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "a", "b", "c", "deep.nix"), "{ }\n")
+	if got, _ := ConfigurationFingerprint(configPath); got != edited {
+		t.Error("ConfigurationFingerprint() changed after adding a file deeper than the limit")
+	}
+
+	// Unreadable directories are skipped instead of failing
+	unreadable := filepath.Join(dir, "unreadable")
+	if err := os.Mkdir(unreadable, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(unreadable, 0755) })
+	if _, err := ConfigurationFingerprint(configPath); err != nil {
+		t.Errorf("ConfigurationFingerprint() with an unreadable directory error = %v", err)
+	}
+
+	if _, err := ConfigurationFingerprint(filepath.Join(dir, "missing.nix")); err == nil {
+		t.Error("ConfigurationFingerprint() of a missing file should fail")
+	}
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lock represents the parts of a flake.lock file we care about
+type Lock struct {
+	Nodes map[string]LockNode `json:"nodes"`
+	Root  string              `json:"root"`
+}
+
+// LockNode is a single node of a flake.lock graph
+type LockNode struct {
+	// Inputs maps input names to node names. Inputs using "follows" are
+	// lists of input names instead of a plain string.
+	Inputs   map[string]json.RawMessage `json:"inputs"`
+	Original LockRef                    `json:"original"`
+}
+
+// LockRef is the original (unlocked) reference of a flake input
+type LockRef struct {
+	Type  string `json:"type"`
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	Ref   string `json:"ref"`
+	URL   string `json:"url"`
+	ID    string `json:"id"`
+}
+
+// LoadLock parses the flake.lock file in flakePath
+func LoadLock(flakePath string) (*Lock, error) {
+	data, err := os.ReadFile(filepath.Join(flakePath, "flake.lock"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flake.lock: %w", err)
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse flake.lock: %w", err)
+	}
+
+	return &lock, nil
+}
+
+// NixpkgsInputs returns the root inputs pointing to NixOS/nixpkgs, mapped
+// from input name to the followed ref (e.g. "nixpkgs" -> "nixos-unstable").
+// An empty ref means the input follows the repository's default branch.
+func (l *Lock) NixpkgsInputs() map[string]string {
+	inputs := make(map[string]string)
+
+	root, ok := l.Nodes[l.Root]
+	if !ok {
+		return inputs
+	}
+
+	for name, raw := range root.Inputs {
+		var nodeName string
+		if err := json.Unmarshal(raw, &nodeName); err != nil {
+			// Input follows another input, it's already covered there
+			continue
+		}
+
+		node, ok := l.Nodes[nodeName]
+		if !ok || !node.Original.isNixpkgs() {
+			continue
+		}
+		inputs[name] = node.Original.Ref
+	}
+
+	return inputs
+}
+
+// isNixpkgs reports whether the reference points to the nixpkgs repository
+func (r LockRef) isNixpkgs() bool {
+	switch r.Type {
+	case "github":
+		return strings.EqualFold(r.Owner, "NixOS") && strings.EqualFold(r.Repo, "nixpkgs")
+	case "git", "tarball":
+		return strings.Contains(strings.ToLower(r.URL), "github.com/nixos/nixpkgs")
+	case "indirect":
+		return r.ID == "nixpkgs"
+	default:
+		return false
+	}
+}
+
+// releaseRefPattern matches channel and branch names tied to a release,
+// such as nixos-25.05, nixos-25.05-small, nixpkgs-25.05-darwin or release-25.05
+var releaseRefPattern = regexp.MustCompile(`^(?:nixos|nixpkgs|release)-(\d{2}\.\d{2})(?:-.*)?$`)
+
+// BaseBranchesForRef maps a nixpkgs channel or branch name to the branches
+// pull requests for it target: release channels map to their release branch
+// (nixos-25.05 -> release-25.05), unstable channels map to master and
+// staging, whose changes reach them through staging-next.
+func BaseBranchesForRef(ref string) []string {
+	if m := releaseRefPattern.FindStringSubmatch(ref); m != nil {
+		return []string{"release-" + m[1]}
+	}
+
+	switch ref {
+	case "staging", "staging-next":
+		return []string{ref}
+	default:
+		// master, nixos-unstable, nixos-unstable-small, nixpkgs-unstable...
+		return []string{"master", "staging"}
+	}
+}
+
+// stagingReleasePattern matches release staging branches, such as
+// staging-25.05 and staging-next-25.05
+var stagingReleasePattern = regexp.MustCompile(`^staging(-next)?-(\d{2}\.\d{2})$`)
+
+// ChannelPath returns the branches changes merged into branch go through,
+// starting with branch, then the channel they are expected to reach:
+// staging is merged into staging-next, then master, which reaches
+// nixos-unstable. Unknown branches are returned alone.
+func ChannelPath(branch string) []string {
+	if m := stagingReleasePattern.FindStringSubmatch(branch); m != nil {
+		path := []string{branch}
+		if m[1] == "" {
+			path = append(path, "staging-next-"+m[2])
+		}
+		return append(path, "release-"+m[2], "nixos-"+m[2])
+	}
+	if m := releaseRefPattern.FindStringSubmatch(branch); m != nil && strings.HasPrefix(branch, "release-") {
+		return []string{branch, "nixos-" + m[1]}
+	}
+
+	switch branch {
+	case "staging":
+		return []string{"staging", "staging-next", "master", "nixos-unstable"}
+	case "staging-next":
+		return []string{"staging-next", "master", "nixos-unstable"}
+	case "master":
+		return []string{"master", "nixos-unstable"}
+	default:
+		return []string{branch}
+	}
+}
+
+// releaseOfRef returns the release version (e.g. "25.05") of a ref, if any
+func releaseOfRef(ref string) string {
+	if m := releaseRefPattern.FindStringSubmatch(ref); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// HostNixpkgsRef returns the ref of the nixpkgs input used by host.
+//
+// With a single nixpkgs input in flake.lock, that input is used for every
+// host. With several, the host's system.nixos.release is evaluated and
+// matched against the input refs, falling back to the input named "nixpkgs".
+func (c *Config) HostNixpkgsRef(host string) (string, error) {
+	lock, err := LoadLock(c.flakePath)
+	if err != nil {
+		return "", err
+	}
+
+	inputs := lock.NixpkgsInputs()
+	switch len(inputs) {
+	case 0:
+		return "", fmt.Errorf("no nixpkgs input found in flake.lock")
+	case 1:
+		for _, ref := range inputs {
+			return ref, nil
+		}
+	}
+
+	release, err := c.hostRelease(host)
+	if err == nil {
+		names := make([]string, 0, len(inputs))
+		for name := range inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if releaseOfRef(inputs[name]) == release {
+				return inputs[name], nil
+			}
+		}
+	}
+
+	if ref, ok := inputs["nixpkgs"]; ok {
+		return ref, nil
+	}
+
+	return "", fmt.Errorf("cannot determine which nixpkgs input host %s uses", host)
+}
+
+// hostRelease evaluates the NixOS release (e.g. "25.05") of a host
+func (c *Config) hostRelease(host string) (string, error) {
+	flakeRef := fmt.Sprintf("%s#nixosConfigurations.%s.config.system.nixos.release", c.flakePath, host)
+	output, err := exec.Command("nix", "eval", "--raw", flakeRef).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate NixOS release of %s: %w", host, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testLock = `{
+  "nodes": {
+    "home-manager": {
+      "inputs": {"nixpkgs": ["nixpkgs"]},
+      "original": {"owner": "nix-community", "repo": "home-manager", "type": "github"}
+    },
+    "nixpkgs": {
+      "original": {"owner": "NixOS", "ref": "nixos-unstable", "repo": "nixpkgs", "type": "github"}
+    },
+    "nixpkgs-stable": {
+      "original": {"owner": "nixos", "ref": "nixos-25.05", "repo": "nixpkgs", "type": "github"}
+    },
+    "nixpkgs-git": {
+      "original": {"type": "git", "url": "https://github.com/NixOS/nixpkgs", "ref": "release-24.11"}
+    },
+    "root": {
+      "inputs": {
+        "home-manager": "home-manager",
+        "nixpkgs": "nixpkgs",
+        "nixpkgs-stable": "nixpkgs-stable",
+        "nixpkgs-old": "nixpkgs-git",
+        "nixpkgs-follows": ["home-manager", "nixpkgs"]
+      }
+    }
+  },
+  "root": "root",
+  "version": 7
+}`
+
+func TestLock_NixpkgsInputs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "flake.lock"), []byte(testLock), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	lock, err := LoadLock(dir)
+	if err != nil {
+		t.Fatalf("LoadLock() error = %v", err)
+	}
+
+	got := lock.NixpkgsInputs()
+	want := map[string]string{
+		"nixpkgs":        "nixos-unstable",
+		"nixpkgs-stable": "nixos-25.05",
+		"nixpkgs-old":    "release-24.11",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NixpkgsInputs() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadLock_Missing(t *testing.T) {
+	if _, err := LoadLock(t.TempDir()); err == nil {
+		t.Error("LoadLock() without flake.lock should fail")
+	}
+}
+
+func TestBaseBranchesForRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{ref: "nixos-25.05", want: []string{"release-25.05"}},
+		{ref: "nixos-25.05-small", want: []string{"release-25.05"}},
+		{ref: "nixpkgs-25.05-darwin", want: []string{"release-25.05"}},
+		{ref: "release-24.11", want: []string{"release-24.11"}},
+		{ref: "nixos-unstable", want: []string{"master", "staging"}},
+		{ref: "nixos-unstable-small", want: []string{"master", "staging"}},
+		{ref: "nixpkgs-unstable", want: []string{"master", "staging"}},
+		{ref: "master", want: []string{"master", "staging"}},
+		{ref: "", want: []string{"master", "staging"}},
+		{ref: "staging", want: []string{"staging"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := BaseBranchesForRef(tt.ref); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BaseBranchesForRef(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelPath(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   []string
+	}{
+		{branch: "master", want: []string{"master", "nixos-unstable"}},
+		{branch: "staging", want: []string{"staging", "staging-next", "master", "nixos-unstable"}},
+		{branch: "staging-next", want: []string{"staging-next", "master", "nixos-unstable"}},
+		{branch: "release-25.05", want: []string{"release-25.05", "nixos-25.05"}},
+		{branch: "staging-25.05", want: []string{"staging-25.05", "staging-next-25.05", "release-25.05", "nixos-25.05"}},
+		{branch: "staging-next-25.05", want: []string{"staging-next-25.05", "release-25.05", "nixos-25.05"}},
+		{branch: "haskell-updates", want: []string{"haskell-updates"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := ChannelPath(tt.branch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChannelPath(%q) = %v, want %v", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_HostNixpkgsRef_SingleInput(t *testing.T) {
+	dir := t.TempDir()
+	lock := `{
+	  "nodes": {
+	    "nixpkgs": {"original": {"owner": "NixOS", "ref": "nixos-25.05", "repo": "nixpkgs", "type": "github"}},
+	    "root": {"inputs": {"nixpkgs": "nixpkgs"}}
+	  },
+	  "root": "root",
+	  "version": 7
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "flake.nix"), []byte("{ }"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "flake.lock"), []byte(lock), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	cfg, err := New(dir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got, err := cfg.HostNixpkgsRef("any-host")
+	if err != nil {
+		t.Fatalf("HostNixpkgsRef() error = %v", err)
+	}
+	if got != "nixos-25.05" {
+		t.Errorf("HostNixpkgsRef() = %q, want %q", got, "nixos-25.05")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/progress"
 )
 
 // Resolver handles merge conflict resolution
@@ -111,12 +112,16 @@ func (r *Resolver) FindConflictingPRs(org, author string) ([]PRInfo, error) {
 
 	r.out.Info("Checking %d PRs for merge conflicts...", len(searchResults))
 
+	p := progress.New(r.out)
+	p.Start("Checking PRs", len(searchResults))
+	defer p.Finish()
+
 	var conflictingPRs []PRInfo
-	for i, result := range searchResults {
-		r.out.Print("\rChecking PR %d/%d...", i+1, len(searchResults))
+	for _, result := range searchResults {
 
 		// Fetch detailed PR info
 		pr, err := r.FindConflictingPR(result.Repository.NameWithOwner, fmt.Sprintf("%d", result.Number))
+		p.Step(1, fmt.Sprintf("%s#%d", result.Repository.NameWithOwner, result.Number))
 		if err != nil {
 			// Skip non-conflicting PRs
 			continue
@@ -125,7 +130,6 @@ func (r *Resolver) FindConflictingPRs(org, author string) ([]PRInfo, error) {
 		conflictingPRs = append(conflictingPRs, *pr)
 	}
 
-	fmt.Println() // Newline after progress
 	return conflictingPRs, nil
 }
 
@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"go.sbr.pm/x/internal/progress"
 )
 
 // Package represents a package dependency
@@ -26,10 +30,40 @@ type Dependencies struct {
 	Services []string     `json:"services"`
 }
 
+// packageNamesExpr maps a list of derivations to their names
+const packageNamesExpr = `pkgs: map (p: p.pname or p.name or "unknown") pkgs`
+
+// enabledServicesExpr lists the names of enabled services.<name> options.
+// Groups (attribute sets without an enable option, or enabled services
+// themselves, such as services.xserver) are searched one level deeper and
+// reported as "<group>.<name>". Options that fail to evaluate (removed or
+// renamed options throw) are skipped.
+const enabledServicesExpr = `services:
+  let
+    try = f: v: let r = builtins.tryEval (f v); in r.success && r.value;
+    isAttrs = try builtins.isAttrs;
+    isEnabled = try (v: builtins.isAttrs v && (v.enable or false) == true);
+    isGroup = try (v: builtins.isAttrs v && (!(v ? enable) || v.enable == true));
+    names = builtins.attrNames services;
+    nested = name:
+      let group = services.${name}; in
+      if isGroup group
+      then map (sub: name + "." + sub) (builtins.filter (sub: isAttrs group.${sub} && isEnabled group.${sub}) (builtins.attrNames group))
+      else [ ];
+  in
+  builtins.filter (name: isEnabled services.${name}) names ++ builtins.concatMap nested names`
+
 // Extractor extracts dependencies from a NixOS configuration
 type Extractor struct {
 	flakePath string
 	hostname  string
+
+	// nixosConfig is the path to a configuration.nix for channel-based
+	// systems. When set, it is evaluated with nix-instantiate instead of
+	// going through the flake.
+	nixosConfig string
+
+	progress progress.Progress
 }
 
 // NewExtractor creates a new dependency extractor
@@ -37,9 +71,28 @@ func NewExtractor(flakePath, hostname string) *Extractor {
 	return &Extractor{
 		flakePath: flakePath,
 		hostname:  hostname,
+		progress:  progress.Nop(),
+	}
+}
+
+// NewNixOSConfigExtractor creates a dependency extractor for a channel-based
+// system, evaluating configPath through '<nixpkgs/nixos>'
+func NewNixOSConfigExtractor(configPath string) *Extractor {
+	return &Extractor{
+		nixosConfig: configPath,
+		progress:    progress.Nop(),
 	}
 }
 
+// SetProgress sets where the progress of Extract is reported, one step per
+// evaluated attribute
+func (e *Extractor) SetProgress(p progress.Progress) {
+	e.progress = p
+}
+
+// extractSteps is the number of attributes evaluated by Extract
+const extractSteps = 5
+
 // Extract extracts all dependencies from the configuration
 func (e *Extractor) Extract() (Dependencies, error) {
 	deps := Dependencies{
@@ -48,12 +101,20 @@ func (e *Extractor) Extract() (Dependencies, error) {
 		Services: []string{},
 	}
 
+	task := "Extracting " + e.hostname
+	if e.nixosConfig != "" {
+		task = "Extracting " + e.nixosConfig
+	}
+	e.progress.Start(task, extractSteps)
+	defer e.progress.Finish()
+
 	// Extract system packages
 	systemPkgs, err := e.extractSystemPackages()
 	if err != nil {
 		return deps, fmt.Errorf("failed to extract system packages: %w", err)
 	}
 	deps.Packages = append(deps.Packages, systemPkgs...)
+	e.progress.Step(1, "system packages")
 
 	// Extract home-manager packages (if available)
 	homePkgs, err := e.extractHomePackages()
@@ -63,6 +124,7 @@ func (e *Extractor) Extract() (Dependencies, error) {
 	} else {
 		deps.Packages = append(deps.Packages, homePkgs...)
 	}
+	e.progress.Step(1, "home-manager packages")
 
 	// Deduplicate packages
 	deps.Packages = deduplicatePackages(deps.Packages)
@@ -74,6 +136,7 @@ func (e *Extractor) Extract() (Dependencies, error) {
 	} else {
 		deps.Modules = append(deps.Modules, nixosModules...)
 	}
+	e.progress.Step(1, "NixOS modules")
 
 	// Extract home-manager modules
 	homeModules, err := e.extractHomeManagerModules()
@@ -82,26 +145,58 @@ func (e *Extractor) Extract() (Dependencies, error) {
 	} else {
 		deps.Modules = append(deps.Modules, homeModules...)
 	}
+	e.progress.Step(1, "home-manager modules")
+
+	// Extract enabled NixOS services
+	services, err := e.extractServices()
+	if err != nil {
+		// Services might fail to evaluate, that's ok
+	} else {
+		deps.Services = append(deps.Services, services...)
+	}
+	e.progress.Step(1, "services")
 
 	return deps, nil
 }
 
-// extractSystemPackages extracts packages from environment.systemPackages
-func (e *Extractor) extractSystemPackages() ([]Package, error) {
-	flakeRef := fmt.Sprintf("%s#nixosConfigurations.%s.config.environment.systemPackages", e.flakePath, e.hostname)
-
-	cmd := exec.Command("nix", "eval", flakeRef,
-		"--apply", `pkgs: map (p: p.pname or p.name or "unknown") pkgs`,
-		"--json")
+// eval evaluates fn applied to config.<attr> of the configuration and
+// returns the result as JSON
+func (e *Extractor) eval(attr, fn string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if e.nixosConfig != "" {
+		configPath, err := filepath.Abs(e.nixosConfig)
+		if err != nil {
+			return nil, err
+		}
+		expr := fmt.Sprintf(`{ configPath }: (%s) (import <nixpkgs/nixos> { configuration = /. + configPath; }).config.%s`, fn, attr)
+		cmd = exec.Command("nix-instantiate", "--eval", "--strict", "--json",
+			"--argstr", "configPath", configPath,
+			"-E", expr)
+	} else {
+		flakeRef := fmt.Sprintf("%s#nixosConfigurations.%s.config.%s", e.flakePath, e.hostname, attr)
+		cmd = exec.Command("nix", "eval", flakeRef,
+			"--apply", fn,
+			"--json")
+	}
 
 	output, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("nix eval failed: %s", string(exitErr.Stderr))
+			return nil, fmt.Errorf("%s failed: %s", cmd.Args[0], string(exitErr.Stderr))
 		}
 		return nil, err
 	}
 
+	return output, nil
+}
+
+// extractSystemPackages extracts packages from environment.systemPackages
+func (e *Extractor) extractSystemPackages() ([]Package, error) {
+	output, err := e.eval("environment.systemPackages", packageNamesExpr)
+	if err != nil {
+		return nil, err
+	}
+
 	var names []string
 	if err := json.Unmarshal(output, &names); err != nil {
 		return nil, fmt.Errorf("failed to parse package names: %w", err)
@@ -123,14 +218,9 @@ func (e *Extractor) extractHomePackages() ([]Package, error) {
 	usernames := []string{"vincent", "vdemeest"}
 
 	for _, username := range usernames {
-		flakeRef := fmt.Sprintf("%s#nixosConfigurations.%s.config.home-manager.users.%s.home.packages",
-			e.flakePath, e.hostname, username)
-
-		cmd := exec.Command("nix", "eval", flakeRef,
-			"--apply", `pkgs: map (p: p.pname or p.name or "unknown") pkgs`,
-			"--json")
+		attr := fmt.Sprintf("home-manager.users.%s.home.packages", username)
 
-		output, err := cmd.Output()
+		output, err := e.eval(attr, packageNamesExpr)
 		if err != nil {
 			// Try next username
 			continue
@@ -159,16 +249,8 @@ func (e *Extractor) extractHomePackages() ([]Package, error) {
 // we track systemd services that are defined, which reflects enabled services
 func (e *Extractor) extractNixOSModules() ([]ModulePath, error) {
 	// Get systemd services - this is a reliable way to see what's configured
-	flakeRef := fmt.Sprintf("%s#nixosConfigurations.%s.config.systemd.services", e.flakePath, e.hostname)
-	cmd := exec.Command("nix", "eval", flakeRef,
-		"--apply", "services: builtins.attrNames services",
-		"--json")
-
-	output, err := cmd.Output()
+	output, err := e.eval("systemd.services", "services: builtins.attrNames services")
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to extract systemd services: %s", string(exitErr.Stderr))
-		}
 		return nil, fmt.Errorf("failed to extract systemd services: %w", err)
 	}
 
@@ -206,6 +288,22 @@ func (e *Extractor) extractNixOSModules() ([]ModulePath, error) {
 	return modules, nil
 }
 
+// extractServices extracts the enabled services.<name>.enable options
+func (e *Extractor) extractServices() ([]string, error) {
+	output, err := e.eval("services", enabledServicesExpr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract services: %w", err)
+	}
+
+	var services []string
+	if err := json.Unmarshal(output, &services); err != nil {
+		return nil, fmt.Errorf("failed to parse service names: %w", err)
+	}
+
+	sort.Strings(services)
+	return services, nil
+}
+
 // extractHomeManagerModules extracts home-manager packages as a proxy for enabled programs
 // Note: Home-manager doesn't expose enabled programs easily, but packages are a good proxy
 func (e *Extractor) extractHomeManagerModules() ([]ModulePath, error) {
@@ -280,6 +378,26 @@ func (d *Dependencies) HasPackage(name string) bool {
 	return false
 }
 
+// HasService checks if dependencies contain an enabled service with the given name
+func (d *Dependencies) HasService(name string) bool {
+	for _, svc := range d.Services {
+		if svc == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceModuleName returns the name a service's module and tests are
+// usually named after: the last segment of nested services, so
+// "xserver.libinput" gives "libinput"
+func ServiceModuleName(service string) string {
+	if idx := strings.LastIndex(service, "."); idx != -1 {
+		return service[idx+1:]
+	}
+	return service
+}
+
 // HasModulePath checks if dependencies contain a module with the given path
 func (d *Dependencies) HasModulePath(path string) bool {
 	for _, mod := range d.Modules {
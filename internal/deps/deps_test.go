@@ -265,3 +265,47 @@ func TestMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestDependencies_HasService(t *testing.T) {
+	deps := &Dependencies{
+		Services: []string{"nginx", "xserver.libinput"},
+	}
+
+	tests := []struct {
+		name    string
+		service string
+		want    bool
+	}{
+		{name: "top-level service", service: "nginx", want: true},
+		{name: "nested service", service: "xserver.libinput", want: true},
+		{name: "nested service by leaf name", service: "libinput", want: false},
+		{name: "non-existent service", service: "postgresql", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deps.HasService(tt.service); got != tt.want {
+				t.Errorf("HasService(%q) = %v, want %v", tt.service, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceModuleName(t *testing.T) {
+	tests := []struct {
+		service string
+		want    string
+	}{
+		{service: "nginx", want: "nginx"},
+		{service: "xserver.libinput", want: "libinput"},
+		{service: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			if got := ServiceModuleName(tt.service); got != tt.want {
+				t.Errorf("ServiceModuleName(%q) = %q, want %q", tt.service, got, tt.want)
+			}
+		})
+	}
+}
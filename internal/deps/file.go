@@ -0,0 +1,93 @@
+package deps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadFile reads dependencies from a file instead of evaluating a
+// configuration. See Parse for the accepted formats.
+func LoadFile(path string) (Dependencies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Dependencies{}, fmt.Errorf("failed to read dependencies file: %w", err)
+	}
+
+	deps, err := Parse(data)
+	if err != nil {
+		return Dependencies{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return deps, nil
+}
+
+// Parse parses a dependencies document. Accepted formats are:
+//   - a JSON Dependencies object (as exported by nixpkgs-pr-watch -o json,
+//     either bare or under a "dependencies" key)
+//   - a JSON array of package names
+//   - plain text with package names separated by whitespace or newlines,
+//     where "#" starts a comment
+func Parse(data []byte) (Dependencies, error) {
+	deps := Dependencies{
+		Packages: []Package{},
+		Modules:  []ModulePath{},
+		Services: []string{},
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return deps, fmt.Errorf("empty dependencies document")
+	}
+
+	switch trimmed[0] {
+	case '{':
+		var doc struct {
+			Dependencies
+			Nested *Dependencies `json:"dependencies"`
+		}
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return deps, fmt.Errorf("invalid JSON dependencies: %w", err)
+		}
+		parsed := doc.Dependencies
+		if doc.Nested != nil {
+			parsed = *doc.Nested
+		}
+		deps.Packages = append(deps.Packages, parsed.Packages...)
+		deps.Modules = append(deps.Modules, parsed.Modules...)
+		deps.Services = append(deps.Services, parsed.Services...)
+
+	case '[':
+		var names []string
+		if err := json.Unmarshal(trimmed, &names); err != nil {
+			return deps, fmt.Errorf("invalid JSON package list: %w", err)
+		}
+		for _, name := range names {
+			if name != "" {
+				deps.Packages = append(deps.Packages, Package{Name: name})
+			}
+		}
+
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if idx := strings.Index(line, "#"); idx != -1 {
+				line = line[:idx]
+			}
+			for _, name := range strings.Fields(line) {
+				deps.Packages = append(deps.Packages, Package{Name: name})
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return deps, err
+		}
+	}
+
+	deps.Packages = deduplicatePackages(deps.Packages)
+
+	return deps, nil
+}
@@ -0,0 +1,109 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantPackages []string
+		wantModules  int
+		wantServices int
+		wantErr      bool
+	}{
+		{
+			name: "dependencies object",
+			input: `{
+				"packages": [{"name": "git"}, {"name": "curl"}],
+				"modules": [{"path": "nixos/modules/services/docker", "type": "nixos"}],
+				"services": ["docker"]
+			}`,
+			wantPackages: []string{"git", "curl"},
+			wantModules:  1,
+			wantServices: 1,
+		},
+		{
+			name: "nested under dependencies key",
+			input: `{
+				"metadata": {"hosts_analyzed": ["kyushu"]},
+				"dependencies": {"packages": [{"name": "firefox"}], "modules": []}
+			}`,
+			wantPackages: []string{"firefox"},
+		},
+		{
+			name:         "JSON package list",
+			input:        `["git", "openssh", "git", ""]`,
+			wantPackages: []string{"git", "openssh"},
+		},
+		{
+			name: "plain text list",
+			input: `# packages used on CI
+git
+openssh curl  # inline comment
+
+firefox
+`,
+			wantPackages: []string{"git", "openssh", "curl", "firefox"},
+		},
+		{
+			name:    "empty document",
+			input:   "  \n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			input:   `{"packages": [`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got.Packages) != len(tt.wantPackages) {
+				t.Fatalf("Parse() got %d packages (%v), want %d", len(got.Packages), got.Packages, len(tt.wantPackages))
+			}
+			for i, name := range tt.wantPackages {
+				if got.Packages[i].Name != name {
+					t.Errorf("Parse() package[%d] = %q, want %q", i, got.Packages[i].Name, name)
+				}
+			}
+			if len(got.Modules) != tt.wantModules {
+				t.Errorf("Parse() got %d modules, want %d", len(got.Modules), tt.wantModules)
+			}
+			if len(got.Services) != tt.wantServices {
+				t.Errorf("Parse() got %d services, want %d", len(got.Services), tt.wantServices)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deps.txt")
+	if err := os.WriteFile(path, []byte("git\ncurl\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	got, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !got.HasPackage("git") || !got.HasPackage("curl") {
+		t.Errorf("LoadFile() = %+v, want git and curl", got.Packages)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadFile() on missing file should fail")
+	}
+}
@@ -3,9 +3,11 @@ package lazypr
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"go.sbr.pm/x/internal/paths"
 )
 
 // Action represents a custom action that can be executed on a PR.
@@ -44,12 +46,8 @@ func LoadConfig(path string) (*Config, error) {
 
 // DefaultConfigPath returns the default config file path.
 func DefaultConfigPath() string {
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		home, _ := os.UserHomeDir()
-		configDir = home + "/.config"
-	}
-	return configDir + "/lazypr/config.toml"
+	configDir, _ := paths.ConfigDir("lazypr")
+	return filepath.Join(configDir, "config.toml")
 }
 
 // SubstitutePlaceholders replaces placeholders in command with PR data.
@@ -6,20 +6,29 @@ import (
 	"fmt"
 	"os/exec"
 	"time"
+
+	"go.sbr.pm/x/internal/progress"
 )
 
 // Fetcher fetches PR details from GitHub.
 type Fetcher struct {
-	timeout time.Duration
+	timeout  time.Duration
+	progress progress.Progress
 }
 
 // NewFetcher creates a new PR fetcher.
 func NewFetcher() *Fetcher {
 	return &Fetcher{
-		timeout: 30 * time.Second,
+		timeout:  30 * time.Second,
+		progress: progress.Nop(),
 	}
 }
 
+// SetProgress sets where the progress of fetches is reported.
+func (f *Fetcher) SetProgress(p progress.Progress) {
+	f.progress = p
+}
+
 // graphqlResponse represents the response from the GitHub GraphQL API.
 type graphqlResponse struct {
 	Data struct {
@@ -219,6 +228,9 @@ func (f *Fetcher) FetchPRDetail(ref PRRef) (PRDetail, error) {
 
 // FetchPRDetails fetches details for multiple PRs.
 func (f *Fetcher) FetchPRDetails(refs []PRRef) ([]PRDetail, error) {
+	f.progress.Start("Loading PRs", len(refs))
+	defer f.progress.Finish()
+
 	details := make([]PRDetail, 0, len(refs))
 	for _, ref := range refs {
 		detail, err := f.FetchPRDetail(ref)
@@ -226,6 +238,7 @@ func (f *Fetcher) FetchPRDetails(refs []PRRef) ([]PRDetail, error) {
 			return nil, fmt.Errorf("failed to fetch %s: %w", ref.String(), err)
 		}
 		details = append(details, detail)
+		f.progress.Step(1, ref.String())
 	}
 	return details, nil
 }
@@ -237,6 +250,9 @@ func (f *Fetcher) FetchRepoPRs(repo RepoRef, limit int) ([]PRDetail, error) {
 
 // FetchRepoPRsWithFilter fetches PRs from a repository with filter options.
 func (f *Fetcher) FetchRepoPRsWithFilter(repo RepoRef, limit int, filter FilterOptions) ([]PRDetail, error) {
+	f.progress.Start("Loading PRs from "+repo.String(), 0)
+	defer f.progress.Finish()
+
 	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
 	defer cancel()
 
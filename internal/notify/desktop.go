@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DesktopSink shows desktop notifications with notify-send
+type DesktopSink struct {
+	Command string // Defaults to notify-send
+
+	// run runs a command, replaced in tests
+	run func(ctx context.Context, name string, args ...string) error
+}
+
+func (s *DesktopSink) Name() string {
+	return "desktop"
+}
+
+func (s *DesktopSink) Send(ctx context.Context, msg Message, n Notification) error {
+	command := s.Command
+	if command == "" {
+		command = "notify-send"
+	}
+	urgency := "normal"
+	if n.Security {
+		urgency = "critical"
+	}
+	args := []string{"--app-name", "nixpkgs-pr-watch", "--urgency", urgency, msg.Title, msg.Body}
+
+	run := s.run
+	if run == nil {
+		run = runCommand
+	}
+	return run(ctx, command, args...)
+}
+
+// runCommand runs a command, returning its output on failure
+func runCommand(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
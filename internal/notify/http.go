@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// httpTimeout bounds HTTP deliveries
+const httpTimeout = 30 * time.Second
+
+// post sends body to url, failing on non-2xx responses
+func post(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// NtfySink publishes messages to an ntfy topic (https://ntfy.sh)
+type NtfySink struct {
+	URL      string // Topic URL, e.g. https://ntfy.sh/my-topic
+	Token    string // Access token, optional
+	Priority string // Message priority, default "high" for security fixes
+	Client   *http.Client
+}
+
+func (s *NtfySink) Name() string {
+	if u, err := url.Parse(s.URL); err == nil {
+		return "ntfy " + u.Redacted()
+	}
+	return "ntfy " + s.URL
+}
+
+func (s *NtfySink) Send(ctx context.Context, msg Message, n Notification) error {
+	header := http.Header{}
+	// ntfy decodes RFC 2047 encoded headers, for non-ASCII titles
+	header.Set("Title", mime.QEncoding.Encode("utf-8", msg.Title))
+	if n.URL != "" {
+		header.Set("Click", n.URL)
+	}
+	tags := []string{n.Event}
+	if n.Security {
+		tags = append(tags, "warning")
+	}
+	header.Set("Tags", strings.Join(tags, ","))
+	switch {
+	case s.Priority != "":
+		header.Set("Priority", s.Priority)
+	case n.Security:
+		header.Set("Priority", "high")
+	}
+	if s.Token != "" {
+		header.Set("Authorization", "Bearer "+s.Token)
+	}
+	return post(ctx, clientOrDefault(s.Client), s.URL, header, []byte(msg.Body))
+}
+
+// WebhookSink posts messages as JSON to a URL
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// WebhookPayload is the JSON document posted by WebhookSink
+type WebhookPayload struct {
+	Title        string       `json:"title"`
+	Message      string       `json:"message"`
+	Notification Notification `json:"notification"`
+}
+
+// Name identifies the webhook by host and a hash of its URL, as webhook
+// URLs often embed secrets
+func (s *WebhookSink) Name() string {
+	sum := sha256.Sum256([]byte(s.URL))
+	host := s.URL
+	if u, err := url.Parse(s.URL); err == nil {
+		host = u.Host
+	}
+	return fmt.Sprintf("webhook %s (%x)", host, sum[:4])
+}
+
+func (s *WebhookSink) Send(ctx context.Context, msg Message, n Notification) error {
+	body, err := json.Marshal(WebhookPayload{Title: msg.Title, Message: msg.Body, Notification: n})
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	return post(ctx, clientOrDefault(s.Client), s.URL, header, body)
+}
+
+func clientOrDefault(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
@@ -0,0 +1,179 @@
+// Package notify delivers notifications about PRs to sinks such as ntfy,
+// webhooks, desktop notifications and email.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"text/template"
+	"time"
+
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/pr"
+)
+
+// Notification is something to announce about a PR
+type Notification struct {
+	Event      string    `json:"event"` // "new", "status-changed", "merged", "closed"
+	Number     int       `json:"number"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	Confidence string    `json:"confidence,omitempty"` // "high", "medium", "low"
+	Security   bool      `json:"security,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Key identifies a notification for deduplication. Status changes are
+// identified by their detail, as a PR may change status several times.
+func (n Notification) Key() string {
+	key := strconv.Itoa(n.Number) + ":" + n.Event
+	if n.Detail != "" {
+		key += ":" + n.Detail
+	}
+	return key
+}
+
+// Message is a notification rendered for a sink
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Sink delivers messages
+type Sink interface {
+	// Name describes the sink in logs and errors, without secrets
+	Name() string
+	// Send delivers a message about a notification
+	Send(ctx context.Context, msg Message, n Notification) error
+}
+
+// Filter selects the notifications sent to a sink
+type Filter struct {
+	MinConfidence string   // Minimum confidence, empty for any
+	SecurityOnly  bool     // Only security fixes
+	Events        []string // Events to send, empty for all
+}
+
+// Matches reports whether n passes the filter
+func (f Filter) Matches(n Notification) bool {
+	if f.MinConfidence != "" && pr.ConfidenceRank(n.Confidence) < pr.ConfidenceRank(f.MinConfidence) {
+		return false
+	}
+	if f.SecurityOnly && !n.Security {
+		return false
+	}
+	if len(f.Events) > 0 && !slices.Contains(f.Events, n.Event) {
+		return false
+	}
+	return true
+}
+
+// Default message templates
+const (
+	DefaultTitleTemplate = `nixpkgs #{{.Number}}: {{.Title}}`
+	DefaultBodyTemplate  = `{{.Event}}{{with .Detail}} ({{.}}){{end}}{{with .Confidence}}, {{.}} confidence{{end}}{{if .Security}}, security{{end}}
+{{.URL}}`
+)
+
+// Template renders notifications into messages. Templates are Go
+// templates executed with a Notification.
+type Template struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// NewTemplate parses title and body templates, using the defaults for
+// empty ones
+func NewTemplate(title, body string) (*Template, error) {
+	if title == "" {
+		title = DefaultTitleTemplate
+	}
+	if body == "" {
+		body = DefaultBodyTemplate
+	}
+
+	t := &Template{}
+	var err error
+	if t.title, err = template.New("title").Parse(title); err != nil {
+		return nil, fmt.Errorf("failed to parse title template: %w", err)
+	}
+	if t.body, err = template.New("body").Parse(body); err != nil {
+		return nil, fmt.Errorf("failed to parse body template: %w", err)
+	}
+	return t, nil
+}
+
+// Render renders n into a message
+func (t *Template) Render(n Notification) (Message, error) {
+	var title, body bytes.Buffer
+	if err := t.title.Execute(&title, n); err != nil {
+		return Message{}, fmt.Errorf("failed to render title: %w", err)
+	}
+	if err := t.body.Execute(&body, n); err != nil {
+		return Message{}, fmt.Errorf("failed to render body: %w", err)
+	}
+	return Message{Title: title.String(), Body: body.String()}, nil
+}
+
+// Target is a sink with its filter and message template
+type Target struct {
+	Sink     Sink
+	Filter   Filter
+	Template *Template
+}
+
+// Notifier delivers notifications to targets, announcing each one at most
+// once per target
+type Notifier struct {
+	targets []*Target
+	seen    *Seen
+	out     *output.Writer
+}
+
+// New creates a notifier delivering to targets. Delivered notifications are
+// recorded in seen, which may be nil to disable deduplication.
+func New(out *output.Writer, seen *Seen, targets ...*Target) *Notifier {
+	return &Notifier{targets: targets, seen: seen, out: out}
+}
+
+// Notify delivers notifications to every target they pass the filter of,
+// skipping those already delivered. Failures don't stop other deliveries
+// and are returned together; failed notifications are retried next time.
+func (n *Notifier) Notify(ctx context.Context, notifications []Notification) error {
+	var errs []error
+	for _, t := range n.targets {
+		sent := 0
+		for _, notification := range notifications {
+			if !t.Filter.Matches(notification) {
+				continue
+			}
+			key := t.Sink.Name() + " " + notification.Key()
+			if n.seen != nil && n.seen.Has(key) {
+				n.out.Debug("Already notified %s", key)
+				continue
+			}
+
+			msg, err := t.Template.Render(notification)
+			if err == nil {
+				err = t.Sink.Send(ctx, msg, notification)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to notify %s of #%d: %w", t.Sink.Name(), notification.Number, err))
+				continue
+			}
+			if n.seen != nil {
+				n.seen.Add(key, notification.Time)
+			}
+			sent++
+		}
+		if sent > 0 {
+			n.out.Verbose("Sent %d notifications to %s", sent, t.Sink.Name())
+		}
+	}
+	return errors.Join(errs...)
+}
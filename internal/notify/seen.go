@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.sbr.pm/x/internal/atomicfile"
+)
+
+// seenRetention is how long delivered notifications are remembered
+const seenRetention = 90 * 24 * time.Hour
+
+// Seen records delivered notifications, so a PR is never announced twice
+type Seen struct {
+	path      string
+	announced map[string]time.Time
+}
+
+// LoadSeen loads the delivered notifications recorded at path, which
+// doesn't need to exist yet
+func LoadSeen(path string) (*Seen, error) {
+	s := &Seen{path: path, announced: make(map[string]time.Time)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.announced); err != nil {
+		return nil, fmt.Errorf("failed to parse notification state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Has reports whether the notification identified by key was delivered
+func (s *Seen) Has(key string) bool {
+	_, ok := s.announced[key]
+	return ok
+}
+
+// Add records the notification identified by key as delivered at t
+func (s *Seen) Add(key string, t time.Time) {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	s.announced[key] = t
+}
+
+// Save writes the delivered notifications, forgetting old ones
+func (s *Seen) Save() error {
+	for key, t := range s.announced {
+		if time.Since(t) > seenRetention {
+			delete(s.announced, key)
+		}
+	}
+
+	data, err := json.MarshalIndent(s.announced, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode notification state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create notification state directory: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write notification state: %w", err)
+	}
+	return nil
+}
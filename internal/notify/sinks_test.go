@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNtfySink(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer server.Close()
+
+	sink := &NtfySink{URL: server.URL + "/my-topic", Token: "tk_secret"}
+	msg := Message{Title: "nixpkgs #42: openssl 🔒", Body: "new\nhttps://github.com/NixOS/nixpkgs/pull/42"}
+	if err := sink.Send(context.Background(), msg, testNotification()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got.Method != http.MethodPost || got.URL.Path != "/my-topic" {
+		t.Errorf("request = %s %s, want POST /my-topic", got.Method, got.URL.Path)
+	}
+	if body != msg.Body {
+		t.Errorf("body = %q, want %q", body, msg.Body)
+	}
+	want := map[string]string{
+		"Title":         "=?utf-8?q?nixpkgs_#42:_openssl_=F0=9F=94=92?=",
+		"Click":         "https://github.com/NixOS/nixpkgs/pull/42",
+		"Tags":          "new,warning",
+		"Priority":      "high",
+		"Authorization": "Bearer tk_secret",
+	}
+	for header, value := range want {
+		if got.Header.Get(header) != value {
+			t.Errorf("header %s = %q, want %q", header, got.Header.Get(header), value)
+		}
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var payload WebhookPayload
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	if err := sink.Send(context.Background(), Message{Title: "title", Body: "body"}, testNotification()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if payload.Title != "title" || payload.Message != "body" || payload.Notification.Number != 42 || !payload.Notification.Security {
+		t.Errorf("payload = %+v", payload)
+	}
+	if strings.Contains(sink.Name(), server.URL) {
+		t.Errorf("Name() = %q should not contain the URL", sink.Name())
+	}
+}
+
+func TestWebhookSink_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	err := sink.Send(context.Background(), Message{}, testNotification())
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden: invalid token") {
+		t.Errorf("Send() error = %v, want status error", err)
+	}
+}
+
+// fakeSMTPServer accepts a single mail delivery and sends what it received
+// to the returned channel
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var transcript strings.Builder
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		reply("220 localhost ESMTP")
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			transcript.WriteString(line)
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case inData:
+				if cmd == "." {
+					inData = false
+					reply("250 OK")
+				}
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				received <- transcript.String()
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+		received <- transcript.String()
+	}()
+
+	return l.Addr().String(), received
+}
+
+func TestSMTPSink(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+
+	sink := &SMTPSink{Addr: addr, From: "watch@example.com", To: []string{"me@example.com"}}
+	msg := Message{Title: "nixpkgs #42: openssl", Body: "new\nhttps://github.com/NixOS/nixpkgs/pull/42"}
+	if err := sink.Send(context.Background(), msg, testNotification()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := <-received
+	for _, want := range []string{
+		"MAIL FROM:<watch@example.com>",
+		"RCPT TO:<me@example.com>",
+		"Subject: nixpkgs #42: openssl\r\n",
+		"To: me@example.com\r\n",
+		"\r\nnew\r\nhttps://github.com/NixOS/nixpkgs/pull/42\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SMTP transcript missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSMTPSink_Cancel(t *testing.T) {
+	// A server that never greets would block the delivery forever
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+	t.Cleanup(func() {
+		l.Close()
+		if conn, ok := <-accepted; ok {
+			conn.Close()
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	sink := &SMTPSink{Addr: l.Addr().String(), From: "watch@example.com", To: []string{"me@example.com"}}
+	err = sink.Send(ctx, Message{Title: "nixpkgs #42"}, testNotification())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
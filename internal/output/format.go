@@ -0,0 +1,342 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in output formats
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatYAML     = "yaml"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatTemplate = "template"
+)
+
+// builtinFormats lists the built-in formats, in the order they are shown
+var builtinFormats = []string{
+	FormatTable, FormatJSON, FormatNDJSON, FormatYAML, FormatCSV, FormatMarkdown, FormatTemplate,
+}
+
+// FormatOptions selects how a result is rendered
+type FormatOptions struct {
+	// Format is the name of a built-in or registered format
+	Format string
+
+	// Template is a Go text/template executed for each item. Setting it
+	// implies the template format.
+	Template string
+}
+
+// Column describes a column of the table, CSV and Markdown formats
+type Column[I any] struct {
+	Name  string
+	Value func(I) string
+}
+
+// Spec describes how a result of type T, made of items of type I, is
+// rendered
+type Spec[T, I any] struct {
+	// Items returns the items of a result, rendered one per row, line or
+	// template execution. By default the result is its only item.
+	Items func(T) []I
+
+	// Columns are the columns of the table, CSV and Markdown formats
+	Columns []Column[I]
+
+	// Custom holds formats specific to T, by name
+	Custom map[string]func(io.Writer, T) error
+}
+
+// renderer is a Spec with its types erased
+type renderer struct {
+	items   func(any) []any
+	columns []string
+	row     func(any) []string
+	custom  map[string]func(io.Writer, any) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[reflect.Type]*renderer)
+)
+
+// Register registers how results of type T are rendered, making every
+// built-in format available for them
+func Register[T, I any](spec Spec[T, I]) {
+	r := &renderer{
+		custom: make(map[string]func(io.Writer, any) error),
+	}
+
+	r.items = func(v any) []any {
+		t := v.(T)
+		if spec.Items == nil {
+			if item, ok := any(t).(I); ok {
+				return []any{item}
+			}
+			return nil
+		}
+		items := spec.Items(t)
+		result := make([]any, len(items))
+		for i, item := range items {
+			result[i] = item
+		}
+		return result
+	}
+
+	for _, col := range spec.Columns {
+		r.columns = append(r.columns, col.Name)
+	}
+	r.row = func(item any) []string {
+		values := make([]string, len(spec.Columns))
+		for i, col := range spec.Columns {
+			values[i] = col.Value(item.(I))
+		}
+		return values
+	}
+
+	for name, fn := range spec.Custom {
+		r.custom[name] = func(w io.Writer, v any) error {
+			return fn(w, v.(T))
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[reflect.TypeFor[T]()] = r
+}
+
+// lookup returns the renderer registered for the type of v, if any
+func lookup(v any) *renderer {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[reflect.TypeOf(v)]
+}
+
+// Formats returns the formats available for v: the built-in formats,
+// followed by the custom formats registered for its type
+func Formats(v any) []string {
+	formats := append([]string{}, builtinFormats...)
+	if r := lookup(v); r != nil {
+		var custom []string
+		for name := range r.custom {
+			custom = append(custom, name)
+		}
+		sort.Strings(custom)
+		formats = append(formats, custom...)
+	}
+	return formats
+}
+
+// Render writes v to w in the format selected by opts. Types that were not
+// registered only support the JSON, NDJSON, YAML and template formats.
+func Render(w io.Writer, opts FormatOptions, v any) error {
+	format := opts.Format
+	if opts.Template != "" {
+		format = FormatTemplate
+	}
+
+	r := lookup(v)
+	if r != nil {
+		if fn, ok := r.custom[format]; ok {
+			return fn(w, v)
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatYAML:
+		return renderYAML(w, v)
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, item := range itemsOf(r, v) {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatTemplate:
+		if opts.Template == "" {
+			return fmt.Errorf("the template format requires a template (--format)")
+		}
+		return renderTemplate(w, opts.Template, itemsOf(r, v))
+	case FormatTable, FormatCSV, FormatMarkdown:
+		if r == nil || len(r.columns) == 0 {
+			return fmt.Errorf("format %q is not supported for %T", format, v)
+		}
+		rows := make([][]string, 0)
+		for _, item := range r.items(v) {
+			rows = append(rows, r.row(item))
+		}
+		switch format {
+		case FormatTable:
+			return renderTable(w, r.columns, rows)
+		case FormatCSV:
+			return renderCSV(w, r.columns, rows)
+		default:
+			return renderMarkdown(w, r.columns, rows)
+		}
+	default:
+		return fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(Formats(v), ", "))
+	}
+}
+
+// itemsOf returns the items of v: the registered ones, the elements of a
+// slice, or v itself
+func itemsOf(r *renderer, v any) []any {
+	if r != nil {
+		return r.items(v)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return items
+	}
+	return []any{v}
+}
+
+// renderYAML writes v as YAML. It goes through JSON so field names and
+// omitempty options match the JSON format, keeping the field order.
+func renderYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is YAML, but flow style and quoting must be reset to get block
+	// style output
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// resetStyle clears the style of node and its children, keeping strings
+// that would otherwise be read back as another type quoted, and writing
+// multi-line strings as literal blocks
+func resetStyle(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		node.Style = 0
+		var decoded any
+		if strings.Contains(node.Value, "\n") {
+			node.Style = yaml.LiteralStyle
+		} else if yaml.Unmarshal([]byte(node.Value), &decoded) != nil || decoded != node.Value {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	} else {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// templateFuncs are the functions available to templates
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// renderTemplate executes tmpl for each item, one per line
+func renderTemplate(w io.Writer, tmpl string, items []any) error {
+	t, err := template.New("format").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, item); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderTable writes aligned columns with upper-case headers
+func renderTable(w io.Writer, columns []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = strings.ToUpper(col)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(cell)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// renderCSV writes a header and one record per row
+func renderCSV(w io.Writer, columns []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// renderMarkdown writes a Markdown table
+func renderMarkdown(w io.Writer, columns []string, rows [][]string) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escape.Replace(cell)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,202 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type testResult struct {
+	Title string     `json:"title"`
+	Items []testItem `json:"items"`
+}
+
+func init() {
+	Register(Spec[testResult, testItem]{
+		Items: func(r testResult) []testItem { return r.Items },
+		Columns: []Column[testItem]{
+			{Name: "name", Value: func(i testItem) string { return i.Name }},
+			{Name: "count", Value: func(i testItem) string { return fmt.Sprint(i.Count) }},
+		},
+		Custom: map[string]func(io.Writer, testResult) error{
+			"names": func(w io.Writer, r testResult) error {
+				for _, i := range r.Items {
+					fmt.Fprintln(w, i.Name)
+				}
+				return nil
+			},
+		},
+	})
+}
+
+func TestRender(t *testing.T) {
+	result := testResult{
+		Title: "results",
+		Items: []testItem{
+			{Name: "hello", Count: 1},
+			{Name: "a|b, c", Count: 22},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts FormatOptions
+		want string
+	}{
+		{
+			name: "table",
+			opts: FormatOptions{Format: FormatTable},
+			want: "NAME    COUNT\nhello   1\na|b, c  22\n",
+		},
+		{
+			name: "json",
+			opts: FormatOptions{Format: FormatJSON},
+			want: `{
+  "title": "results",
+  "items": [
+    {
+      "name": "hello",
+      "count": 1
+    },
+    {
+      "name": "a|b, c",
+      "count": 22
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "ndjson",
+			opts: FormatOptions{Format: FormatNDJSON},
+			want: "{\"name\":\"hello\",\"count\":1}\n{\"name\":\"a|b, c\",\"count\":22}\n",
+		},
+		{
+			name: "yaml",
+			opts: FormatOptions{Format: FormatYAML},
+			want: `title: results
+items:
+  - name: hello
+    count: 1
+  - name: a|b, c
+    count: 22
+`,
+		},
+		{
+			name: "csv",
+			opts: FormatOptions{Format: FormatCSV},
+			want: "name,count\nhello,1\n\"a|b, c\",22\n",
+		},
+		{
+			name: "markdown",
+			opts: FormatOptions{Format: FormatMarkdown},
+			want: "| name | count |\n| --- | --- |\n| hello | 1 |\n| a\\|b, c | 22 |\n",
+		},
+		{
+			name: "template",
+			opts: FormatOptions{Template: "{{.Name | upper}}={{.Count}}"},
+			want: "HELLO=1\nA|B, C=22\n",
+		},
+		{
+			name: "custom",
+			opts: FormatOptions{Format: "names"},
+			want: "hello\na|b, c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, tt.opts, result); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Render() =\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_Unregistered(t *testing.T) {
+	items := []testItem{{Name: "a", Count: 1}, {Name: "b", Count: 2}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatOptions{Format: FormatNDJSON}, items); err != nil {
+		t.Fatalf("Render(ndjson) error = %v", err)
+	}
+	if want := "{\"name\":\"a\",\"count\":1}\n{\"name\":\"b\",\"count\":2}\n"; buf.String() != want {
+		t.Errorf("Render(ndjson) = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := Render(&buf, FormatOptions{Template: "{{.Name}}"}, items); err != nil {
+		t.Fatalf("Render(template) error = %v", err)
+	}
+	if want := "a\nb\n"; buf.String() != want {
+		t.Errorf("Render(template) = %q, want %q", buf.String(), want)
+	}
+
+	if err := Render(&buf, FormatOptions{Format: FormatTable}, items); err == nil {
+		t.Error("Render(table) expected an error for an unregistered type")
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FormatOptions
+		want string
+	}{
+		{name: "unknown format", opts: FormatOptions{Format: "xml"}, want: "unknown output format"},
+		{name: "missing template", opts: FormatOptions{Format: FormatTemplate}, want: "requires a template"},
+		{name: "invalid template", opts: FormatOptions{Template: "{{.Name"}, want: "failed to parse template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Render(io.Discard, tt.opts, testResult{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Render() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderYAML_Quoting(t *testing.T) {
+	v := map[string]string{"a": "true", "b": "123", "c": "", "d": "line1\nline2", "e": "plain"}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatOptions{Format: FormatYAML}, v); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := `a: "true"
+b: "123"
+c: ""
+d: |-
+  line1
+  line2
+e: plain
+`
+	if buf.String() != want {
+		t.Errorf("Render() =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	got := strings.Join(Formats(testResult{}), ",")
+	if want := "table,json,ndjson,yaml,csv,markdown,template,names"; got != want {
+		t.Errorf("Formats() = %q, want %q", got, want)
+	}
+	got = strings.Join(Formats(42), ",")
+	if want := "table,json,ndjson,yaml,csv,markdown,template"; got != want {
+		t.Errorf("Formats() = %q, want %q", got, want)
+	}
+}
@@ -4,6 +4,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
 )
 
 // Color codes for terminal output
@@ -12,69 +19,307 @@ const (
 	Green  = "\033[0;32m"
 	Yellow = "\033[1;33m"
 	Blue   = "\033[0;34m"
+	Gray   = "\033[0;90m"
 	Reset  = "\033[0m"
 )
 
+// clearLine moves to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+// DefaultWidth is the width assumed when the output is not a terminal
+const DefaultWidth = 80
+
+// Level controls which messages are printed
+type Level int
+
+const (
+	// LevelQuiet only prints results, warnings and errors
+	LevelQuiet Level = iota - 1
+	// LevelNormal also prints informational messages
+	LevelNormal
+	// LevelVerbose also prints verbose messages
+	LevelVerbose
+	// LevelDebug prints everything
+	LevelDebug
+)
+
 // Writer provides colored output methods
 type Writer struct {
-	out    io.Writer
-	err    io.Writer
-	colors bool
+	out       io.Writer
+	err       io.Writer
+	outColors bool
+	errColors bool
+	level     Level
+	width     int
+
+	mu  sync.Mutex
+	log io.WriteCloser
+
+	// termMu guards writes to the streams while a status line is shown
+	termMu        sync.Mutex
+	status        string
+	statusEnabled bool
 }
 
 // NewWriter creates a new output writer
 func NewWriter(out, err io.Writer, colors bool) *Writer {
 	return &Writer{
-		out:    out,
-		err:    err,
-		colors: colors,
+		out:       out,
+		err:       err,
+		outColors: colors,
+		errColors: colors,
 	}
 }
 
-// Default creates a writer that outputs to stdout/stderr with colors
+// Default creates a writer that outputs to stdout/stderr, with colors on
+// each stream only if it is a terminal (see ColorEnabled)
 func Default() *Writer {
-	return NewWriter(os.Stdout, os.Stderr, true)
+	w := NewWriter(os.Stdout, os.Stderr, false)
+	w.outColors = ColorEnabled(os.Stdout)
+	w.errColors = ColorEnabled(os.Stderr)
+	w.width = TerminalWidth(os.Stdout)
+	w.statusEnabled = IsTerminal(os.Stderr) && os.Getenv("TERM") != "dumb"
+	return w
+}
+
+// ColorEnabled reports whether colors should be used when writing to f.
+// NO_COLOR disables colors and FORCE_COLOR enables them, otherwise colors
+// are used for terminals, unless TERM is "dumb".
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" {
+		return force != "0" && force != "false"
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// TerminalWidth returns the width of the terminal f, or 0 if f is not a
+// terminal. COLUMNS overrides the detected width.
+func TerminalWidth(f *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if !IsTerminal(f) {
+		return 0
+	}
+	width, _, err := term.GetSize(f.Fd())
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// SetWidth sets the width of the output, 0 meaning DefaultWidth
+func (w *Writer) SetWidth(width int) {
+	w.width = width
+}
+
+// Width returns the width of the output terminal, or DefaultWidth when it
+// is unknown
+func (w *Writer) Width() int {
+	if w.width <= 0 {
+		return DefaultWidth
+	}
+	return w.width
+}
+
+// SetLevel sets which messages are printed
+func (w *Writer) SetLevel(level Level) {
+	w.level = level
+}
+
+// Level returns the current level
+func (w *Writer) Level() Level {
+	return w.level
+}
+
+// SetColors enables or disables colors on both streams
+func (w *Writer) SetColors(colors bool) {
+	w.outColors = colors
+	w.errColors = colors
+}
+
+// Colors reports whether colors are enabled on the output stream
+func (w *Writer) Colors() bool {
+	return w.outColors
+}
+
+// EnableStatus enables or disables the status line (see SetStatus)
+func (w *Writer) EnableStatus(enabled bool) {
+	w.termMu.Lock()
+	defer w.termMu.Unlock()
+	w.statusEnabled = enabled
+}
+
+// StatusEnabled reports whether a status line can be shown, which is the
+// case by default when stderr is a terminal
+func (w *Writer) StatusEnabled() bool {
+	w.termMu.Lock()
+	defer w.termMu.Unlock()
+	return w.statusEnabled
+}
+
+// SetStatus shows line as a transient status line at the bottom of stderr,
+// replacing the previous one. Other messages are printed above it. An empty
+// line clears it. It does nothing unless the status line is enabled.
+func (w *Writer) SetStatus(line string) {
+	w.termMu.Lock()
+	defer w.termMu.Unlock()
+
+	if !w.statusEnabled || line == w.status {
+		return
+	}
+	fmt.Fprint(w.err, clearLine+line)
+	w.status = line
+}
+
+// write writes text to dst, keeping the status line, if any, below it
+func (w *Writer) write(dst io.Writer, text string) {
+	w.termMu.Lock()
+	defer w.termMu.Unlock()
+
+	if w.status == "" {
+		fmt.Fprint(dst, text)
+		return
+	}
+	fmt.Fprint(w.err, clearLine)
+	fmt.Fprint(dst, text)
+	fmt.Fprint(w.err, w.status)
+}
+
+// SetLogFile mirrors all messages, whatever the level, with timestamps to
+// the file at path. Messages are appended, and the file and its parent
+// directories are created if needed.
+func (w *Writer) SetLogFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	w.SetLog(f)
+	return nil
+}
+
+// SetLog mirrors all messages with timestamps to log, closing any previous
+// log. A nil log disables logging.
+func (w *Writer) SetLog(log io.WriteCloser) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.log != nil {
+		w.log.Close()
+	}
+	w.log = log
+}
+
+// Close closes the log file, if any
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.log == nil {
+		return nil
+	}
+	err := w.log.Close()
+	w.log = nil
+	return err
 }
 
 // colorize wraps text in color codes if colors are enabled
-func (w *Writer) colorize(color, text string) string {
-	if !w.colors {
+func colorize(enabled bool, color, text string) string {
+	if !enabled {
 		return text
 	}
 	return color + text + Reset
 }
 
+// logf mirrors a message to the log file
+func (w *Writer) logf(tag, msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.log == nil {
+		return
+	}
+	timestamp := time.Now().Format(time.RFC3339)
+	for _, line := range strings.Split(strings.TrimRight(msg, "\n"), "\n") {
+		fmt.Fprintf(w.log, "%s %-5s %s\n", timestamp, tag, line)
+	}
+}
+
 // Info prints an informational message to stderr
 func (w *Writer) Info(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintln(w.err, w.colorize(Blue, msg))
+	w.logf("INFO", msg)
+	if w.level < LevelNormal {
+		return
+	}
+	w.write(w.err, colorize(w.errColors, Blue, msg)+"\n")
+}
+
+// Verbose prints a message to stderr with -v or more
+func (w *Writer) Verbose(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	w.logf("INFO", msg)
+	if w.level < LevelVerbose {
+		return
+	}
+	w.write(w.err, colorize(w.errColors, Gray, msg)+"\n")
+}
+
+// Debug prints a message to stderr with -vv
+func (w *Writer) Debug(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	w.logf("DEBUG", msg)
+	if w.level < LevelDebug {
+		return
+	}
+	w.write(w.err, colorize(w.errColors, Gray, msg)+"\n")
 }
 
 // Success prints a success message
 func (w *Writer) Success(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintln(w.out, w.colorize(Green, msg))
+	w.logf("OK", msg)
+	w.write(w.out, colorize(w.outColors, Green, msg)+"\n")
 }
 
 // Warning prints a warning message
 func (w *Writer) Warning(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintln(w.err, w.colorize(Yellow, msg))
+	w.logf("WARN", msg)
+	w.write(w.err, colorize(w.errColors, Yellow, msg)+"\n")
 }
 
 // Error prints an error message
 func (w *Writer) Error(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintln(w.err, w.colorize(Red, msg))
+	w.logf("ERROR", msg)
+	w.write(w.err, colorize(w.errColors, Red, msg)+"\n")
 }
 
 // Print prints a message without color
 func (w *Writer) Print(format string, args ...interface{}) {
-	fmt.Fprintf(w.out, format, args...)
+	w.write(w.out, fmt.Sprintf(format, args...))
 }
 
 // Println prints a message with newline without color
 func (w *Writer) Println(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintln(w.out, msg)
+	w.write(w.out, fmt.Sprintf(format, args...)+"\n")
 }
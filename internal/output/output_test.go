@@ -0,0 +1,187 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriter_Levels(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    Level
+		wantOut  string
+		wantErrs []string
+		notErrs  []string
+	}{
+		{
+			name:     "quiet",
+			level:    LevelQuiet,
+			wantOut:  "done\n",
+			wantErrs: []string{"careful", "failed"},
+			notErrs:  []string{"info", "verbose", "debug"},
+		},
+		{
+			name:     "normal",
+			level:    LevelNormal,
+			wantOut:  "done\n",
+			wantErrs: []string{"info", "careful", "failed"},
+			notErrs:  []string{"verbose", "debug"},
+		},
+		{
+			name:     "verbose",
+			level:    LevelVerbose,
+			wantOut:  "done\n",
+			wantErrs: []string{"info", "verbose", "careful", "failed"},
+			notErrs:  []string{"debug"},
+		},
+		{
+			name:     "debug",
+			level:    LevelDebug,
+			wantOut:  "done\n",
+			wantErrs: []string{"info", "verbose", "debug", "careful", "failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			w := NewWriter(&stdout, &stderr, false)
+			w.SetLevel(tt.level)
+
+			w.Info("info")
+			w.Verbose("verbose")
+			w.Debug("debug")
+			w.Success("done")
+			w.Warning("careful")
+			w.Error("failed")
+
+			if stdout.String() != tt.wantOut {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantOut)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr = %q, want %q", stderr.String(), want)
+				}
+			}
+			for _, notWant := range tt.notErrs {
+				if strings.Contains(stderr.String(), notWant) {
+					t.Errorf("stderr = %q, should not contain %q", stderr.String(), notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestWriter_Colors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	w := NewWriter(&stdout, &stderr, false)
+	w.Success("plain")
+	if strings.Contains(stdout.String(), "\033[") {
+		t.Errorf("stdout without colors = %q", stdout.String())
+	}
+
+	stdout.Reset()
+	w.SetColors(true)
+	w.Success("colored")
+	if stdout.String() != Green+"colored"+Reset+"\n" {
+		t.Errorf("stdout with colors = %q", stdout.String())
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	// A regular file is never a terminal
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatalf("CreateTemp() error = %v", err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name     string
+		noColor  string
+		force    string
+		expected bool
+	}{
+		{name: "not a terminal", expected: false},
+		{name: "force color", force: "1", expected: true},
+		{name: "force color disabled", force: "0", expected: false},
+		{name: "no color wins", noColor: "1", force: "1", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("FORCE_COLOR", tt.force)
+			if got := ColorEnabled(f); got != tt.expected {
+				t.Errorf("ColorEnabled() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriter_SetLogFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	w := NewWriter(&stdout, &stderr, true)
+	w.SetLevel(LevelQuiet)
+
+	path := filepath.Join(t.TempDir(), "logs", "tool.log")
+	if err := w.SetLogFile(path); err != nil {
+		t.Fatalf("SetLogFile() error = %v", err)
+	}
+
+	w.Info("hidden on the terminal")
+	w.Debug("debug details")
+	w.Warning("two\nlines")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("log has %d lines, want 4: %q", len(lines), data)
+	}
+	for i, want := range []string{"INFO  hidden on the terminal", "DEBUG debug details", "WARN  two", "WARN  lines"} {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("log line %d = %q, want suffix %q", i, lines[i], want)
+		}
+	}
+	if strings.Contains(string(data), "\033[") {
+		t.Error("log file should not contain color codes")
+	}
+}
+
+func TestWriter_Status(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	w := NewWriter(&stdout, &stderr, false)
+
+	// Disabled by default without a terminal
+	w.SetStatus("working")
+	if stderr.Len() != 0 {
+		t.Errorf("status shown while disabled: %q", stderr.String())
+	}
+
+	w.EnableStatus(true)
+	w.SetStatus("working")
+	w.Warning("careful")
+	w.Println("result")
+	w.SetStatus("")
+
+	want := "\r\033[Kworking" +
+		"\r\033[Kcareful\nworking" +
+		"\r\033[Kworking" +
+		"\r\033[K"
+	if stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+	if stdout.String() != "result\n" {
+		t.Errorf("stdout = %q, want %q", stdout.String(), "result\n")
+	}
+}
@@ -0,0 +1,109 @@
+// Package paths resolves where tools store their cache, configuration,
+// state and data, following the XDG Base Directory specification.
+//
+// Each directory can be overridden per tool with an environment variable
+// named after the tool, e.g. NIXPKGS_PR_WATCH_CACHE_DIR or LAZYPR_STATE_DIR.
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kind is a kind of directory
+type Kind string
+
+const (
+	Cache  Kind = "cache"
+	Config Kind = "config"
+	State  Kind = "state"
+	Data   Kind = "data"
+)
+
+// Kinds lists all kinds of directories
+var Kinds = []Kind{Cache, Config, State, Data}
+
+// xdg maps each kind to its XDG environment variable and default location
+// relative to the home directory
+var xdg = map[Kind]struct {
+	env      string
+	fallback string
+}{
+	Cache:  {env: "XDG_CACHE_HOME", fallback: ".cache"},
+	Config: {env: "XDG_CONFIG_HOME", fallback: ".config"},
+	State:  {env: "XDG_STATE_HOME", fallback: filepath.Join(".local", "state")},
+	Data:   {env: "XDG_DATA_HOME", fallback: filepath.Join(".local", "share")},
+}
+
+// Dir returns the directory of the given kind for tool, without creating it.
+//
+// It is, in order of precedence: the tool override (<TOOL>_<KIND>_DIR),
+// $XDG_<KIND>_HOME/<tool>, or the XDG default under the home directory.
+// Relative XDG values are ignored, as required by the specification.
+func Dir(tool string, kind Kind) (string, error) {
+	if dir := os.Getenv(OverrideEnv(tool, kind)); dir != "" {
+		return dir, nil
+	}
+
+	spec, ok := xdg[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown directory kind %q", kind)
+	}
+
+	if base := os.Getenv(spec.env); filepath.IsAbs(base) {
+		return filepath.Join(base, tool), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return filepath.Join(home, spec.fallback, tool), nil
+}
+
+// CacheDir returns the cache directory of tool
+func CacheDir(tool string) (string, error) {
+	return Dir(tool, Cache)
+}
+
+// ConfigDir returns the configuration directory of tool
+func ConfigDir(tool string) (string, error) {
+	return Dir(tool, Config)
+}
+
+// StateDir returns the state directory of tool, for logs and history
+func StateDir(tool string) (string, error) {
+	return Dir(tool, State)
+}
+
+// DataDir returns the data directory of tool
+func DataDir(tool string) (string, error) {
+	return Dir(tool, Data)
+}
+
+// Ensure returns the directory of the given kind for tool, creating it if
+// needed
+func Ensure(tool string, kind Kind) (string, error) {
+	dir, err := Dir(tool, kind)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s directory: %w", kind, err)
+	}
+	return dir, nil
+}
+
+// OverrideEnv returns the environment variable overriding the directory of
+// the given kind for tool (e.g. "GH_PR_CACHE_DIR" for gh-pr's cache)
+func OverrideEnv(tool string, kind Kind) string {
+	name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(tool))
+	return fmt.Sprintf("%s_%s_DIR", name, strings.ToUpper(string(kind)))
+}
+
+// XDGEnv returns the XDG environment variable for the given kind
+func XDGEnv(kind Kind) string {
+	return xdg[kind].env
+}
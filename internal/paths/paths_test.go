@@ -0,0 +1,104 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	home := t.TempDir()
+
+	tests := []struct {
+		name string
+		kind Kind
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "cache default",
+			kind: Cache,
+			want: filepath.Join(home, ".cache", "my-tool"),
+		},
+		{
+			name: "config default",
+			kind: Config,
+			want: filepath.Join(home, ".config", "my-tool"),
+		},
+		{
+			name: "state default",
+			kind: State,
+			want: filepath.Join(home, ".local", "state", "my-tool"),
+		},
+		{
+			name: "data default",
+			kind: Data,
+			want: filepath.Join(home, ".local", "share", "my-tool"),
+		},
+		{
+			name: "xdg cache home",
+			kind: Cache,
+			env:  map[string]string{"XDG_CACHE_HOME": "/xdg/cache"},
+			want: "/xdg/cache/my-tool",
+		},
+		{
+			name: "xdg state home",
+			kind: State,
+			env:  map[string]string{"XDG_STATE_HOME": "/xdg/state"},
+			want: "/xdg/state/my-tool",
+		},
+		{
+			name: "relative xdg home ignored",
+			kind: Config,
+			env:  map[string]string{"XDG_CONFIG_HOME": "relative/config"},
+			want: filepath.Join(home, ".config", "my-tool"),
+		},
+		{
+			name: "tool override wins",
+			kind: Cache,
+			env: map[string]string{
+				"XDG_CACHE_HOME":    "/xdg/cache",
+				"MY_TOOL_CACHE_DIR": "/override",
+			},
+			want: "/override",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			for _, kind := range Kinds {
+				t.Setenv(XDGEnv(kind), "")
+				t.Setenv(OverrideEnv("my-tool", kind), "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			got, err := Dir("my-tool", tt.kind)
+			if err != nil {
+				t.Fatalf("Dir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Dir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverrideEnv(t *testing.T) {
+	tests := []struct {
+		tool string
+		kind Kind
+		want string
+	}{
+		{tool: "lazypr", kind: State, want: "LAZYPR_STATE_DIR"},
+		{tool: "gh-pr", kind: Cache, want: "GH_PR_CACHE_DIR"},
+		{tool: "nixpkgs-pr-watch", kind: Config, want: "NIXPKGS_PR_WATCH_CONFIG_DIR"},
+	}
+
+	for _, tt := range tests {
+		if got := OverrideEnv(tt.tool, tt.kind); got != tt.want {
+			t.Errorf("OverrideEnv(%q, %q) = %q, want %q", tt.tool, tt.kind, got, tt.want)
+		}
+	}
+}
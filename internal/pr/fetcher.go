@@ -3,16 +3,27 @@ package pr
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
+
+	"go.sbr.pm/x/internal/output"
+	"go.sbr.pm/x/internal/progress"
 )
 
+// ErrTooManyUpdates is returned by FetchNixpkgsPRsUpdatedSince when more
+// PRs were updated than requested, and fetching them all is cheaper
+var ErrTooManyUpdates = errors.New("too many updated PRs")
+
 // Fetcher fetches pull requests from GitHub
 type Fetcher struct {
 	rateLimiter *RateLimiter
+	out         *output.Writer
+	progress    progress.Progress
+	ctx         context.Context
 }
 
 // NewFetcher creates a new PR fetcher with default rate limiting
@@ -20,9 +31,28 @@ type Fetcher struct {
 func NewFetcher() *Fetcher {
 	return &Fetcher{
 		rateLimiter: NewRateLimiter(100*time.Millisecond, 1.5, 5*time.Second),
+		out:         output.NewWriter(os.Stdout, os.Stderr, false),
+		progress:    progress.Nop(),
+		ctx:         context.Background(),
 	}
 }
 
+// SetOutput sets where retry messages are printed
+func (f *Fetcher) SetOutput(out *output.Writer) {
+	f.out = out
+}
+
+// SetProgress sets where the progress of batched fetches is reported
+func (f *Fetcher) SetProgress(p progress.Progress) {
+	f.progress = p
+}
+
+// SetContext sets the context cancelling the gh commands of the fetcher,
+// and the waits between retries
+func (f *Fetcher) SetContext(ctx context.Context) {
+	f.ctx = ctx
+}
+
 // ghPR represents a PR as returned by gh CLI
 type ghPR struct {
 	Number      int       `json:"number"`
@@ -54,7 +84,7 @@ type ghFile struct {
 // PRs are returned sorted by creation date descending (newest first).
 func (f *Fetcher) FetchNixpkgsPRs(limit int) ([]PullRequest, error) {
 	// Use context with timeout to prevent hanging
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(f.ctx, 60*time.Second)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "gh", "pr", "list",
@@ -113,6 +143,68 @@ func (f *Fetcher) FetchNixpkgsPRs(limit int) ([]PullRequest, error) {
 	return prs, nil
 }
 
+// FetchNixpkgsPRState returns the state of a NixOS/nixpkgs PR: OPEN,
+// CLOSED or MERGED.
+func (f *Fetcher) FetchNixpkgsPRState(number int) (string, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "gh", "pr", "view", fmt.Sprintf("%d", number),
+		"--repo", "NixOS/nixpkgs",
+		"--json", "state")
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("gh CLI failed: %s", string(exitErr.Stderr))
+		}
+		return "", fmt.Errorf("failed to run gh CLI: %w", err)
+	}
+
+	var result struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", fmt.Errorf("failed to parse PR state: %w", err)
+	}
+	return result.State, nil
+}
+
+// SubscribeNixpkgsPR subscribes the authenticated user to notifications of
+// a NixOS/nixpkgs PR, as the Subscribe button on GitHub does.
+func (f *Fetcher) SubscribeNixpkgsPR(number int) error {
+	ctx, cancel := context.WithTimeout(f.ctx, 30*time.Second)
+	defer cancel()
+
+	idCmd := exec.CommandContext(ctx, "gh", "pr", "view", fmt.Sprintf("%d", number),
+		"--repo", "NixOS/nixpkgs",
+		"--json", "id",
+		"--jq", ".id")
+	id, err := idCmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("gh CLI failed: %s", string(exitErr.Stderr))
+		}
+		return fmt.Errorf("failed to run gh CLI: %w", err)
+	}
+
+	mutation := `mutation($id: ID!) {
+  updateSubscription(input: {subscribableId: $id, state: SUBSCRIBED}) {
+    subscribable { viewerSubscription }
+  }
+}`
+	cmd := exec.CommandContext(ctx, "gh", "api", "graphql",
+		"-f", "query="+mutation,
+		"-f", "id="+strings.TrimSpace(string(id)))
+	if _, err := cmd.Output(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("gh CLI failed: %s", string(exitErr.Stderr))
+		}
+		return fmt.Errorf("failed to run gh CLI: %w", err)
+	}
+	return nil
+}
+
 // FetchNixpkgsPRsWithCursor fetches PRs using cursor-based pagination.
 // It automatically batches requests to respect GitHub's 100-record limit per request.
 // Returns the PRs, the cursor for the next page, and any error.
@@ -128,6 +220,9 @@ func (f *Fetcher) FetchNixpkgsPRsWithCursor(limit int, afterCursor string, baseB
 	currentCursor := afterCursor
 	remaining := limit
 
+	f.progress.Start("Fetching PRs", limit)
+	defer f.progress.Finish()
+
 	batchNum := 0
 	for remaining > 0 {
 		batchNum++
@@ -135,7 +230,7 @@ func (f *Fetcher) FetchNixpkgsPRsWithCursor(limit int, afterCursor string, baseB
 		// Apply rate limiting with exponential backoff
 		delay := f.rateLimiter.Wait()
 		if delay > 0 {
-			fmt.Fprintf(os.Stderr, "⏱️  Rate limiting: waiting %v before batch %d...\n", delay.Round(time.Millisecond), batchNum)
+			f.progress.Step(0, fmt.Sprintf("rate limited %v before batch %d", delay.Round(time.Millisecond), batchNum))
 		}
 
 		batchSize := remaining
@@ -153,6 +248,7 @@ func (f *Fetcher) FetchNixpkgsPRsWithCursor(limit int, afterCursor string, baseB
 		allPRs = append(allPRs, prs...)
 		currentCursor = cursor
 		remaining -= len(prs)
+		f.progress.Step(len(prs), fmt.Sprintf("batch %d", batchNum))
 
 		// If we got fewer PRs than requested, we've reached the end
 		if len(prs) < batchSize {
@@ -163,6 +259,51 @@ func (f *Fetcher) FetchNixpkgsPRsWithCursor(limit int, afterCursor string, baseB
 	return allPRs, currentCursor, nil
 }
 
+// FetchNixpkgsPRsUpdatedSince fetches the PRs updated since the given time,
+// whatever their state, so PRs closed or merged since are returned too with
+// their State. It fails with ErrTooManyUpdates when more than max PRs were
+// updated.
+//
+// This allows updating cached PRs without fetching them all again.
+func (f *Fetcher) FetchNixpkgsPRsUpdatedSince(since time.Time, baseBranch string, max int) ([]PullRequest, error) {
+	const perRequest = 100
+
+	f.progress.Start("Fetching updated PRs", 0)
+	defer f.progress.Finish()
+
+	var updated []PullRequest
+	cursor := ""
+	for batchNum := 1; ; batchNum++ {
+		delay := f.rateLimiter.Wait()
+		if delay > 0 {
+			f.progress.Step(0, fmt.Sprintf("rate limited %v before batch %d", delay.Round(time.Millisecond), batchNum))
+		}
+
+		prs, next, hasNext, err := f.fetchPRPage(perRequest, cursor, baseBranch, true)
+		if err != nil {
+			return nil, err
+		}
+		f.rateLimiter.recordRequest()
+
+		for _, p := range prs {
+			if p.UpdatedAt.Before(since) {
+				// Sorted by update time, older pages are unchanged too
+				return updated, nil
+			}
+			updated = append(updated, p)
+		}
+		f.progress.Step(len(prs), fmt.Sprintf("batch %d", batchNum))
+
+		if !hasNext {
+			return updated, nil
+		}
+		if len(updated) >= max {
+			return nil, ErrTooManyUpdates
+		}
+		cursor = next
+	}
+}
+
 // fetchPRBatchWithRetry fetches a batch with retry logic for transient errors
 func (f *Fetcher) fetchPRBatchWithRetry(limit int, afterCursor string, baseBranch string, maxRetries int) ([]PullRequest, string, error) {
 	var lastErr error
@@ -182,9 +323,13 @@ func (f *Fetcher) fetchPRBatchWithRetry(limit int, afterCursor string, baseBranc
 		if attempt < maxRetries {
 			// Exponential backoff: 1s, 2s, 4s
 			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
-			fmt.Fprintf(os.Stderr, "⚠️  GitHub API error (attempt %d/%d): %v\n", attempt, maxRetries, err)
-			fmt.Fprintf(os.Stderr, "   Retrying in %v...\n", backoff)
-			time.Sleep(backoff)
+			f.out.Warning("⚠️  GitHub API error (attempt %d/%d): %v", attempt, maxRetries, err)
+			f.out.Warning("   Retrying in %v...", backoff)
+			select {
+			case <-f.ctx.Done():
+				return nil, "", f.ctx.Err()
+			case <-time.After(backoff):
+			}
 		}
 	}
 
@@ -202,22 +347,34 @@ func isRetryableError(err error) bool {
 		strings.Contains(errStr, "connection reset")
 }
 
-// fetchPRBatch fetches a single batch of PRs (max 100).
+// fetchPRBatch fetches a single batch of open PRs (max 100), newest first.
 func (f *Fetcher) fetchPRBatch(limit int, afterCursor string, baseBranch string) ([]PullRequest, string, error) {
+	prs, cursor, _, err := f.fetchPRPage(limit, afterCursor, baseBranch, false)
+	return prs, cursor, err
+}
+
+// fetchPRPage fetches a single page of PRs (max 100): open PRs newest
+// first, or PRs in any state most recently updated first if updated is
+// true. It also reports whether there is a next page.
+func (f *Fetcher) fetchPRPage(limit int, afterCursor string, baseBranch string, updated bool) ([]PullRequest, string, bool, error) {
 	// Use context with timeout to prevent hanging (30s per batch)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(f.ctx, 30*time.Second)
 	defer cancel()
 
 	// Build GraphQL query with optional base branch filter
+	args := "first: $limit, after: $after, states: OPEN, orderBy: {field: CREATED_AT, direction: DESC}"
+	if updated {
+		args = "first: $limit, after: $after, orderBy: {field: UPDATED_AT, direction: DESC}"
+	}
 	var query string
 	if baseBranch != "" {
 		query = `query($limit: Int!, $after: String, $baseRefName: String!) {
 			repository(owner: "NixOS", name: "nixpkgs") {
-				pullRequests(first: $limit, after: $after, states: OPEN, baseRefName: $baseRefName, orderBy: {field: CREATED_AT, direction: DESC}) {`
+				pullRequests(` + args + `, baseRefName: $baseRefName) {`
 	} else {
 		query = `query($limit: Int!, $after: String) {
 			repository(owner: "NixOS", name: "nixpkgs") {
-				pullRequests(first: $limit, after: $after, states: OPEN, orderBy: {field: CREATED_AT, direction: DESC}) {`
+				pullRequests(` + args + `) {`
 	}
 	query += `
 
@@ -233,6 +390,7 @@ func (f *Fetcher) fetchPRBatch(limit int, afterCursor string, baseBranch string)
 						login
 					}
 					baseRefName
+					state
 					mergeable
 					commits(last: 1) {
 						nodes {
@@ -274,9 +432,9 @@ func (f *Fetcher) fetchPRBatch(limit int, afterCursor string, baseBranch string)
 	output, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, "", fmt.Errorf("gh API failed: %s", string(exitErr.Stderr))
+			return nil, "", false, fmt.Errorf("gh API failed: %s", string(exitErr.Stderr))
 		}
-		return nil, "", fmt.Errorf("failed to run gh API: %w", err)
+		return nil, "", false, fmt.Errorf("failed to run gh API: %w", err)
 	}
 
 	// Parse GraphQL response
@@ -296,6 +454,7 @@ func (f *Fetcher) fetchPRBatch(limit int, afterCursor string, baseBranch string)
 							Login string `json:"login"`
 						} `json:"author"`
 						BaseRefName string `json:"baseRefName"`
+						State       string `json:"state"`
 						Mergeable   string `json:"mergeable"`
 						Commits     struct {
 							Nodes []struct {
@@ -327,7 +486,7 @@ func (f *Fetcher) fetchPRBatch(limit int, afterCursor string, baseBranch string)
 	}
 
 	if err := json.Unmarshal(output, &response); err != nil {
-		return nil, "", fmt.Errorf("failed to parse GraphQL response: %w", err)
+		return nil, "", false, fmt.Errorf("failed to parse GraphQL response: %w", err)
 	}
 
 	// Convert to our PR type
@@ -359,6 +518,7 @@ func (f *Fetcher) fetchPRBatch(limit int, afterCursor string, baseBranch string)
 			URL:         node.URL,
 			Author:      node.Author.Login,
 			BaseRef:     node.BaseRefName,
+			State:       node.State,
 			Mergeable:   node.Mergeable,
 			StatusState: statusState,
 			Labels:      labels,
@@ -368,5 +528,6 @@ func (f *Fetcher) fetchPRBatch(limit int, afterCursor string, baseBranch string)
 		}
 	}
 
-	return prs, response.Data.Repository.PullRequests.PageInfo.EndCursor, nil
+	pageInfo := response.Data.Repository.PullRequests.PageInfo
+	return prs, pageInfo.EndCursor, pageInfo.HasNextPage, nil
 }
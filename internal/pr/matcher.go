@@ -111,6 +111,34 @@ func (m *Matcher) matchPR(pr PullRequest) MatchResult {
 		}
 	}
 
+	// Phase 1b: Service matching against module and test files. Each
+	// service keeps its most confident file whatever the order of files,
+	// and upgrades a module match of the same service instead of
+	// duplicating it.
+	for _, svc := range m.deps.Services {
+		name := deps.ServiceModuleName(svc)
+		var confidence, filePath string
+		for _, file := range pr.Files {
+			if c := matchServiceFile(file.Path, name); confidenceScores[c] > confidenceScores[confidence] {
+				confidence, filePath = c, file.Path
+			}
+		}
+		if confidence == "" {
+			continue
+		}
+
+		if i := result.serviceMatchIndex(svc, name); i >= 0 {
+			result.upgradeMatch(i, confidence, filePath)
+			continue
+		}
+		result.addMatch(Match{
+			Type:       "service",
+			Dependency: svc,
+			FilePath:   filePath,
+			Confidence: confidence,
+		})
+	}
+
 	// Phase 2: Title matching (medium confidence)
 	titleLower := strings.ToLower(pr.Title)
 	for _, pkg := range m.deps.Packages {
@@ -154,6 +182,38 @@ func (m *Matcher) extractPackageName(path string) string {
 	return ""
 }
 
+// matchServiceFile returns the confidence of a service matching a file.
+// Files under nixos/modules/services/ whose path contains a directory or
+// file named after the service are high confidence (the service module
+// itself), while nixos/tests/<name> tests are medium confidence.
+// It returns an empty string when the file isn't related to the service.
+func matchServiceFile(path, name string) string {
+	if name == "" {
+		return ""
+	}
+
+	isNamed := func(component string) bool {
+		return component == name || component == name+".nix"
+	}
+
+	if rest, ok := strings.CutPrefix(path, "nixos/modules/services/"); ok {
+		for _, component := range strings.Split(rest, "/") {
+			if isNamed(component) {
+				return "high"
+			}
+		}
+		return ""
+	}
+
+	if rest, ok := strings.CutPrefix(path, "nixos/tests/"); ok {
+		if isNamed(strings.Split(rest, "/")[0]) {
+			return "medium"
+		}
+	}
+
+	return ""
+}
+
 // extractServiceName extracts service name from a module path
 // e.g., "nixos/modules/services/docker" -> "docker"
 func extractServiceName(modulePath string) string {
@@ -209,25 +269,47 @@ func (m *Matcher) matchesWithWordBoundary(text, pkg string) bool {
 	return pattern.MatchString(text)
 }
 
+// confidenceScores is how much a match of each confidence adds to the score
+var confidenceScores = map[string]int{
+	"high":   100,
+	"medium": 50,
+	"low":    25,
+}
+
 // addMatch adds a match to the result and updates the score
 func (mr *MatchResult) addMatch(match Match) {
 	mr.Matches = append(mr.Matches, match)
 	mr.TotalMatches = len(mr.Matches)
 
-	// Update score based on confidence
-	switch match.Confidence {
-	case "high":
-		mr.Score += 100
-	case "medium":
-		mr.Score += 50
-	case "low":
-		mr.Score += 25
+	// Update score based on confidence, capped at 100
+	mr.Score = min(mr.Score+confidenceScores[match.Confidence], 100)
+}
+
+// upgradeMatch raises the confidence of the i-th match to confidence, found
+// in filePath, if it's higher, and updates the score
+func (mr *MatchResult) upgradeMatch(i int, confidence, filePath string) {
+	if confidenceScores[confidence] <= confidenceScores[mr.Matches[i].Confidence] {
+		return
+	}
+	mr.Matches[i].Confidence = confidence
+	mr.Matches[i].FilePath = filePath
+
+	mr.Score = 0
+	for _, m := range mr.Matches {
+		mr.Score += confidenceScores[m.Confidence]
 	}
+	mr.Score = min(mr.Score, 100)
+}
 
-	// Cap score at 100
-	if mr.Score > 100 {
-		mr.Score = 100
+// serviceMatchIndex returns the index of the match of service svc, either
+// as a service or as a module named moduleName, or -1
+func (mr *MatchResult) serviceMatchIndex(svc, moduleName string) int {
+	for i, m := range mr.Matches {
+		if m.Dependency == svc || (m.Type == "module" && m.Dependency == moduleName) {
+			return i
+		}
 	}
+	return -1
 }
 
 // hasMatch checks if a dependency is already matched
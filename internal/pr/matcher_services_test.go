@@ -0,0 +1,197 @@
+package pr
+
+import (
+	"testing"
+
+	"go.sbr.pm/x/internal/deps"
+)
+
+func TestMatchServiceFile(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		svc  string
+		want string
+	}{
+		{
+			name: "module file named after service",
+			path: "nixos/modules/services/web-servers/nginx/default.nix",
+			svc:  "nginx",
+			want: "high",
+		},
+		{
+			name: "module nix file named after service",
+			path: "nixos/modules/services/hardware/libinput.nix",
+			svc:  "libinput",
+			want: "high",
+		},
+		{
+			name: "test file named after service",
+			path: "nixos/tests/nginx.nix",
+			svc:  "nginx",
+			want: "medium",
+		},
+		{
+			name: "test directory named after service",
+			path: "nixos/tests/nginx/default.nix",
+			svc:  "nginx",
+			want: "medium",
+		},
+		{
+			name: "partial name does not match",
+			path: "nixos/modules/services/web-servers/nginx-sso.nix",
+			svc:  "nginx",
+			want: "",
+		},
+		{
+			name: "test with different name",
+			path: "nixos/tests/nginx-http3.nix",
+			svc:  "nginx",
+			want: "",
+		},
+		{
+			name: "package file does not match",
+			path: "pkgs/by-name/ng/nginx/package.nix",
+			svc:  "nginx",
+			want: "",
+		},
+		{
+			name: "empty service name",
+			path: "nixos/tests/nginx.nix",
+			svc:  "",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchServiceFile(tt.path, tt.svc); got != tt.want {
+				t.Errorf("matchServiceFile(%q, %q) = %q, want %q", tt.path, tt.svc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_matchPR_Services(t *testing.T) {
+	dependencies := &deps.Dependencies{
+		Services: []string{"postgresql", "xserver.libinput"},
+	}
+
+	tests := []struct {
+		name           string
+		pr             PullRequest
+		wantMatchCount int
+		wantConfidence string
+		wantDependency string
+	}{
+		{
+			name: "service module change",
+			pr: PullRequest{
+				Title: "nixos/postgresql: add option",
+				Files: []File{
+					{Path: "nixos/modules/services/databases/postgresql.nix"},
+					{Path: "nixos/tests/postgresql/default.nix"},
+				},
+			},
+			wantMatchCount: 1,
+			wantConfidence: "high",
+			wantDependency: "postgresql",
+		},
+		{
+			name: "nested service module",
+			pr: PullRequest{
+				Title: "nixos/libinput: fix defaults",
+				Files: []File{
+					{Path: "nixos/modules/services/hardware/libinput.nix"},
+				},
+			},
+			wantMatchCount: 1,
+			wantConfidence: "high",
+			wantDependency: "xserver.libinput",
+		},
+		{
+			name: "test only change",
+			pr: PullRequest{
+				Title: "nixosTests.postgresql: fix flakiness",
+				Files: []File{
+					{Path: "nixos/tests/postgresql/default.nix"},
+				},
+			},
+			wantMatchCount: 1,
+			wantConfidence: "medium",
+			wantDependency: "postgresql",
+		},
+		{
+			name: "test before service module",
+			pr: PullRequest{
+				Title: "nixos/postgresql: add option",
+				Files: []File{
+					{Path: "nixos/tests/postgresql/default.nix"},
+					{Path: "nixos/modules/services/databases/postgresql.nix"},
+				},
+			},
+			wantMatchCount: 1,
+			wantConfidence: "high",
+			wantDependency: "postgresql",
+		},
+		{
+			name: "unrelated service",
+			pr: PullRequest{
+				Title: "nixos/mysql: update",
+				Files: []File{
+					{Path: "nixos/modules/services/databases/mysql.nix"},
+				},
+			},
+			wantMatchCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewMatcher(dependencies).matchPR(tt.pr)
+
+			if len(result.Matches) != tt.wantMatchCount {
+				t.Fatalf("matchPR() got %d matches (%+v), want %d", len(result.Matches), result.Matches, tt.wantMatchCount)
+			}
+			if tt.wantMatchCount == 0 {
+				return
+			}
+
+			match := result.Matches[0]
+			if match.Type != "service" {
+				t.Errorf("Match.Type = %q, want %q", match.Type, "service")
+			}
+			if match.Confidence != tt.wantConfidence {
+				t.Errorf("Match.Confidence = %q, want %q", match.Confidence, tt.wantConfidence)
+			}
+			if match.Dependency != tt.wantDependency {
+				t.Errorf("Match.Dependency = %q, want %q", match.Dependency, tt.wantDependency)
+			}
+		})
+	}
+}
+
+func TestMatcher_matchPR_ServiceUpgradesModuleMatch(t *testing.T) {
+	dependencies := &deps.Dependencies{
+		Modules:  []deps.ModulePath{{Path: "nixos/modules/services/libinput"}},
+		Services: []string{"xserver.libinput"},
+	}
+
+	result := NewMatcher(dependencies).matchPR(PullRequest{
+		Title: "nixos/libinput: fix defaults",
+		Files: []File{
+			{Path: "nixos/tests/libinput.nix"},
+			{Path: "nixos/modules/services/hardware/libinput.nix"},
+		},
+	})
+
+	if len(result.Matches) != 1 {
+		t.Fatalf("matchPR() got %d matches (%+v), want 1", len(result.Matches), result.Matches)
+	}
+	if match := result.Matches[0]; match.Type != "module" || match.Confidence != "high" {
+		t.Errorf("match = %+v, want a high confidence module match", match)
+	}
+	if result.Score != 100 {
+		t.Errorf("Score = %d, want 100", result.Score)
+	}
+}
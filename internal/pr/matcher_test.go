@@ -287,6 +287,27 @@ func TestMatchResult_HighestConfidence(t *testing.T) {
 	}
 }
 
+func TestPullRequest_IsSecurity(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   PullRequest
+		want bool
+	}{
+		{name: "severity label", pr: PullRequest{Title: "openssl: 3.0.1 -> 3.0.2", Labels: []string{"1.severity: security"}}, want: true},
+		{name: "CVE in title", pr: PullRequest{Title: "curl: fix CVE-2024-1234"}, want: true},
+		{name: "security in title", pr: PullRequest{Title: "[Backport] sudo: security update"}, want: true},
+		{name: "regular update", pr: PullRequest{Title: "git: 2.43.0 -> 2.44.0", Labels: []string{"10.rebuild-linux: 1-10"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pr.IsSecurity(); got != tt.want {
+				t.Errorf("IsSecurity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestMatchResult_addMatch(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -1,6 +1,7 @@
 package pr
 
 import (
+	"strings"
 	"time"
 )
 
@@ -11,6 +12,7 @@ type PullRequest struct {
 	URL             string    `json:"url"`
 	Author          string    `json:"author"`
 	BaseRef         string    `json:"baseRefName"`   // Base branch (e.g., "master", "staging")
+	State           string    `json:"state,omitempty"` // OPEN, CLOSED or MERGED
 	Mergeable       string    `json:"mergeable"`     // MERGEABLE, CONFLICTING, UNKNOWN
 	StatusState     string    `json:"statusState"`   // SUCCESS, FAILURE, PENDING, ERROR, EXPECTED
 	Labels          []string  `json:"labels"`
@@ -42,6 +44,15 @@ type MatchResult struct {
 	TotalMatches int         `json:"total_matches"`
 }
 
+// confidenceRanks orders confidence levels
+var confidenceRanks = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// ConfidenceRank returns the rank of a confidence level, higher for more
+// confident levels, or 0 for an unknown level
+func ConfidenceRank(confidence string) int {
+	return confidenceRanks[confidence]
+}
+
 // HighestConfidence returns the highest confidence level among all matches
 func (mr *MatchResult) HighestConfidence() string {
 	highest := "low"
@@ -76,6 +87,19 @@ func (pr *PullRequest) NeedsAttention() bool {
 	return pr.HasConflicts() || pr.HasBuildFailure()
 }
 
+// IsSecurity returns true if the PR looks like a security fix: labeled with
+// a security severity (e.g. "1.severity: security") or mentioning a CVE or
+// security in its title
+func (pr *PullRequest) IsSecurity() bool {
+	for _, label := range pr.Labels {
+		if strings.Contains(strings.ToLower(label), "security") {
+			return true
+		}
+	}
+	title := strings.ToLower(pr.Title)
+	return strings.Contains(title, "cve-") || strings.Contains(title, "security")
+}
+
 // FilterByBaseBranch filters PRs to only those targeting the specified base branch
 func FilterByBaseBranch(prs []PullRequest, baseBranch string) []PullRequest {
 	if baseBranch == "" {
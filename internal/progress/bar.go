@@ -0,0 +1,102 @@
+package progress
+
+import (
+	"sync"
+	"time"
+
+	"go.sbr.pm/x/internal/output"
+)
+
+// spinnerFrames are the frames of the spinner shown before a bar
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// barWidth is the width of the bar, brackets included
+const barWidth = 22
+
+// bar shows progress as a spinner and a bar on the status line of a
+// terminal
+type bar struct {
+	tracker
+	out *output.Writer
+
+	mu    sync.Mutex
+	frame int
+	stop  chan struct{}
+	done  sync.WaitGroup
+}
+
+// NewBar returns a Progress showing a spinner, a bar for tasks with a known
+// total, counts and an ETA on the status line of out (see
+// output.Writer.SetStatus). The spinner keeps turning between steps.
+func NewBar(out *output.Writer) Progress {
+	return &bar{out: out}
+}
+
+func (b *bar) Start(task string, total int) {
+	b.Finish()
+	b.start(task, total)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stop = make(chan struct{})
+	b.done.Add(1)
+	go b.spin(b.stop)
+	b.render()
+}
+
+// Step is a no-op once the bar is finished, so late steps don't bring the
+// status line back
+func (b *bar) Step(n int, message string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop == nil {
+		return
+	}
+
+	b.step(n, message)
+	b.render()
+}
+
+func (b *bar) Finish() {
+	b.mu.Lock()
+	if b.stop == nil {
+		b.mu.Unlock()
+		return
+	}
+	close(b.stop)
+	b.stop = nil
+	b.mu.Unlock()
+
+	b.done.Wait()
+	b.finish()
+	b.out.SetStatus("")
+}
+
+// spin animates the spinner until stop is closed
+func (b *bar) spin(stop chan struct{}) {
+	defer b.done.Done()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			b.frame = (b.frame + 1) % len(spinnerFrames)
+			b.render()
+			b.mu.Unlock()
+		}
+	}
+}
+
+// render draws the current state, with b.mu held
+func (b *bar) render() {
+	s := b.current()
+	line := spinnerFrames[b.frame] + " "
+	if s.Total > 0 {
+		line += s.Bar(barWidth) + " "
+	}
+	b.out.SetStatus(line + s.String())
+}
@@ -0,0 +1,200 @@
+// Package progress reports the progress of long-running tasks (fetching PRs,
+// extracting dependencies, ...) without tying them to a user interface.
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.sbr.pm/x/internal/output"
+)
+
+// Progress receives the progress of a task. Implementations are safe for
+// concurrent use.
+type Progress interface {
+	// Start starts a task of total steps, or an unknown number of steps if
+	// total is 0, finishing any previous task
+	Start(task string, total int)
+
+	// Step reports n more steps done, with an optional message describing
+	// the current step. n may be 0 to only update the message.
+	Step(n int, message string)
+
+	// Finish finishes the current task
+	Finish()
+}
+
+// State is a snapshot of the progress of a task
+type State struct {
+	Task     string
+	Done     int
+	Total    int // 0 if unknown
+	Message  string
+	Started  time.Time
+	Finished bool
+}
+
+// Elapsed returns the time since the task started
+func (s State) Elapsed() time.Duration {
+	return time.Since(s.Started)
+}
+
+// ETA estimates the time left, from the average time per step so far. It
+// returns 0 when it can't be estimated.
+func (s State) ETA() time.Duration {
+	if s.Total <= 0 || s.Done <= 0 || s.Done >= s.Total {
+		return 0
+	}
+	perStep := s.Elapsed() / time.Duration(s.Done)
+	return perStep * time.Duration(s.Total-s.Done)
+}
+
+// String describes the state, e.g. "Fetching PRs 200/500 (40%, ETA 12s): batch 3"
+func (s State) String() string {
+	var b strings.Builder
+	b.WriteString(s.Task)
+	switch {
+	case s.Total > 0:
+		fmt.Fprintf(&b, " %d/%d (%d%%", s.Done, s.Total, s.Done*100/s.Total)
+		if eta := s.ETA(); eta > 0 {
+			fmt.Fprintf(&b, ", ETA %s", formatDuration(eta))
+		}
+		b.WriteString(")")
+	case s.Done > 0:
+		fmt.Fprintf(&b, " %d", s.Done)
+	}
+	if s.Message != "" {
+		b.WriteString(": " + s.Message)
+	}
+	return b.String()
+}
+
+// Bar renders a progress bar of the given width, e.g. "[=====>    ]".
+// Tasks with an unknown total render an empty bar.
+func (s State) Bar(width int) string {
+	inner := width - 2
+	if inner < 1 {
+		return ""
+	}
+	filled := 0
+	if s.Total > 0 {
+		filled = min(s.Done*inner/s.Total, inner)
+	}
+	bar := strings.Repeat("=", filled)
+	if filled > 0 && filled < inner {
+		bar = bar[:filled-1] + ">"
+	}
+	return "[" + bar + strings.Repeat(" ", inner-filled) + "]"
+}
+
+// formatDuration formats a duration to the second, e.g. "12s" or "3m5s"
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return "<1s"
+	}
+	return d.Round(time.Second).String()
+}
+
+// tracker keeps the state of the current task
+type tracker struct {
+	mu    sync.Mutex
+	state State
+}
+
+func (t *tracker) start(task string, total int) State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state = State{Task: task, Total: total, Started: time.Now()}
+	return t.state
+}
+
+func (t *tracker) step(n int, message string) State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.Done += n
+	t.state.Message = message
+	return t.state
+}
+
+func (t *tracker) finish() State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.Finished = true
+	return t.state
+}
+
+func (t *tracker) current() State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state
+}
+
+// nop discards progress
+type nop struct{}
+
+func (nop) Start(string, int) {}
+func (nop) Step(int, string)  {}
+func (nop) Finish()           {}
+
+// Nop returns a Progress that discards everything
+func Nop() Progress {
+	return nop{}
+}
+
+// funcProgress calls a function with every state
+type funcProgress struct {
+	tracker
+	fn func(State)
+}
+
+// Func returns a Progress calling fn with the state of the task on every
+// change, e.g. to display it in a user interface
+func Func(fn func(State)) Progress {
+	return &funcProgress{fn: fn}
+}
+
+func (p *funcProgress) Start(task string, total int) { p.fn(p.start(task, total)) }
+func (p *funcProgress) Step(n int, message string)   { p.fn(p.step(n, message)) }
+func (p *funcProgress) Finish()                      { p.fn(p.finish()) }
+
+// logProgress prints progress as plain log lines
+type logProgress struct {
+	tracker
+	out *output.Writer
+}
+
+// NewLog returns a Progress printing a line per step as verbose messages to
+// out, suitable for logs and non-interactive output
+func NewLog(out *output.Writer) Progress {
+	return &logProgress{out: out}
+}
+
+func (p *logProgress) Start(task string, total int) {
+	p.start(task, total)
+}
+
+func (p *logProgress) Step(n int, message string) {
+	p.out.Verbose("%s", p.step(n, message))
+}
+
+func (p *logProgress) Finish() {
+	s := p.finish()
+	if s.Total > 0 || s.Done > 0 {
+		p.out.Verbose("%s: done in %s", s.Task, formatDuration(s.Elapsed()))
+	}
+}
+
+// New returns the Progress suited to out: a bar when a status line can be
+// shown (see NewBar), plain log lines otherwise, and nothing in quiet mode
+func New(out *output.Writer) Progress {
+	switch {
+	case out.Level() < output.LevelNormal:
+		return Nop()
+	case out.StatusEnabled():
+		return NewBar(out)
+	default:
+		return NewLog(out)
+	}
+}
@@ -0,0 +1,159 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sbr.pm/x/internal/output"
+)
+
+func TestState_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+		want  string
+	}{
+		{
+			name:  "unknown total",
+			state: State{Task: "Loading", Started: time.Now()},
+			want:  "Loading",
+		},
+		{
+			name:  "unknown total with count",
+			state: State{Task: "Loading", Done: 3, Message: "foo", Started: time.Now()},
+			want:  "Loading 3: foo",
+		},
+		{
+			name:  "not started",
+			state: State{Task: "Fetching PRs", Total: 500, Started: time.Now()},
+			want:  "Fetching PRs 0/500 (0%)",
+		},
+		{
+			name:  "with ETA",
+			state: State{Task: "Fetching PRs", Done: 200, Total: 500, Message: "batch 3", Started: time.Now().Add(-20 * time.Second)},
+			want:  "Fetching PRs 200/500 (40%, ETA 30s): batch 3",
+		},
+		{
+			name:  "done",
+			state: State{Task: "Fetching PRs", Done: 500, Total: 500, Started: time.Now().Add(-time.Minute)},
+			want:  "Fetching PRs 500/500 (100%)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestState_Bar(t *testing.T) {
+	tests := []struct {
+		done, total int
+		want        string
+	}{
+		{0, 0, "[          ]"},
+		{0, 10, "[          ]"},
+		{5, 10, "[====>     ]"},
+		{10, 10, "[==========]"},
+		{20, 10, "[==========]"},
+	}
+
+	for _, tt := range tests {
+		got := State{Done: tt.done, Total: tt.total}.Bar(12)
+		if got != tt.want {
+			t.Errorf("Bar(%d/%d) = %q, want %q", tt.done, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestFunc(t *testing.T) {
+	var states []State
+	p := Func(func(s State) { states = append(states, s) })
+
+	p.Start("Fetching", 3)
+	p.Step(1, "one")
+	p.Step(2, "two")
+	p.Finish()
+
+	if len(states) != 4 {
+		t.Fatalf("got %d states, want 4", len(states))
+	}
+	last := states[3]
+	if last.Done != 3 || last.Message != "two" || !last.Finished {
+		t.Errorf("last state = %+v, want 3 done, message two, finished", last)
+	}
+	if states[1].Done != 1 || states[1].Finished {
+		t.Errorf("second state = %+v, want 1 done, not finished", states[1])
+	}
+}
+
+func TestNewLog(t *testing.T) {
+	var stderr bytes.Buffer
+	out := output.NewWriter(&bytes.Buffer{}, &stderr, false)
+	out.SetLevel(output.LevelVerbose)
+
+	p := NewLog(out)
+	p.Start("Fetching PRs", 200)
+	p.Step(100, "batch 1")
+	p.Step(100, "batch 2")
+	p.Finish()
+
+	got := stderr.String()
+	for _, want := range []string{"Fetching PRs 100/200 (50%", "batch 1", "Fetching PRs 200/200 (100%): batch 2", "Fetching PRs: done in"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestNewBar(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	out := output.NewWriter(&stdout, &stderr, false)
+	out.EnableStatus(true)
+
+	p := NewBar(out)
+	p.Start("Fetching PRs", 10)
+	p.Step(5, "batch 1")
+	out.Warning("careful")
+	p.Finish()
+
+	got := stderr.String()
+	if !strings.Contains(got, "[=========>          ] Fetching PRs 5/10 (50%") {
+		t.Errorf("bar output missing progress:\n%q", got)
+	}
+	if !strings.Contains(got, "\r\033[Kcareful\n") {
+		t.Errorf("warning not printed above the bar:\n%q", got)
+	}
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("bar not cleared when finished:\n%q", got)
+	}
+
+	// Steps after Finish don't show the bar again
+	stderr.Reset()
+	p.Step(1, "late")
+	if got := stderr.String(); got != "" {
+		t.Errorf("Step() after Finish() wrote %q", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	out := output.NewWriter(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if _, ok := New(out).(*logProgress); !ok {
+		t.Errorf("New() = %T, want log progress without a terminal", New(out))
+	}
+
+	out.EnableStatus(true)
+	if _, ok := New(out).(*bar); !ok {
+		t.Errorf("New() = %T, want bar with a terminal", New(out))
+	}
+
+	out.SetLevel(output.LevelQuiet)
+	if _, ok := New(out).(nop); !ok {
+		t.Errorf("New() = %T, want nop in quiet mode", New(out))
+	}
+}
@@ -24,9 +24,26 @@ type Finder struct {
 	cache *cache.Cache
 }
 
+// cacheBudget bounds the template cache. Keys change daily, so old entries
+// are never read again once expired.
+var cacheBudget = cache.Budget{
+	MaxSize: 16 << 20,
+	MaxAge:  7 * 24 * time.Hour,
+}
+
+// OpenCache opens the gh-pr cache holding templates
+func OpenCache() (*cache.Cache, error) {
+	c, err := cache.New(cache.DefaultTTL, "gh-pr")
+	if err != nil {
+		return nil, err
+	}
+	c.SetBudget(cacheBudget)
+	return c, nil
+}
+
 // NewFinder creates a new template finder
 func NewFinder() (*Finder, error) {
-	c, err := cache.New(cache.DefaultTTL, "gh-pr")
+	c, err := OpenCache()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cache: %w", err)
 	}
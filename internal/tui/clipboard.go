@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoClipboard is returned when no clipboard command is installed
+var ErrNoClipboard = errors.New("no clipboard command found")
+
+// CopyToClipboard copies text to the system clipboard, using the first
+// clipboard command found among wl-copy, xclip and pbcopy.
+func CopyToClipboard(text string) error {
+	var cmd *exec.Cmd
+	switch {
+	case CommandExists("wl-copy"):
+		cmd = exec.Command("wl-copy")
+	case CommandExists("xclip"):
+		cmd = exec.Command("xclip", "-selection", "clipboard")
+	case CommandExists("pbcopy"):
+		cmd = exec.Command("pbcopy")
+	default:
+		return ErrNoClipboard
+	}
+	cmd.Stdin = strings.NewReader(text)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+	return nil
+}
+
+// CommandExists returns whether a command is found in PATH.
+func CommandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
@@ -1,4 +1,5 @@
-package main
+// Package tui provides the styles and helpers shared by terminal UIs.
+package tui
 
 import (
 	"github.com/charmbracelet/lipgloss"
@@ -21,26 +22,26 @@ type Theme struct {
 	MergedFg  lipgloss.Color
 
 	// Background
-	BgColor      lipgloss.Color
-	SelectedBg   lipgloss.Color
-	FocusedBg    lipgloss.Color
-	UnfocusedBg  lipgloss.Color
+	BgColor     lipgloss.Color
+	SelectedBg  lipgloss.Color
+	FocusedBg   lipgloss.Color
+	UnfocusedBg lipgloss.Color
 }
 
 // DefaultTheme returns the default dark theme (Dracula-inspired).
 func DefaultTheme() Theme {
 	return Theme{
-		Accent:      lipgloss.Color("#BD93F9"),
-		TextFg:      lipgloss.Color("#F8F8F2"),
-		MutedFg:     lipgloss.Color("#6272A4"),
-		BorderFg:    lipgloss.Color("#BD93F9"),
-		BorderDim:   lipgloss.Color("#44475A"),
-
-		SuccessFg:   lipgloss.Color("#50FA7B"),
-		ErrorFg:     lipgloss.Color("#FF5555"),
-		WarnFg:      lipgloss.Color("#F1FA8C"),
-		PendingFg:   lipgloss.Color("#FFB86C"),
-		MergedFg:    lipgloss.Color("#BD93F9"), // Purple for merged
+		Accent:    lipgloss.Color("#BD93F9"),
+		TextFg:    lipgloss.Color("#F8F8F2"),
+		MutedFg:   lipgloss.Color("#6272A4"),
+		BorderFg:  lipgloss.Color("#BD93F9"),
+		BorderDim: lipgloss.Color("#44475A"),
+
+		SuccessFg: lipgloss.Color("#50FA7B"),
+		ErrorFg:   lipgloss.Color("#FF5555"),
+		WarnFg:    lipgloss.Color("#F1FA8C"),
+		PendingFg: lipgloss.Color("#FFB86C"),
+		MergedFg:  lipgloss.Color("#BD93F9"), // Purple for merged
 
 		BgColor:     lipgloss.Color("#282A36"),
 		SelectedBg:  lipgloss.Color("#44475A"),